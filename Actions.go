@@ -0,0 +1,119 @@
+package gold
+
+// semantic action which is called when a rule is reduced. values contains the values of the
+// symbols of the rule body in order. The returned value becomes the value of the non-terminal.
+type RuleAction func(rule RuleId, values []interface{}) (interface{}, error)
+
+// semantic action which is called when a terminal is shifted. The returned value becomes the
+// value of the terminal.
+type TerminalAction func(t Terminal) (interface{}, error)
+
+// maps rules and terminals to the semantic actions used by Parser.Evaluate.
+// If the error recovery is enabled, missing terminals and the non-terminals replaced by the
+// recovery evaluate to nil without calling any action.
+type Actions struct {
+	rules     map[RuleId]RuleAction
+	terminals map[SymbolId]TerminalAction
+
+	defaultRule     RuleAction
+	defaultTerminal TerminalAction
+}
+
+// creates an empty set of semantic actions.
+// Without any registered action, terminals evaluate to their Terminal value and rules evaluate
+// to the value of their only symbol or to the []interface{} of all symbol values.
+func NewActions() *Actions {
+	return &Actions{
+		rules:     make(map[RuleId]RuleAction),
+		terminals: make(map[SymbolId]TerminalAction),
+	}
+}
+
+// registers the action which is called when the given rule is reduced
+func (a *Actions) OnRule(id RuleId, action RuleAction) *Actions {
+	a.rules[id] = action
+	return a
+}
+
+// registers the action which is called when a terminal of the given symbol is shifted
+func (a *Actions) OnTerminal(id SymbolId, action TerminalAction) *Actions {
+	a.terminals[id] = action
+	return a
+}
+
+// registers the action which is called for all rules without an action of their own.
+// If the reductions are trimmed, it is not called for rules whose body is a single non-terminal;
+// they evaluate to the value of that non-terminal.
+func (a *Actions) OnAnyRule(action RuleAction) *Actions {
+	a.defaultRule = action
+	return a
+}
+
+// registers the action which is called for all terminals without an action of their own
+func (a *Actions) OnAnyTerminal(action TerminalAction) *Actions {
+	a.defaultTerminal = action
+	return a
+}
+
+func (a *Actions) ruleAction(id RuleId) RuleAction {
+	if a != nil {
+		if action, ok := a.rules[id]; ok && action != nil {
+			return action
+		}
+		return a.defaultRule
+	}
+	return nil
+}
+
+func (a *Actions) terminalAction(id SymbolId) TerminalAction {
+	if a != nil {
+		if action, ok := a.terminals[id]; ok && action != nil {
+			return action
+		}
+		return a.defaultTerminal
+	}
+	return nil
+}
+
+// reducer which calls the semantic actions
+type actionReducer struct {
+	actions    *Actions
+	trimReduce bool
+}
+
+func (ar *actionReducer) shift(t *parserToken) (interface{}, error) {
+	if t.Missing {
+		return nil, nil
+	}
+	terminal := t.toTerminal()
+	if action := ar.actions.terminalAction(terminal.Symbol); action != nil {
+		return action(terminal)
+	}
+	return terminal, nil
+}
+
+func (ar *actionReducer) reduce(rule *rule, values []interface{}) (interface{}, error) {
+	id := RuleId(rule.Index)
+	if ar.actions != nil {
+		if action, ok := ar.actions.rules[id]; ok && action != nil {
+			return action(id, values)
+		}
+	}
+	if ar.trimReduce && len(rule.Symbols) == 1 && rule.Symbols[0].Kind == stNonTerminal {
+		return values[0], nil
+	}
+	if action := ar.actions.ruleAction(id); action != nil {
+		return action(id, values)
+	}
+	if len(values) == 1 {
+		return values[0], nil
+	}
+	return values, nil
+}
+
+func (ar *actionReducer) recovered(nonTerminal *symbol, values []interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (ar *actionReducer) skip(t *parserToken) {
+}
@@ -0,0 +1,173 @@
+package gold_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+// returns the id of the rule of the grammar which is printed as s
+func ruleId(t *testing.T, g gold.Grammar, s string) gold.RuleId {
+	t.Helper()
+	for _, r := range g.Rules() {
+		if r.String() == s {
+			return r.Id
+		}
+	}
+	t.Fatalf("the rule %s is missing", s)
+	return 0
+}
+
+// returns actions which calculate the values printed by the calculator
+func calcActions(t *testing.T, g gold.Grammar) *gold.Actions {
+	binary := func(op func(a, b float64) float64) gold.RuleAction {
+		return func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			return op(values[0].(float64), values[2].(float64)), nil
+		}
+	}
+	return gold.NewActions().
+		OnTerminal(g.SymbolByName("Number").Id, func(t gold.Terminal) (interface{}, error) {
+			return strconv.ParseFloat(t.Text, 64)
+		}).
+		OnRule(ruleId(t, g, "<Expr> ::= <Expr> + <Term>"), binary(func(a, b float64) float64 { return a + b })).
+		OnRule(ruleId(t, g, "<Expr> ::= <Expr> - <Term>"), binary(func(a, b float64) float64 { return a - b })).
+		OnRule(ruleId(t, g, "<Term> ::= <Term> * <Factor>"), binary(func(a, b float64) float64 { return a * b })).
+		OnRule(ruleId(t, g, "<Term> ::= <Term> / <Factor>"), binary(func(a, b float64) float64 { return a / b })).
+		OnRule(ruleId(t, g, "<Factor> ::= ( <Expr> )"), func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			return values[1], nil
+		}).
+		OnRule(ruleId(t, g, "<Factor> ::= - <Factor>"), func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			return -values[1].(float64), nil
+		}).
+		OnRule(ruleId(t, g, "<Factor> ::= Identifier"), func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("unknown variable %s", values[0].(gold.Terminal).Text)
+		}).
+		OnRule(ruleId(t, g, "<Stmt> ::= print <Expr> ;"), func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			return values[1], nil
+		}).
+		OnRule(ruleId(t, g, "<Stmts> ::= <Stmt> <Stmts>"), func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			return append([]float64{values[0].(float64)}, values[1].([]float64)...), nil
+		}).
+		OnRule(ruleId(t, g, "<Stmts> ::= "), func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			return []float64{}, nil
+		})
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []float64
+		err   string
+	}{
+		{"print 1 + 2 * 3;", []float64{7}, ""},
+		{"print (1 + 2) * 3; print -4 / 2 - 1;", []float64{9, -3}, ""},
+		{"", []float64{}, ""},
+		// the errors of the actions are reported at the token which caused the reduction
+		{"print 1 + x;", nil, "unknown variable x at Line 1, Column 12"},
+	}
+	for format, p := range grammarFormats(t) {
+		actions := calcActions(t, p.Grammar())
+		for _, trim := range []bool{false, true} {
+			for _, tt := range tests {
+				v, err := p.Evaluate(context.Background(), strings.NewReader(tt.input), actions, gold.TrimReductions(trim))
+				if tt.err != "" {
+					var pe *gold.ParseError
+					if !errors.As(err, &pe) || pe.Error() != tt.err || pe.Err == nil {
+						t.Errorf("%s trim %v %q: got %v, want the error %s", format, trim, tt.input, err, tt.err)
+					}
+					continue
+				}
+				if err != nil || !reflect.DeepEqual(v, tt.want) {
+					t.Errorf("%s trim %v %q: got %v, %v, want %v", format, trim, tt.input, v, err, tt.want)
+				}
+			}
+		}
+	}
+}
+
+func TestEvaluateDefaultValues(t *testing.T) {
+	for format, p := range grammarFormats(t) {
+		v, err := p.Evaluate(context.Background(), strings.NewReader("print 1;"), nil)
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		// <Program> and <Stmts> evaluate to the values of <Stmt> and of the empty <Stmts>
+		values, ok := v.([]interface{})
+		if !ok || len(values) != 2 {
+			t.Errorf("%s: got %#v", format, v)
+			continue
+		}
+		stmt, ok := values[0].([]interface{})
+		if !ok || len(stmt) != 3 {
+			t.Errorf("%s: the statement is %#v", format, values[0])
+			continue
+		}
+		if num, ok := stmt[1].(gold.Terminal); !ok || num.Text != "1" || num.Name != "Number" {
+			t.Errorf("%s: the number is %#v", format, stmt[1])
+		}
+		if rest, ok := values[1].([]interface{}); !ok || len(rest) != 0 {
+			t.Errorf("%s: the empty statements are %#v", format, values[1])
+		}
+	}
+}
+
+func TestEvaluateTrimReductions(t *testing.T) {
+	for format, p := range grammarFormats(t) {
+		g := p.Grammar()
+		for _, tt := range []struct {
+			trim bool
+			// the rules passed to the default action in the order of the reductions
+			want []string
+		}{
+			{false, []string{
+				"<Factor> ::= Number",
+				"<Term> ::= <Factor>",
+				"<Expr> ::= <Term>",
+				"<Stmt> ::= print <Expr> ;",
+				"<Stmts> ::= ",
+				"<Stmts> ::= <Stmt> <Stmts>",
+			}},
+			// the rules with a single non-terminal are trimmed without calling the default action
+			{true, []string{
+				"<Factor> ::= Number",
+				"<Stmt> ::= print <Expr> ;",
+				"<Stmts> ::= ",
+				"<Stmts> ::= <Stmt> <Stmts>",
+			}},
+		} {
+			var called []string
+			programCalled := false
+			actions := gold.NewActions().
+				OnAnyRule(func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+					called = append(called, g.Rule(rule).String())
+					return rule, nil
+				}).
+				// the action of a rule is called even if the rule is trimmed
+				OnRule(ruleId(t, g, "<Program> ::= <Stmts>"), func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+					programCalled = true
+					return values[0], nil
+				})
+			v, err := p.Evaluate(context.Background(), strings.NewReader("print 1;"), actions, gold.TrimReductions(tt.trim))
+			if err != nil {
+				t.Errorf("%s trim %v: %v", format, tt.trim, err)
+				continue
+			}
+			if !reflect.DeepEqual(called, tt.want) {
+				t.Errorf("%s trim %v: the default action was called for\n%s\nwant\n%s", format, tt.trim, strings.Join(called, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if !programCalled {
+				t.Errorf("%s trim %v: the action of <Program> was not called", format, tt.trim)
+			}
+			if v != ruleId(t, g, "<Stmts> ::= <Stmt> <Stmts>") {
+				t.Errorf("%s trim %v: got the value %v", format, tt.trim, v)
+			}
+		}
+	}
+}
@@ -1,9 +1,10 @@
 package gold
 
 import (
-	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"sort"
 )
 
 const (
@@ -22,69 +23,182 @@ type cgtGrammar struct {
 
 type cgtRecEntryTyp byte
 
-func loadCGTGrammar(rd *bufio.Reader) *cgtGrammar {
-	g := new(cgtGrammar)
+func readCGTTables(rd *grammarFileReader) (*Tables, error) {
+	t := new(Tables)
+	hasCounts := false
 
-	for curRec := range readRecords(rd) {
-		recTyp := recordId((<-curRec).asByte())
+	for {
+		rec, err := rd.readRecord()
+		if err == io.EOF {
+			if err := rd.checkTables(t, hasCounts); err != nil {
+				return nil, err
+			}
+			return t, rd.checkDefined(cgtRIdCharSets, len(t.CharSets), "character set")
+		} else if err != nil {
+			return nil, err
+		}
 
-		switch recTyp {
+		switch rec.typ {
 		case cgtRIdParameters:
-			g.Name = (<-curRec).asString()
-			g.Version = (<-curRec).asString()
-			g.Author = (<-curRec).asString()
-			g.About = (<-curRec).asString()
-			<-curRec
-			<-curRec
+			t.Properties = nil
+			for _, name := range []string{"Name", "Version", "Author", "About"} {
+				t.Properties = append(t.Properties, TableProperty{Name: name, Value: rec.readString()})
+			}
+			t.CaseSensitive = rec.readBool()
+			t.StartSymbol = SymbolId(rec.readInt())
 		case cgtRIdTableCounts:
-			g.symbols = newSymbolTable((<-curRec).asInt(), true)
-			g.charSets = make([]charSet, (<-curRec).asInt())
-			g.rules = newRuleTable((<-curRec).asInt())
-			g.dfaStates = newDFAStateTable((<-curRec).asInt())
-			g.lrStates = newLRStateTable((<-curRec).asInt())
+			if hasCounts {
+				rec.fail(-1, "duplicate table counts")
+				break
+			}
+			hasCounts = true
+			t.Symbols = make([]TableSymbol, rec.readInt())
+			t.CharSets = make([]TableCharSet, rec.readInt())
+			t.Rules = make([]TableRule, rec.readInt())
+			t.DFAStates = make([]TableDFAState, rec.readInt())
+			t.LRStates = make([]TableLRState, rec.readInt())
 		case cgtRIdCharSets:
-			idx := (<-curRec).asInt()
-			g.charSets[idx] = fixedCharSet((<-curRec).asString())
-
+			idx := rec.readIndex(len(t.CharSets), "character set")
+			chars := rec.readString()
+			if rec.err == nil {
+				t.CharSets[idx] = newCGTCharSet(chars)
+			}
 		default:
-			g.goldGrammar.loadRecord(recordId(recTyp), curRec)
+			loadTableRecord(t, rec)
+		}
+		if err := rec.error(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// converts the characters of a cgt character set to ranges
+func newCGTCharSet(chars string) TableCharSet {
+	runes := []int{}
+	for _, r := range chars {
+		if r <= 0xFFFF {
+			runes = append(runes, int(r))
 		}
 	}
+	sort.Ints(runes)
 
-	return g
+	result := TableCharSet{Plane: 0}
+	for _, r := range runes {
+		last := len(result.Ranges) - 1
+		if last >= 0 && int(result.Ranges[last].End)+1 >= r {
+			result.Ranges[last].End = uint16(r)
+		} else {
+			result.Ranges = append(result.Ranges, TableCharRange{Start: uint16(r), End: uint16(r)})
+		}
+	}
+	return result
 }
 
-func (g *cgtGrammar) readTokens(rd io.Reader) <-chan *parserToken {
-	c := make(chan *parserToken)
-	r := newSourceReader(rd)
-	go func() {
-		for {
-			t := g.readToken(r)
-
-			switch t.Symbol.Kind {
-			case stNonTerminal, stTerminal:
-				c <- t
-			case stCommentLine:
-				t.Text += g.readLineComment(r)
-			case stGroupStart:
-				t.Text += g.readBlockComment(r)
-			case stNoise, stGroupEnd:
-				// do nothing
-			case stError, stEnd:
-				c <- t
-				close(c)
-				return
-			}
+// returns the characters of the character set as they are stored in cgt files
+func cgtCharSetString(cs TableCharSet) (string, error) {
+	if cs.Plane != 0 {
+		return "", grammarError("cgt files can only contain characters of the basic multilingual plane")
+	}
+	buf := new(bytes.Buffer)
+	for _, r := range cs.Ranges {
+		if r.Start == 0 {
+			return "", grammarError("cgt files can not contain the character 0 in character sets")
+		}
+		for c := int(r.Start); c <= int(r.End); c++ {
+			buf.WriteRune(rune(c))
 		}
-	}()
-	return c
+	}
+	return string(buf.Bytes()), nil
 }
 
-func (g *cgtGrammar) readBlockComment(r *sourceReader) string {
+// Writes the tables as "GOLD Parser Tables/v1.0" (cgt) file. cgt files can not store lexical groups,
+// so only groups of noise symbols are supported which are stored as block and line comments.
+func (t *Tables) WriteCGT(w io.Writer) error {
+	if err := t.checkCounts(); err != nil {
+		return err
+	}
+
+	t = t.Clone()
+	for _, g := range t.Groups {
+		if int(g.Container) >= len(t.Symbols) || int(g.Start) >= len(t.Symbols) {
+			return grammarError(fmt.Sprintf("the group %s references unknown symbols", g.Name))
+		}
+		if t.Symbols[g.Container].Kind != KindNoise {
+			return grammarError(fmt.Sprintf("the group %s can not be stored in a cgt file", g.Name))
+		}
+		if g.EndingMode == EndingOpen {
+			// groups which are not closed by their end symbol are line comments
+			t.Symbols[g.Start].Kind = KindCommentLine
+		}
+	}
+
+	charSets := make([]string, len(t.CharSets))
+	for idx, cs := range t.CharSets {
+		var err error
+		if charSets[idx], err = cgtCharSetString(cs); err != nil {
+			return err
+		}
+	}
+
+	rw := newRecordWriter(w)
+	rw.writeString(cgtHeader)
+	rw.writeRecord(byte(cgtRIdParameters), t.Property("Name"), t.Property("Version"), t.Property("Author"),
+		t.Property("About"), t.CaseSensitive, uint16(t.StartSymbol))
+	rw.writeRecord(byte(cgtRIdTableCounts), uint16(len(t.Symbols)), uint16(len(t.CharSets)),
+		uint16(len(t.Rules)), uint16(len(t.DFAStates)), uint16(len(t.LRStates)))
+	for idx, cs := range charSets {
+		rw.writeRecord(byte(cgtRIdCharSets), uint16(idx), cs)
+	}
+	writeTableRecords(rw, t)
+	return rw.flush()
+}
+
+type cgtTokenizer struct {
+	grammar *cgtGrammar
+	reader  *sourceReader
+	cfg     *parseConfig
+}
+
+func (g *cgtGrammar) newTokenizer(rd io.Reader, cfg *parseConfig) tokenizer {
+	return &cgtTokenizer{grammar: g, reader: newSourceReader(rd, cfg.limits.MaxInputBytes, cfg.done), cfg: cfg}
+}
+
+func (t *cgtTokenizer) next() *parserToken {
+	token := t.grammar.readToken(t.reader, t.cfg)
+	if token.Err != nil {
+		return token
+	}
+
+	var text string
+	switch token.Symbol.Kind {
+	case stCommentLine:
+		text, token.Err = t.grammar.readLineComment(t.reader, t.cfg, token.Position)
+		token.Text += text
+		token.End = t.reader.Position
+	case stGroupStart:
+		var closed bool
+		text, closed, token.Err = t.grammar.readBlockComment(t.reader, t.cfg, token.Position)
+		token.Text += text
+		token.End = t.reader.Position
+		// the comment is kept, even if the input ends before the end of the comment
+		token.Runaway = !closed
+	}
+	return token
+}
+
+// reads the rest of a block comment which started at start. Returns false if the input
+// ends before the end of the comment.
+func (g *cgtGrammar) readBlockComment(r *sourceReader, cfg *parseConfig, start TextPosition) (string, bool, *LimitError) {
 	buff := new(bytes.Buffer)
 
 	for {
-		token := g.readToken(r)
+		token := g.readToken(r, cfg)
+		if token.Err == nil {
+			token.Err = cfg.limits.checkTokenBytes(start, r)
+		}
+		if token.Err != nil {
+			return "", false, token.Err
+		}
 
 		symbolType := token.Symbol.Kind
 
@@ -92,31 +206,29 @@ func (g *cgtGrammar) readBlockComment(r *sourceReader) string {
 
 		case stEnd, stGroupEnd:
 			buff.WriteString(token.Text)
-			return string(buff.Bytes())
-		case stError:
-			buff.WriteString(token.Text)
-			r.Next()
+			return string(buff.Bytes()), symbolType == stGroupEnd, nil
 		default:
 			buff.WriteString(token.Text)
 		}
 	}
-
-	return string(buff.Bytes())
 }
 
-func (g *cgtGrammar) readLineComment(r *sourceReader) string {
+// reads the rest of a line comment which started at start
+func (g *cgtGrammar) readLineComment(r *sourceReader, cfg *parseConfig, start TextPosition) (string, *LimitError) {
 	result := new(bytes.Buffer)
-	exit := false
 	for r.Next() {
 		if r.Rune == '\n' || r.Rune == '\r' {
-			exit = true
-		} else {
-			if exit {
-				r.UnreadLast()
-				break
-			}
-			result.WriteRune(r.Rune)
+			// the line break is not part of the comment
+			r.UnreadLast()
+			break
+		}
+		if err := cfg.limits.checkTokenBytes(start, r); err != nil {
+			return "", err
 		}
+		result.WriteRune(r.Rune)
+	}
+	if r.err != nil {
+		return "", r.err
 	}
-	return string(result.Bytes())
+	return string(result.Bytes()), nil
 }
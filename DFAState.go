@@ -8,6 +8,7 @@ type dfaEdge struct {
 type dfaTransition func(r rune) (*dfaState, bool)
 
 type dfaState struct {
+	Index            uint16
 	AcceptSymbol     *symbol
 	TransitionVector dfaTransition
 }
@@ -30,6 +31,7 @@ func newDFAStateTable(count uint16) dfaStateTable {
 	var i uint16
 	for i = 0; i < count; i++ {
 		result[i] = new(dfaState)
+		result[i].Index = i
 	}
 	return result
 }
@@ -0,0 +1,182 @@
+package gold
+
+import (
+	"context"
+	"io"
+)
+
+// steps through the tokenizer and the parser. The input is parsed in a goroutine which is
+// paused after each event until the next event is requested by Step, Next or Continue.
+// The methods of a Debugger must not be called concurrently.
+type Debugger struct {
+	events chan *ParseEvent
+	resume chan struct{}
+	cancel context.CancelFunc
+	// starts the goroutine of the parser, nil after it was started
+	start func()
+	// tells if the parser waits for resume
+	paused bool
+
+	tree *Token
+	err  error
+
+	symbols   map[SymbolId]bool
+	rules     map[RuleId]bool
+	states    map[int]bool
+	dfaStates map[int]bool
+}
+
+// creates a debugger which parses the code from the reader with the given options.
+// The parser does not read any input before the first event is requested.
+func NewDebugger(p Parser, r io.Reader, opts ...ParseOption) *Debugger {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Debugger{
+		events:    make(chan *ParseEvent),
+		resume:    make(chan struct{}),
+		cancel:    cancel,
+		symbols:   make(map[SymbolId]bool),
+		rules:     make(map[RuleId]bool),
+		states:    make(map[int]bool),
+		dfaStates: make(map[int]bool),
+	}
+	hook := func(cfg *parseConfig) {
+		previous := cfg.trace
+		cfg.traceChars = true
+		cfg.trace = func(e *ParseEvent) {
+			if previous != nil {
+				previous(e)
+			}
+			select {
+			case d.events <- e.snapshot():
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-d.resume:
+			case <-ctx.Done():
+			}
+		}
+	}
+	d.start = func() {
+		go func() {
+			defer close(d.events)
+			d.tree, d.err = p.ParseContext(ctx, r, append(opts[:len(opts):len(opts)], hook)...)
+		}()
+	}
+	return d
+}
+
+// returns a copy of the event with copies of the stacks
+func (e *ParseEvent) snapshot() *ParseEvent {
+	result := *e
+	for _, s := range []**stack{&result.states, &result.values} {
+		if *s != nil {
+			*s = &stack{nodes: append([]interface{}(nil), (*s).nodes[:(*s).count]...), count: (*s).count}
+		}
+	}
+	return &result
+}
+
+// runs the parser to the next event. Returns false if the parser has finished.
+// The stacks of the returned events stay valid.
+func (d *Debugger) Step() (*ParseEvent, bool) {
+	d.run()
+	if d.paused {
+		d.resume <- struct{}{}
+		d.paused = false
+	}
+	e, ok := <-d.events
+	d.paused = ok
+	return e, ok
+}
+
+// runs the parser to the next event which is not an EventChar of the tokenizer.
+// Returns false if the parser has finished.
+func (d *Debugger) Next() (*ParseEvent, bool) {
+	for {
+		e, ok := d.Step()
+		if !ok || e.Kind != EventChar {
+			return e, ok
+		}
+	}
+}
+
+// runs the parser to the next breakpoint or error. Returns false if the parser has finished.
+func (d *Debugger) Continue() (*ParseEvent, bool) {
+	for {
+		e, ok := d.Step()
+		if !ok || e.Kind == EventError || d.isBreakpoint(e) {
+			return e, ok
+		}
+	}
+}
+
+// stops Continue when a terminal of the symbol is scanned or a non-terminal of the symbol is reduced
+func (d *Debugger) BreakOnSymbol(id SymbolId) {
+	d.symbols[id] = true
+}
+
+// stops Continue in front of the reduction of the rule
+func (d *Debugger) BreakOnRule(id RuleId) {
+	d.rules[id] = true
+}
+
+// stops Continue when the LR state is pushed by a shift or a goto
+func (d *Debugger) BreakOnState(index int) {
+	d.states[index] = true
+}
+
+// stops Continue when the tokenizer moves to the DFA state
+func (d *Debugger) BreakOnDFAState(index int) {
+	d.dfaStates[index] = true
+}
+
+// removes all breakpoints
+func (d *Debugger) ClearBreakpoints() {
+	d.symbols = make(map[SymbolId]bool)
+	d.rules = make(map[RuleId]bool)
+	d.states = make(map[int]bool)
+	d.dfaStates = make(map[int]bool)
+}
+
+// starts the goroutine of the parser unless it is already running
+func (d *Debugger) run() {
+	if d.start != nil {
+		d.start()
+		d.start = nil
+	}
+}
+
+func (d *Debugger) isBreakpoint(e *ParseEvent) bool {
+	switch e.Kind {
+	case EventChar:
+		return d.dfaStates[e.Target]
+	case EventToken:
+		return d.symbols[e.Symbol]
+	case EventReduce:
+		return d.symbols[e.Symbol] || d.rules[e.Rule]
+	case EventShift, EventGoto:
+		return d.states[e.Target]
+	}
+	return false
+}
+
+// runs the parser to the end without stopping at breakpoints and returns the result of
+// Parser.ParseContext.
+func (d *Debugger) Result() (*Token, error) {
+	for {
+		if _, ok := d.Step(); !ok {
+			return d.tree, d.err
+		}
+	}
+}
+
+// aborts the parser and waits until its goroutine has finished
+func (d *Debugger) Close() {
+	d.cancel()
+	// a parser which was never started returns the error of the context
+	d.run()
+	for range d.events {
+	}
+	d.paused = false
+}
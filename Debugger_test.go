@@ -0,0 +1,195 @@
+package gold_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+// the events of the tokenizer and the parser for "x = 1;" without the characters
+var debugEvents = []string{
+	`token Identifier "x" in 0`,
+	`shift Identifier "x": 0 -> 1`,
+	`token Whitespace " " in 1`,
+	`token = "=" in 1`,
+	`shift = "=": 1 -> 6`,
+	`token Whitespace " " in 6`,
+	`token Number "1" in 6`,
+	`shift Number "1": 6 -> 10`,
+	`token ; ";" in 10`,
+	`reduce <Factor> ::= Number in 10`,
+	`goto <Factor>: 6 -> 12`,
+	`reduce <Term> ::= <Factor> in 12`,
+	`goto <Term>: 6 -> 13`,
+	`reduce <Expr> ::= <Term> in 13`,
+	`goto <Expr>: 6 -> 15`,
+	`shift ; ";": 15 -> 23`,
+	`token EOF "" in 23`,
+	`reduce <Stmt> ::= Identifier = <Expr> ; in 23`,
+	`goto <Stmt>: 0 -> 4`,
+	`reduce <Stmts> ::=  in 4`,
+	`goto <Stmts>: 4 -> 14`,
+	`reduce <Stmts> ::= <Stmt> <Stmts> in 14`,
+	`goto <Stmts>: 0 -> 5`,
+	`reduce <Program> ::= <Stmts> in 5`,
+	`goto <Program>: 0 -> 3`,
+	`accept EOF "" in 3`,
+}
+
+func TestDebuggerSteps(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	d := gold.NewDebugger(p, strings.NewReader("x = 1;"))
+	defer d.Close()
+
+	var events, chars []string
+	var shift *gold.ParseEvent
+	for {
+		e, ok := d.Step()
+		if !ok {
+			break
+		}
+		if e.Kind == gold.EventChar {
+			chars = append(chars, string(e.Char))
+			continue
+		}
+		events = append(events, e.String())
+		if shift == nil && e.Kind == gold.EventShift && e.Terminal.Text == "1" {
+			shift = e
+		}
+	}
+	if !reflect.DeepEqual(events, debugEvents) {
+		t.Errorf("got the events\n%s\nwant\n%s", strings.Join(events, "\n"), strings.Join(debugEvents, "\n"))
+	}
+	if got := strings.Join(chars, ""); got != "x = 1;" {
+		t.Errorf("got the characters %q", got)
+	}
+	// the stacks of the events stay valid after the parser continued
+	if shift == nil {
+		t.Fatal("the shift of 1 is missing")
+	}
+	if got := fmt.Sprint(shift.StateStack()); got != "[0 1 6]" {
+		t.Errorf("got the state stack %s", got)
+	}
+	if values := shift.ValueStack(); len(values) != 2 || values[1].(*gold.Token).Text != "=" {
+		t.Errorf("got the value stack %v", values)
+	}
+
+	tree, err := d.Result()
+	if err != nil || treeString(tree) != "(<Program> (<Stmts> (<Stmt> x = (<Expr> (<Term> (<Factor> 1))) ;) (<Stmts>)))" {
+		t.Errorf("got %s, %v", treeString(tree), err)
+	}
+	if _, ok := d.Step(); ok {
+		t.Error("Step returned an event after the parser finished")
+	}
+}
+
+func TestDebuggerNext(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	d := gold.NewDebugger(p, strings.NewReader("x = 1;"))
+	defer d.Close()
+	for i, want := range debugEvents {
+		e, ok := d.Next()
+		if !ok || e.String() != want {
+			t.Fatalf("got the event %d %v, want %s", i, e, want)
+		}
+	}
+	if _, ok := d.Next(); ok {
+		t.Error("Next returned an event after the parser finished")
+	}
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	g := p.Grammar()
+	d := gold.NewDebugger(p, strings.NewReader("x = 1; print x $; y = 2;"), gold.RecoverErrors())
+	defer d.Close()
+
+	d.BreakOnSymbol(g.SymbolByName("Number").Id)
+	d.BreakOnRule(ruleId(t, g, "<Expr> ::= <Term>"))
+	d.BreakOnState(23)
+	d.BreakOnDFAState(14)
+	var got []string
+	for {
+		e, ok := d.Continue()
+		if !ok {
+			break
+		}
+		got = append(got, e.String())
+		if len(got) == 4 {
+			// the number 2 and the second <Expr> no longer stop Continue
+			d.ClearBreakpoints()
+		}
+	}
+	want := []string{
+		`token Number "1" in 6`,
+		`reduce <Expr> ::= <Term> in 13`,
+		`shift ; ";": 15 -> 23`,
+		`char 'p': DFA 0 -> 14, lexeme "p"`,
+		// the errors stop Continue even without breakpoints
+		`error in 9: Unknown Token "$"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got the events\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	var errs gold.ParseErrors
+	if tree, err := d.Result(); tree == nil || !errors.As(err, &errs) || len(errs) != 1 {
+		t.Errorf("got %v, %v", tree, err)
+	}
+}
+
+func TestDebuggerClose(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	d := gold.NewDebugger(p, strings.NewReader("x = 1; print x;"))
+	if _, ok := d.Next(); !ok {
+		t.Fatal("no event")
+	}
+	d.Close()
+	if _, ok := d.Step(); ok {
+		t.Error("Step returned an event after Close")
+	}
+	if tree, err := d.Result(); tree != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, %v, want context.Canceled", tree, err)
+	}
+}
+
+// counts the reads of the input
+type countingReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.r.Read(p)
+}
+
+func TestDebuggerStartsOnDemand(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+
+	// a debugger which is closed before the first event never reads the input
+	r := &countingReader{r: strings.NewReader("x = 1;")}
+	d := gold.NewDebugger(p, r)
+	if r.reads != 0 {
+		t.Errorf("the input was read %d times before the first event", r.reads)
+	}
+	d.Close()
+	if r.reads != 0 {
+		t.Errorf("the input was read %d times by a closed debugger", r.reads)
+	}
+	if tree, err := d.Result(); tree != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, %v, want context.Canceled", tree, err)
+	}
+
+	r = &countingReader{r: strings.NewReader("x = 1;")}
+	d = gold.NewDebugger(p, r)
+	defer d.Close()
+	if e, ok := d.Step(); !ok || e.Kind != gold.EventChar || r.reads == 0 {
+		t.Errorf("got the first event %v after %d reads", e, r.reads)
+	}
+}
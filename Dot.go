@@ -0,0 +1,167 @@
+package gold
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// the number of ranges of a character set which are shown in the labels of DFA edges
+const dotCharSetRanges = 6
+
+// writes the syntax tree as Graphviz DOT graph. Non-terminals are labeled with their symbol
+// and terminals with their symbol and text. Tokens created by the error recovery are red.
+// The graphs can be rendered e.g. as SVG with dot -Tsvg.
+func WriteTreeDot(w io.Writer, tree *Token) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph tree {\n\tordering=out;\n\tnode [fontname=\"Helvetica\"];\n")
+	if tree != nil {
+		id := 0
+		writeTreeDotNode(bw, tree, &id)
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func writeTreeDotNode(w *bufio.Writer, tok *Token, id *int) int {
+	node := *id
+	*id++
+
+	attrs := ""
+	if tok.IsError {
+		attrs = ", color=red, fontcolor=red"
+	}
+	if tok.IsTerminal {
+		fmt.Fprintf(w, "\tn%d [shape=box, label=%s%s];\n", node, dotString(tok.Name+"\n"+strconv.Quote(tok.Text)), attrs)
+	} else {
+		fmt.Fprintf(w, "\tn%d [shape=ellipse, label=%s, tooltip=%s%s];\n", node, dotString(tok.Name), dotString(tok.Text), attrs)
+	}
+	for _, sub := range tok.Tokens {
+		if sub != nil {
+			fmt.Fprintf(w, "\tn%d -> n%d;\n", node, writeTreeDotNode(w, sub, id))
+		}
+	}
+	return node
+}
+
+// writes the DFA of the tokenizer as Graphviz DOT graph. Accepting states are drawn with a
+// double border and the accepted symbol. Edges are labeled with a summary of their
+// character sets.
+func (t *Tables) WriteDFADot(w io.Writer) error {
+	if err := t.Validate().Err(); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph dfa {\n\trankdir=LR;\n\tnode [fontname=\"Helvetica\", shape=circle];\n")
+	fmt.Fprintf(bw, "\tstart [shape=point];\n\tstart -> s%d;\n", t.InitialDFAState)
+	for idx, s := range t.DFAStates {
+		if s.Accept {
+			fmt.Fprintf(bw, "\ts%d [shape=doublecircle, label=%s];\n", idx, dotString(fmt.Sprintf("%d\n%s", idx, t.symbolName(s.AcceptSymbol))))
+		} else {
+			fmt.Fprintf(bw, "\ts%d [label=\"%d\"];\n", idx, idx)
+		}
+	}
+	for idx, s := range t.DFAStates {
+		// edges to the same target are merged into one
+		var targets []uint16
+		labels := make(map[uint16][]string)
+		for _, e := range s.Edges {
+			if _, ok := labels[e.Target]; !ok {
+				targets = append(targets, e.Target)
+			}
+			labels[e.Target] = append(labels[e.Target], charSetSummary(t.CharSets[e.CharSet]))
+		}
+		for _, target := range targets {
+			fmt.Fprintf(bw, "\ts%d -> s%d [label=%s];\n", idx, target, dotString(strings.Join(labels[target], "\n")))
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// writes the LALR automaton of the parser as Graphviz DOT graph. Shift actions are drawn as
+// solid edges labeled with the terminal and goto actions as dashed edges labeled with the
+// non-terminal. The reduce and accept actions are listed in the states with their lookahead.
+func (t *Tables) WriteLRDot(w io.Writer) error {
+	if err := t.Validate().Err(); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph lr {\n\tnode [fontname=\"Helvetica\", shape=box];\n")
+	fmt.Fprintf(bw, "\tstart [shape=point];\n\tstart -> s%d;\n", t.InitialLRState)
+	for idx, s := range t.LRStates {
+		// the lookahead of the reductions grouped by rule
+		var rules []uint16
+		lookahead := make(map[uint16][]string)
+		var accept []string
+		for _, a := range s.Actions {
+			switch a.Action {
+			case ActionReduce:
+				if _, ok := lookahead[a.Target]; !ok {
+					rules = append(rules, a.Target)
+				}
+				lookahead[a.Target] = append(lookahead[a.Target], t.symbolName(a.Symbol))
+			case ActionAccept:
+				accept = append(accept, t.symbolName(a.Symbol))
+			}
+		}
+
+		label := dotEscape(fmt.Sprintf("State %d", idx)) + "\\n"
+		for _, r := range rules {
+			label += dotEscape(fmt.Sprintf("reduce %s on %s", t.ruleString(RuleId(r)), strings.Join(lookahead[r], " "))) + "\\l"
+		}
+		if len(accept) > 0 {
+			label += dotEscape("accept on "+strings.Join(accept, " ")) + "\\l"
+		}
+		attrs := ""
+		if len(accept) > 0 {
+			attrs = ", peripheries=2"
+		}
+		fmt.Fprintf(bw, "\ts%d [label=\"%s\"%s];\n", idx, label, attrs)
+	}
+	for idx, s := range t.LRStates {
+		for _, a := range s.Actions {
+			switch a.Action {
+			case ActionShift:
+				fmt.Fprintf(bw, "\ts%d -> s%d [label=%s];\n", idx, a.Target, dotString(t.symbolName(a.Symbol)))
+			case ActionGoto:
+				fmt.Fprintf(bw, "\ts%d -> s%d [label=%s, style=dashed];\n", idx, a.Target, dotString(t.symbolName(a.Symbol)))
+			}
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// returns a short description of the character set like 'a'-'z' '_' ...
+func charSetSummary(cs TableCharSet) string {
+	parts := make([]string, 0, dotCharSetRanges+1)
+	for i, r := range cs.Ranges {
+		if i == dotCharSetRanges {
+			parts = append(parts, fmt.Sprintf("(+%d)", len(cs.Ranges)-i))
+			break
+		}
+		start := rune(cs.Plane)<<16 | rune(r.Start)
+		end := rune(cs.Plane)<<16 | rune(r.End)
+		if start == end {
+			parts = append(parts, strconv.QuoteRune(start))
+		} else {
+			parts = append(parts, strconv.QuoteRune(start)+"-"+strconv.QuoteRune(end))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// escapes the text for a DOT string
+func dotEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	s = strings.Replace(s, "\"", "\\\"", -1)
+	return strings.Replace(s, "\n", "\\n", -1)
+}
+
+// returns the text as quoted DOT string
+func dotString(s string) string {
+	return "\"" + dotEscape(s) + "\""
+}
@@ -0,0 +1,78 @@
+package gold_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// compares the output with the golden file in testdata or updates it
+func checkGoldenFile(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := "testdata/" + name
+	if *update {
+		if err := os.WriteFile(path, got, 0666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the output differs from %s, run the tests with -update to update it:\n%s", path, got)
+	}
+}
+
+func TestWriteTreeDot(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	tree, err := p.ParseContext(context.Background(), strings.NewReader("x = ; print -y;"),
+		gold.RecoverErrors(), gold.TrimReductions(true))
+	if tree == nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := gold.WriteTreeDot(buf, tree); err != nil {
+		t.Fatal(err)
+	}
+	checkGoldenFile(t, "tree.dot", buf.Bytes())
+
+	buf.Reset()
+	if err := gold.WriteTreeDot(buf, nil); err != nil || buf.String() != "digraph tree {\n\tordering=out;\n\tnode [fontname=\"Helvetica\"];\n}\n" {
+		t.Errorf("got %q, %v for an empty tree", buf.String(), err)
+	}
+}
+
+func TestWriteAutomatonDot(t *testing.T) {
+	tables := buildTables(t, calcGrammar(t))
+	for _, tt := range []struct {
+		name  string
+		write func(*gold.Tables, *bytes.Buffer) error
+	}{
+		{"dfa.dot", func(t *gold.Tables, buf *bytes.Buffer) error { return t.WriteDFADot(buf) }},
+		{"lr.dot", func(t *gold.Tables, buf *bytes.Buffer) error { return t.WriteLRDot(buf) }},
+	} {
+		buf := new(bytes.Buffer)
+		if err := tt.write(tables, buf); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		checkGoldenFile(t, tt.name, buf.Bytes())
+
+		invalid := tables.Clone()
+		invalid.InitialLRState = uint16(len(invalid.LRStates))
+		var ve *gold.ValidationError
+		if err := tt.write(invalid, new(bytes.Buffer)); !errors.As(err, &ve) {
+			t.Errorf("%s: got %v for invalid tables", tt.name, err)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package gold
 
 import (
-	"bufio"
+	"fmt"
 	"io"
 )
 
@@ -11,8 +11,6 @@ const (
 
 type egtGrammar struct {
 	goldGrammar
-
-	groups groupTable
 }
 
 const (
@@ -22,158 +20,256 @@ const (
 	egtRIdTableCount recordId = 116 // t
 )
 
-func loadEGTGrammar(rd *bufio.Reader) *egtGrammar {
-	g := new(egtGrammar)
-
-	records := readRecords(rd)
+func readEGTTables(rd *grammarFileReader) (*Tables, error) {
+	t := new(Tables)
+	hasCounts := false
 
-	for curRec := range records {
-		// process record...
-		recTyp := recordId((<-curRec).asByte())
+	for {
+		rec, err := rd.readRecord()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
 
-		switch recTyp {
+		switch rec.typ {
 		case egtRIdProperty:
-			idx := (<-curRec).asInt()
-			<-curRec // skip name...
-			value := (<-curRec).asString()
-
-			switch idx {
-			case 0:
-				g.Name = value
-			case 1:
-				g.Version = value
-			case 2:
-				g.Author = value
-			case 3:
-				g.Version = value
-			default:
-				// Not needed...
+			idx := int(rec.readInt())
+			name, value := rec.readString(), rec.readString()
+			if rec.err != nil {
+				break
 			}
+			for len(t.Properties) <= idx {
+				t.Properties = append(t.Properties, TableProperty{})
+			}
+			t.Properties[idx] = TableProperty{Name: name, Value: value}
 		case egtRIdTableCount:
-			g.symbols = newSymbolTable((<-curRec).asInt(), true)
-			g.charSets = make([]charSet, (<-curRec).asInt())
-			g.rules = newRuleTable((<-curRec).asInt())
-			g.dfaStates = newDFAStateTable((<-curRec).asInt())
-			g.lrStates = newLRStateTable((<-curRec).asInt())
-			g.groups = newGroupTable((<-curRec).asInt(), true)
+			if hasCounts {
+				rec.fail(-1, "duplicate table counts")
+				break
+			}
+			hasCounts = true
+			t.Symbols = make([]TableSymbol, rec.readInt())
+			t.CharSets = make([]TableCharSet, rec.readInt())
+			t.Rules = make([]TableRule, rec.readInt())
+			t.DFAStates = make([]TableDFAState, rec.readInt())
+			t.LRStates = make([]TableLRState, rec.readInt())
+			t.Groups = make([]TableGroup, rec.readInt())
 		case egtRIdCharSet:
-			cs := new(rangedCharSet)
-			g.charSets[(<-curRec).asInt()] = cs
-			cs.plane = (<-curRec).asInt()
+			idx := rec.readIndex(len(t.CharSets), "character set")
+			cs := TableCharSet{Plane: rec.readInt()}
 
-			rangeCnt := (<-curRec).asInt()
-			<-curRec // reserved...
+			rangeCnt := int(rec.readInt())
+			rec.skip() // reserved...
 
-			cs.ranges = make(charRanges, rangeCnt)
-			var i uint16
-			for i = 0; i < rangeCnt; i++ {
-				cs.ranges[i].start = (<-curRec).asInt()
-				cs.ranges[i].end = (<-curRec).asInt()
+			if rec.err == nil && rangeCnt*2 != rec.remaining() {
+				rec.fail(-1, fmt.Sprintf("the character set has %d ranges but %d fields", rangeCnt, rec.remaining()))
+			}
+			cs.Ranges = make([]TableCharRange, rec.readGroups(2))
+			for i := range cs.Ranges {
+				cs.Ranges[i].Start = rec.readInt()
+				cs.Ranges[i].End = rec.readInt()
+			}
+			if rec.err == nil {
+				t.CharSets[idx] = cs
 			}
-			cs.optimize()
-
 		case egtRIdGroup:
-			group := g.groups[(<-curRec).asInt()]
-			group.Name = (<-curRec).asString()
-
-			group.Container = g.symbols[(<-curRec).asInt()]
-			group.Container.Group = group // link back
-
-			group.Start = g.symbols[(<-curRec).asInt()]
-			group.Start.Group = group // link back
+			idx := rec.readIndex(len(t.Groups), "group")
+			group := TableGroup{
+				Name:        rec.readString(),
+				Container:   SymbolId(rec.readIndex(len(t.Symbols), "symbol")),
+				Start:       SymbolId(rec.readIndex(len(t.Symbols), "symbol")),
+				End:         SymbolId(rec.readIndex(len(t.Symbols), "symbol")),
+				AdvanceMode: AdvanceMode(rec.readInt()),
+				EndingMode:  EndingMode(rec.readInt()),
+			}
 
-			group.End = g.symbols[(<-curRec).asInt()]
-			group.End.Group = group // link back
+			rec.skip() // reserved
+			nestingCnt := int(rec.readInt())
+			if rec.err == nil && nestingCnt != rec.remaining() {
+				rec.fail(-1, fmt.Sprintf("the group has %d nested groups but %d fields", nestingCnt, rec.remaining()))
+			}
+			group.Nesting = make([]GroupId, rec.remaining())
+			for i := range group.Nesting {
+				group.Nesting[i] = GroupId(rec.readIndex(len(t.Groups), "group"))
+			}
+			if rec.err == nil {
+				t.Groups[idx] = group
+			}
+		default:
+			loadTableRecord(t, rec)
+		}
+		if err := rec.error(); err != nil {
+			return nil, err
+		}
+	}
 
-			group.AdvanceMode = advanceMode((<-curRec).asInt())
-			group.EndingMode = endingMode((<-curRec).asInt())
+	if err := rd.checkTables(t, hasCounts); err != nil {
+		return nil, err
+	}
+	if err := rd.checkDefined(egtRIdCharSet, len(t.CharSets), "character set"); err != nil {
+		return nil, err
+	}
+	if err := rd.checkDefined(egtRIdGroup, len(t.Groups), "group"); err != nil {
+		return nil, err
+	}
 
-			<-curRec // reserved
-			nestingCnt := (<-curRec).asInt()
-			group.Nested = newGroupTable(nestingCnt, false)
-			var i uint16
-			for i = 0; i < nestingCnt; i++ {
-				group.Nested[i] = g.groups[(<-curRec).asInt()]
+	// egt files do not contain the start symbol, but the initial state has a goto to the
+	// state which accepts the start symbol.
+	if int(t.InitialLRState) < len(t.LRStates) {
+		for _, a := range t.LRStates[t.InitialLRState].Actions {
+			if a.Action == ActionGoto && int(a.Target) < len(t.LRStates) && t.LRStates[a.Target].accepts() {
+				t.StartSymbol = a.Symbol
 			}
-		default:
-			g.goldGrammar.loadRecord(recordId(recTyp), curRec)
 		}
 	}
 
-	return g
+	return t, nil
 }
 
-func (g *egtGrammar) readTokens(rd io.Reader) <-chan *parserToken {
-	result := make(chan *parserToken, 100)
+// Writes the tables as "GOLD Parser Tables/v5.0" (egt) file.
+func (t *Tables) WriteEGT(w io.Writer) error {
+	if err := t.checkCounts(); err != nil {
+		return err
+	}
+
+	rw := newRecordWriter(w)
+	rw.writeString(egtHeader)
+	for idx, p := range t.Properties {
+		rw.writeRecord(byte(egtRIdProperty), uint16(idx), p.Name, p.Value)
+	}
+	rw.writeRecord(byte(egtRIdTableCount), uint16(len(t.Symbols)), uint16(len(t.CharSets)),
+		uint16(len(t.Rules)), uint16(len(t.DFAStates)), uint16(len(t.LRStates)), uint16(len(t.Groups)))
+	for idx, cs := range t.CharSets {
+		entries := []interface{}{byte(egtRIdCharSet), uint16(idx), cs.Plane, uint16(len(cs.Ranges)), nil}
+		for _, r := range cs.Ranges {
+			entries = append(entries, r.Start, r.End)
+		}
+		rw.writeRecord(entries...)
+	}
+	for idx, g := range t.Groups {
+		entries := []interface{}{byte(egtRIdGroup), uint16(idx), g.Name, uint16(g.Container), uint16(g.Start),
+			uint16(g.End), uint16(g.AdvanceMode), uint16(g.EndingMode), nil, uint16(len(g.Nesting))}
+		for _, n := range g.Nesting {
+			entries = append(entries, uint16(n))
+		}
+		rw.writeRecord(entries...)
+	}
+	writeTableRecords(rw, t)
+	return rw.flush()
+}
 
-	sr := newSourceReader(rd)
+type egtTokenizer struct {
+	grammar    *egtGrammar
+	reader     *sourceReader
+	groupStack *stack
+	cfg        *parseConfig
+}
 
-	groupStack := newStack()
+func (g *egtGrammar) newTokenizer(rd io.Reader, cfg *parseConfig) tokenizer {
+	return &egtTokenizer{
+		grammar:    g,
+		reader:     newSourceReader(rd, cfg.limits.MaxInputBytes, cfg.done),
+		groupStack: newStack(),
+		cfg:        cfg,
+	}
+}
 
-	go func() {
+func (t *egtTokenizer) next() *parserToken {
+	g, sr, groupStack := t.grammar, t.reader, t.groupStack
 
-		nestGroup := false
-		for {
-			read := g.readToken(sr)
-			if read.Symbol.Kind == stEnd {
-				// EOF always stops the loop. The caller method (parse) can flag a runaway group error.
-				result <- read
-				break
+	nestGroup := false
+	for {
+		read := g.readToken(sr, t.cfg)
+		if read.Err == nil && groupStack.Len() > 0 {
+			// the groups are limited as a whole
+			read.Err = t.cfg.limits.checkTokenBytes(groupStack.nodes[0].(*parserToken).Position, sr)
+		}
+		if read.Err != nil {
+			return read
+		}
+		if read.Symbol.Kind == stEnd {
+			if groupStack.Len() == 0 {
+				return read
 			}
-			// Groups (comments, etc.)
-			// The logic - to determine if a group should be nested - requires that the top
-			// of the stack and the symbol's linked group need to be looked at. Both of these
-			// can be unset. So, this section sets a boolean and avoids errors. We will use
-			// this boolean in the logic chain below.
-			if read.Symbol.Kind == stGroupStart {
+			// the input ends within a group. The group is closed, so that its text is kept,
+			// and flagged as runaway unless all open groups end at the end of a line.
+			runaway := false
+			for {
+				pop := groupStack.Pop().(*parserToken)
+				runaway = runaway || pop.Symbol.Group.End.Kind == stGroupEnd
 				if groupStack.Len() == 0 {
-					nestGroup = true
-				} else {
-					nestGroup = groupStack.Peek().(*parserToken).Symbol.Group.Nested.contains(read.Symbol.Group)
+					pop.Symbol = pop.Symbol.Group.Container
+					pop.Runaway = runaway
+					return pop
 				}
+				parent := groupStack.Peek().(*parserToken)
+				parent.Text += pop.Text
+				parent.End = pop.End
+			}
+		}
+		// Groups (comments, etc.)
+		// The logic - to determine if a group should be nested - requires that the top
+		// of the stack and the symbol's linked group need to be looked at. Both of these
+		// can be unset. So, this section sets a boolean and avoids errors. We will use
+		// this boolean in the logic chain below.
+		if read.Symbol.Kind == stGroupStart {
+			if groupStack.Len() == 0 {
+				nestGroup = true
 			} else {
-				nestGroup = false
+				nestGroup = groupStack.Peek().(*parserToken).Symbol.Group.Nested.contains(read.Symbol.Group)
 			}
+		} else {
+			nestGroup = false
+		}
 
-			// Logic chain
-			if nestGroup {
-				groupStack.Push(read)
-			} else if groupStack.Len() == 0 {
-				// The token is ready to be analyzed
-				result <- read
-			} else if groupStack.Peek().(*parserToken).Symbol.Group.End == read.Symbol {
-				// End the current group
-				pop := groupStack.Pop().(*parserToken)
+		// Logic chain
+		if nestGroup {
+			if max := t.cfg.limits.MaxGroupDepth; max > 0 && groupStack.Len() >= max {
+				read.Err = &LimitError{Kind: LimitGroupDepth, Limit: max, Position: read.Position}
+				return read
+			}
+			groupStack.Push(read)
+		} else if groupStack.Len() == 0 {
+			// The token is ready to be analyzed
+			return read
+		} else if groupStack.Peek().(*parserToken).Symbol.Group.End == read.Symbol {
+			// End the current group
+			pop := groupStack.Pop().(*parserToken)
 
-				// Ending logic
-				if pop.Symbol.Group.EndingMode == emClosed {
-					pop.Text = pop.Text + read.Text
-				}
-				if groupStack.Len() == 0 {
-					// We are out of the group. Return pop'd token which contains all the group text
-					pop.Symbol = pop.Symbol.Group.Container
-					result <- pop
-				} else {
-					// Append group text to parent
-					groupStack.Peek().(*parserToken).Text += pop.Text
-				}
+			// Ending logic
+			if pop.Symbol.Group.EndingMode == emClosed {
+				pop.Text = pop.Text + read.Text
+				pop.End = read.End
 			} else {
-				// We are in a group, Append to the Token on the top of the stack.
-				// Take into account the Token group mode
-				top := groupStack.Peek().(*parserToken)
-				if top.Symbol.Group.AdvanceMode == amToken {
-					// Append all text
-					top.Text += read.Text
-				} else {
-					// Append one character
-					runes := []rune(read.Text)
-					top.Text += string(runes[0])
-					sr.UnreadAll(runes[1:])
-				}
+				// leave the ending symbol on the input queue
+				sr.unreadRunes(read.runes)
+			}
+			if groupStack.Len() == 0 {
+				// We are out of the group. Return pop'd token which contains all the group text
+				pop.Symbol = pop.Symbol.Group.Container
+				return pop
+			} else {
+				// Append group text to parent
+				parent := groupStack.Peek().(*parserToken)
+				parent.Text += pop.Text
+				parent.End = pop.End
+			}
+		} else {
+			// We are in a group, Append to the Token on the top of the stack.
+			// Take into account the Token group mode
+			top := groupStack.Peek().(*parserToken)
+			if top.Symbol.Group.AdvanceMode == amToken {
+				// Append all text
+				top.Text += read.Text
+				top.End = read.End
+			} else {
+				// Append one character
+				first := read.runes[0]
+				top.Text += string(first.Rune)
+				top.End = first.Position.advance(first.Rune, first.Size)
+				sr.unreadRunes(read.runes[1:])
 			}
 		}
-		close(result)
-	}()
-
-	return result
+	}
 }
@@ -1,6 +1,6 @@
 package gold
 
-import "bytes"
+import "fmt"
 
 type GrammarInformation struct {
 	Name    string
@@ -24,9 +24,17 @@ type goldGrammar struct {
 	dfaStates dfaStateTable
 	lrStates  lrStateTable
 	charSets  []charSet
+	groups    groupTable
 
 	errorSymbol *symbol
 	endSymbol   *symbol
+
+	// the tables the grammar was created from
+	tables *Tables
+}
+
+func (g *goldGrammar) getTables() *goldGrammar {
+	return g
 }
 
 func (g *goldGrammar) getInitialDfaState() *dfaState {
@@ -37,44 +45,74 @@ func (g *goldGrammar) getInitialLRState() *lrState {
 	return g.lrStates[g.initialLRState]
 }
 
-func (g *goldGrammar) readToken(r *sourceReader) *parserToken {
+// reads the longest token the DFA accepts. The transitions are traced and counted as
+// configured by cfg.
+func (g *goldGrammar) readToken(r *sourceReader, cfg *parseConfig) *parserToken {
 
 	dfa := g.getInitialDfaState()
 
-	tText := new(bytes.Buffer)
+	read := make([]sourceRune, 0, 16)
+	acceptedLen := 0
 
 	result := new(parserToken)
 	result.Text = ""
 	result.Symbol = g.errorSymbol
 	result.Position = r.Position
-	for {
-		if !r.Next() {
-			if r.Rune == 0 && tText.Len() == 0 {
-				result.Text = ""
-				result.Symbol = g.endSymbol
-			}
+	for r.Next() {
+		nextState, ok := dfa.TransitionVector(r.Rune)
+		if !ok {
+			r.UnreadLast()
+			break
+		}
+		read = append(read, r.last)
+		if err := cfg.limits.checkTokenBytes(result.Position, r); err != nil {
+			result.Err = err
 			return result
 		}
 
-		nextState, ok := dfa.TransitionVector(r.Rune)
-		if ok {
-			tText.WriteRune(r.Rune)
+		previous := dfa
+		dfa = nextState
+		if dfa.AcceptSymbol != nil {
+			result.Symbol = dfa.AcceptSymbol
+			acceptedLen = len(read)
+		}
+		if cfg.stats != nil {
+			cfg.stats.DFATransitions++
+		}
+		if cfg.traceChars {
+			cfg.trace(&ParseEvent{Kind: EventChar, Terminal: lexeme(result, read), State: int(previous.Index),
+				Target: int(dfa.Index), Char: r.Rune})
+		}
+	}
+	if r.err != nil {
+		result.Err = r.err
+		return result
+	}
 
-			dfa = nextState
-			if dfa.AcceptSymbol != nil {
-				result.Text = string(tText.Bytes())
-				result.Symbol = dfa.AcceptSymbol
-			}
-		} else {
-			if result.Symbol == g.errorSymbol {
-				tText.WriteRune(r.Rune)
-			} else {
-				r.UnreadLast()
+	if acceptedLen == 0 {
+		// nothing was accepted, so the first character is an unknown token.
+		if len(read) == 0 {
+			if !r.Next() {
+				result.Symbol = g.endSymbol
+				result.End = r.Position
+				return result
 			}
-			break
+			read = append(read, r.last)
 		}
+		acceptedLen = 1
+	}
+
+	// everything behind the longest accepted token is read again.
+	r.unreadRunes(read[acceptedLen:])
+
+	text := make([]rune, acceptedLen)
+	for i, sr := range read[:acceptedLen] {
+		text[i] = sr.Rune
 	}
-	result.Text = string(tText.Bytes())
+	last := read[acceptedLen-1]
+	result.Text = string(text)
+	result.runes = read[:acceptedLen:acceptedLen]
+	result.End = last.Position.advance(last.Rune, last.Size)
 
 	return result
 }
@@ -89,88 +127,109 @@ const (
 	rIdSymbol    recordId = 83 // S
 )
 
-func (g *goldGrammar) loadRecord(recId recordId, record recordEntry) {
-	switch recId {
+// reads the records which are shared by cgt and egt files into the tables
+func loadTableRecord(t *Tables, rec *record) {
+	switch rec.typ {
 	case rIdInitial:
-		g.initialDFAState = (<-record).asInt()
-		g.initialLRState = (<-record).asInt()
+		dfa := rec.readIndex(len(t.DFAStates), "DFA state")
+		lr := rec.readIndex(len(t.LRStates), "LR state")
+		if rec.err == nil {
+			t.InitialDFAState, t.InitialLRState = dfa, lr
+		}
 	case rIdLRTables:
-		idx := (<-record).asInt()
-		(<-record) // reserved...
-
-		actions := record.readTillEnd()
-		actnCount := len(actions) / 4
-
-		lrstate := g.lrStates[idx]
-		lrstate.Index = idx
-
-		lrstate.Actions = make(lrActionTable)
-		for i := 0; i < actnCount; i++ {
-			symb := g.symbols[actions[4*i+0].asInt()]
-
-			actn := new(lrAction)
-			lrstate.Actions[symb] = actn
-			actn.Symbol = symb
-			actn.Action = action(actions[4*i+1].asInt())
-			targetIdx := actions[4*i+2].asInt()
-			actn.TargetRule = nil
-			actn.TargetState = nil
-
-			switch actn.Action {
-			case actionShift, actionGoto:
-				actn.TargetState = g.lrStates[targetIdx]
-			case actionReduce:
-				actn.TargetRule = g.rules[targetIdx]
+		idx := rec.readIndex(len(t.LRStates), "LR state")
+		rec.skip() // reserved...
+
+		actions := make([]TableLRAction, rec.readGroups(4))
+		for i := range actions {
+			actions[i].Symbol = SymbolId(rec.readIndex(len(t.Symbols), "symbol"))
+			actions[i].Action = ActionKind(rec.readInt())
+			switch actions[i].Action {
+			case ActionShift, ActionGoto:
+				actions[i].Target = rec.readIndex(len(t.LRStates), "LR state")
+			case ActionReduce:
+				actions[i].Target = rec.readIndex(len(t.Rules), "rule")
+			case ActionAccept:
+				actions[i].Target = rec.readInt()
+			default:
+				rec.fail(rec.pos-1, fmt.Sprintf("unknown LR action %d", actions[i].Action))
 			}
-
+			rec.skip() // reserved...
+		}
+		if rec.err == nil {
+			t.LRStates[idx].Actions = actions
 		}
 	case rIdRules:
-		r := g.rules[(<-record).asInt()]
-		r.NonTerminal = g.symbols[(<-record).asInt()]
-		<-record // Field No 3 is reserved...
-		symbols := record.readTillEnd()
+		idx := rec.readIndex(len(t.Rules), "rule")
+		head := SymbolId(rec.readIndex(len(t.Symbols), "symbol"))
+		rec.skip() // Field No 3 is reserved...
 
-		r.Symbols = newSymbolTable(uint16(len(symbols)), false)
-
-		for idx, entry := range symbols {
-			r.Symbols[idx] = g.symbols[entry.asInt()]
+		symbols := make([]SymbolId, rec.remaining())
+		for i := range symbols {
+			symbols[i] = SymbolId(rec.readIndex(len(t.Symbols), "symbol"))
+		}
+		if rec.err == nil {
+			t.Rules[idx] = TableRule{Head: head, Symbols: symbols}
 		}
-		g.rules[r.Index] = r
 	case rIdSymbol:
-		idx := (<-record).asInt()
-		g.symbols[idx].Index = idx
-		g.symbols[idx].Name = (<-record).asString()
-		g.symbols[idx].Kind = symbolType((<-record).asInt())
-
-		switch g.symbols[idx].Kind {
-		case stEnd:
-			g.endSymbol = g.symbols[idx]
-		case stError:
-			g.errorSymbol = g.symbols[idx]
+		idx := rec.readIndex(len(t.Symbols), "symbol")
+		name := rec.readString()
+		kind := SymbolKind(rec.readInt())
+		if rec.err == nil && kind > KindError {
+			rec.fail(rec.pos-1, fmt.Sprintf("unknown symbol kind %d", kind))
+		}
+		if rec.err == nil {
+			t.Symbols[idx] = TableSymbol{Name: name, Kind: kind}
 		}
 	case rIdDFAStates:
-		idx := (<-record).asInt()
-		state := g.dfaStates[idx]
-
-		if (<-record).asBool() {
-			state.AcceptSymbol = g.symbols[(<-record).asInt()]
+		idx := rec.readIndex(len(t.DFAStates), "DFA state")
+		state := TableDFAState{Accept: rec.readBool()}
+		if state.Accept {
+			state.AcceptSymbol = SymbolId(rec.readIndex(len(t.Symbols), "symbol"))
 		} else {
-			(<-record) // not used...
-			state.AcceptSymbol = nil
+			rec.skip() // not used...
 		}
-		(<-record) // Reserved...
+		rec.skip() // Reserved...
 
-		edges := record.readTillEnd()
-		edgeCount := len(edges) / 3
-		dfaedges := make([]dfaEdge, edgeCount)
-
-		for i := 0; i < len(edges)/3; i++ {
-			dfaedges[i].CharSet = g.charSets[edges[3*i+0].asInt()]
-			dfaedges[i].Target = g.dfaStates[edges[3*i+1].asInt()]
+		state.Edges = make([]TableDFAEdge, rec.readGroups(3))
+		for i := range state.Edges {
+			state.Edges[i].CharSet = rec.readIndex(len(t.CharSets), "character set")
+			state.Edges[i].Target = rec.readIndex(len(t.DFAStates), "DFA state")
+			rec.skip() // reserved...
+		}
+		if rec.err == nil {
+			t.DFAStates[idx] = state
 		}
-
-		state.TransitionVector = newTransitionVector(dfaedges)
 	default:
-		record.readTillEnd() // skip this record...
+		// skip this record...
+	}
+}
+
+// writes the records which are shared by cgt and egt files
+func writeTableRecords(w *recordWriter, t *Tables) {
+	for idx, s := range t.Symbols {
+		w.writeRecord(byte(rIdSymbol), uint16(idx), s.Name, uint16(s.Kind))
+	}
+	for idx, r := range t.Rules {
+		entries := []interface{}{byte(rIdRules), uint16(idx), uint16(r.Head), nil}
+		for _, s := range r.Symbols {
+			entries = append(entries, uint16(s))
+		}
+		w.writeRecord(entries...)
+	}
+	w.writeRecord(byte(rIdInitial), t.InitialDFAState, t.InitialLRState)
+	for idx, state := range t.DFAStates {
+		entries := []interface{}{byte(rIdDFAStates), uint16(idx), state.Accept, uint16(state.AcceptSymbol), nil}
+		for _, e := range state.Edges {
+			entries = append(entries, e.CharSet, e.Target, nil)
+		}
+		w.writeRecord(entries...)
+	}
+	for idx, state := range t.LRStates {
+		entries := []interface{}{byte(rIdLRTables), uint16(idx), nil}
+		for _, a := range state.Actions {
+			entries = append(entries, uint16(a.Symbol), uint16(a.Action), a.Target, nil)
+		}
+		w.writeRecord(entries...)
 	}
 }
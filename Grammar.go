@@ -0,0 +1,269 @@
+package gold
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// the id of a lexical group
+type GroupId uint16
+
+// describes how a lexical group advances over its content
+type AdvanceMode uint16
+
+const (
+	AdvanceToken     AdvanceMode = AdvanceMode(amToken)     // The group advances a token at a time.
+	AdvanceCharacter AdvanceMode = AdvanceMode(amCharacter) // The group advances by just one character at a time.
+)
+
+// describes what happens to the ending symbol of a lexical group
+type EndingMode uint16
+
+const (
+	EndingOpen   EndingMode = EndingMode(emOpen)   // The ending symbol is left on the input queue.
+	EndingClosed EndingMode = EndingMode(emClosed) // The ending symbol is consumed and part of the group.
+)
+
+// describes a symbol of a grammar
+type Symbol struct {
+	Id   SymbolId
+	Name string
+	Kind SymbolKind
+	// the lexical group which is started, ended or contained by the symbol or nil
+	Group *Group
+}
+
+// returns the name of the symbol. The names of non-terminals are enclosed in angle brackets.
+func (s *Symbol) String() string {
+	if s.Kind == KindNonTerminal {
+		return fmt.Sprintf("<%s>", s.Name)
+	}
+	return s.Name
+}
+
+// describes a production rule of a grammar
+type Rule struct {
+	Id RuleId
+	// the non-terminal which is produced by the rule
+	Head *Symbol
+	// the symbols of the rule body, empty for rules which produce nothing
+	Symbols []*Symbol
+}
+
+// returns the rule in BNF notation
+func (r *Rule) String() string {
+	buf := new(bytes.Buffer)
+
+	buf.WriteString(r.Head.String())
+	buf.WriteString(" ::= ")
+	for idx, s := range r.Symbols {
+		if idx > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(s.String())
+	}
+	return string(buf.Bytes())
+}
+
+// describes a lexical group like a block comment
+type Group struct {
+	Id   GroupId
+	Name string
+	// the symbol which is returned for the whole group
+	Container *Symbol
+	// the symbol which starts the group
+	Start *Symbol
+	// the symbol which ends the group
+	End         *Symbol
+	AdvanceMode AdvanceMode
+	EndingMode  EndingMode
+	// the groups which can be nested inside of the group
+	Nested []*Group
+}
+
+// read-only view on the tables of a loaded grammar.
+// The returned values are shared and must not be modified.
+type Grammar interface {
+	// returns the information stored in the grammar file
+	Information() GrammarInformation
+
+	// returns all symbols ordered by their id
+	Symbols() []*Symbol
+	// returns the symbol with the given id or nil
+	Symbol(id SymbolId) *Symbol
+	// returns the first symbol with the given name or nil. Non-terminals can also be
+	// looked up by their name enclosed in angle brackets to tell them apart from terminals.
+	SymbolByName(name string) *Symbol
+
+	// returns all rules ordered by their id
+	Rules() []*Rule
+	// returns the rule with the given id or nil
+	Rule(id RuleId) *Rule
+
+	// returns all lexical groups ordered by their id. Grammars from cgt files have no groups.
+	Groups() []*Group
+	// returns the group with the given id or nil
+	Group(id GroupId) *Group
+	// returns the group with the given name or nil
+	GroupByName(name string) *Group
+
+	// returns the number of states of the DFA
+	DFAStateCount() int
+	// returns the number of states of the LALR state machine
+	LRStateCount() int
+
+	// checks the consistency of the grammar tables and reports unreachable states and unused symbols and rules
+	Validate() *ValidationReport
+}
+
+type grammarView struct {
+	info      GrammarInformation
+	symbols   []*Symbol
+	rules     []*Rule
+	groups    []*Group
+	dfaStates int
+	lrStates  int
+	tables    *Tables
+}
+
+func newGrammarView(g *goldGrammar) *grammarView {
+	result := &grammarView{
+		info:      g.GrammarInformation,
+		symbols:   make([]*Symbol, len(g.symbols)),
+		rules:     make([]*Rule, len(g.rules)),
+		groups:    make([]*Group, len(g.groups)),
+		dfaStates: len(g.dfaStates),
+		lrStates:  len(g.lrStates),
+		tables:    g.tables,
+	}
+
+	for i, s := range g.symbols {
+		result.symbols[i] = &Symbol{
+			Id:   SymbolId(i),
+			Name: s.Name,
+			Kind: SymbolKind(s.Kind),
+		}
+	}
+	symbolOf := func(s *symbol) *Symbol {
+		if s == nil || int(s.Index) >= len(result.symbols) {
+			return nil
+		}
+		return result.symbols[s.Index]
+	}
+
+	for i, grp := range g.groups {
+		result.groups[i] = &Group{
+			Id:          GroupId(i),
+			Name:        grp.Name,
+			Container:   symbolOf(grp.Container),
+			Start:       symbolOf(grp.Start),
+			End:         symbolOf(grp.End),
+			AdvanceMode: AdvanceMode(grp.AdvanceMode),
+			EndingMode:  EndingMode(grp.EndingMode),
+		}
+	}
+	for i, grp := range g.groups {
+		nested := make([]*Group, 0, len(grp.Nested))
+		for _, n := range grp.Nested {
+			if n != nil && int(n.Index) < len(result.groups) {
+				nested = append(nested, result.groups[n.Index])
+			}
+		}
+		result.groups[i].Nested = nested
+	}
+	for i, s := range g.symbols {
+		if s.Group != nil && int(s.Group.Index) < len(result.groups) {
+			result.symbols[i].Group = result.groups[s.Group.Index]
+		}
+	}
+
+	for i, r := range g.rules {
+		body := make([]*Symbol, len(r.Symbols))
+		for j, s := range r.Symbols {
+			body[j] = symbolOf(s)
+		}
+		result.rules[i] = &Rule{
+			Id:      RuleId(i),
+			Head:    symbolOf(r.NonTerminal),
+			Symbols: body,
+		}
+	}
+
+	return result
+}
+
+func (gv *grammarView) Information() GrammarInformation {
+	return gv.info
+}
+
+func (gv *grammarView) Symbols() []*Symbol {
+	return append([]*Symbol(nil), gv.symbols...)
+}
+
+func (gv *grammarView) Symbol(id SymbolId) *Symbol {
+	if int(id) < len(gv.symbols) {
+		return gv.symbols[id]
+	}
+	return nil
+}
+
+func (gv *grammarView) SymbolByName(name string) *Symbol {
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") && len(name) > 2 {
+		inner := name[1 : len(name)-1]
+		for _, s := range gv.symbols {
+			if s.Kind == KindNonTerminal && s.Name == inner {
+				return s
+			}
+		}
+	}
+	for _, s := range gv.symbols {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func (gv *grammarView) Rules() []*Rule {
+	return append([]*Rule(nil), gv.rules...)
+}
+
+func (gv *grammarView) Rule(id RuleId) *Rule {
+	if int(id) < len(gv.rules) {
+		return gv.rules[id]
+	}
+	return nil
+}
+
+func (gv *grammarView) Groups() []*Group {
+	return append([]*Group(nil), gv.groups...)
+}
+
+func (gv *grammarView) Group(id GroupId) *Group {
+	if int(id) < len(gv.groups) {
+		return gv.groups[id]
+	}
+	return nil
+}
+
+func (gv *grammarView) GroupByName(name string) *Group {
+	for _, grp := range gv.groups {
+		if grp.Name == name {
+			return grp
+		}
+	}
+	return nil
+}
+
+func (gv *grammarView) DFAStateCount() int {
+	return gv.dfaStates
+}
+
+func (gv *grammarView) LRStateCount() int {
+	return gv.lrStates
+}
+
+func (gv *grammarView) Validate() *ValidationReport {
+	return gv.tables.Validate()
+}
@@ -2,40 +2,71 @@ package gold
 
 import (
 	"bufio"
+	"fmt"
+	"io"
 	"unicode/utf16"
 )
 
-type cgtRecEntry struct {
-	typ   cgtRecEntryTyp
-	value interface{}
-}
+// the type of an entry of a grammar file record
+type EntryType byte
+
+const (
+	EntryEmpty  EntryType = EntryType(etEmpty)  // an empty entry, used for reserved fields
+	EntryBool   EntryType = EntryType(etBool)   // a boolean
+	EntryByte   EntryType = EntryType(etByte)   // a single byte
+	EntryInt16  EntryType = EntryType(etInt16)  // an unsigned 16 bit integer
+	EntryString EntryType = EntryType(etString) // a zero terminated UTF-16 string
+)
 
-func (re cgtRecEntry) asString() string {
-	if re.typ == etString {
-		return re.value.(string)
+func (et EntryType) String() string {
+	switch et {
+	case EntryEmpty:
+		return "Empty"
+	case EntryBool:
+		return "Boolean"
+	case EntryByte:
+		return "Byte"
+	case EntryInt16:
+		return "Integer"
+	case EntryString:
+		return "String"
 	}
-	return ""
+	return fmt.Sprintf("EntryType(%d)", byte(et))
 }
 
-func (re cgtRecEntry) asByte() byte {
-	if re.typ == etByte {
-		return re.value.(byte)
-	}
-	return 0
+// describes a structural problem of a grammar file
+type GrammarFileError struct {
+	Message string
+	// the byte offset in the grammar file where the problem was found
+	Offset int64
+	// the number of the record starting at 0 or -1 for the file header
+	Record int
+	// the type of the record like 'S' for symbols or 0 if it is unknown
+	RecordType byte
+	// the index of the entry in the record starting at 0 or -1 if the problem is not related to an entry
+	Field int
+	// the expected and the actual type of the entry, if the entry has the wrong type
+	Expected, Actual EntryType
+	// the error returned by the reader like io.ErrUnexpectedEOF or nil
+	Err error
 }
 
-func (re cgtRecEntry) asInt() uint16 {
-	if re.typ == etInt16 {
-		return uint16(re.value.(uint16))
+func (e *GrammarFileError) Error() string {
+	where := "header"
+	if e.Record >= 0 {
+		where = fmt.Sprintf("record %d", e.Record)
+		if e.RecordType != 0 {
+			where += fmt.Sprintf(" (%q)", e.RecordType)
+		}
+		if e.Field >= 0 {
+			where += fmt.Sprintf(", field %d", e.Field)
+		}
 	}
-	return 0
+	return fmt.Sprintf("invalid grammar file: %s at %s, offset %d", e.Message, where, e.Offset)
 }
 
-func (re cgtRecEntry) asBool() bool {
-	if re.typ == etBool {
-		return re.value.(bool)
-	}
-	return false
+func (e *GrammarFileError) Unwrap() error {
+	return e.Err
 }
 
 const (
@@ -46,23 +77,75 @@ const (
 	etString
 )
 
-func readUInt16(r *bufio.Reader) (uint16, error) {
-	b1, err1 := r.ReadByte()
-	b2, err2 := r.ReadByte()
+type cgtRecEntry struct {
+	typ   cgtRecEntryTyp
+	value interface{}
+	// the byte offset of the entry in the grammar file
+	offset int64
+}
+
+// Reads the tables of a cgt or egt grammar file. Returns a *GrammarFileError if the file is
+// truncated or malformed.
+func ReadTables(r io.Reader) (*Tables, error) {
+	rd := &grammarFileReader{rd: bufio.NewReader(r), record: -1, defined: make(map[recordId]map[uint16]bool)}
+
+	head, err := rd.readString()
+	if err != nil {
+		return nil, rd.fail(-1, "the header is missing or truncated", err)
+	}
+
+	switch head {
+	case cgtHeader:
+		return readCGTTables(rd)
+	case egtHeader:
+		return readEGTTables(rd)
+	}
+	return nil, rd.fail(-1, "unknown grammar file format: "+head, nil)
+}
+
+// reads the records of a grammar file and tracks the offset for error messages
+type grammarFileReader struct {
+	rd     *bufio.Reader
+	offset int64
+	record int
+	// the indices of the table entries which are defined by the records
+	defined map[recordId]map[uint16]bool
+}
 
-	if err1 != nil {
-		return 0, err1
-	} else if err2 != nil {
-		return 0, err2
+func (r *grammarFileReader) fail(field int, msg string, err error) *GrammarFileError {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		msg = fmt.Sprintf("%s: %v", msg, err)
 	}
+	return &GrammarFileError{Message: msg, Offset: r.offset, Record: r.record, Field: field, Err: err}
+}
 
+func (r *grammarFileReader) readByte() (byte, error) {
+	b, err := r.rd.ReadByte()
+	if err == nil {
+		r.offset++
+	}
+	return b, err
+}
+
+func (r *grammarFileReader) readUInt16() (uint16, error) {
+	b1, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	b2, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
 	return uint16(b2)<<8 | uint16(b1), nil
 }
 
-func readString(r *bufio.Reader) (string, error) {
+func (r *grammarFileReader) readString() (string, error) {
 	result := make([]uint16, 0)
 	for {
-		v, err := readUInt16(r)
+		v, err := r.readUInt16()
 		if err != nil {
 			return "", err
 		}
@@ -75,89 +158,232 @@ func readString(r *bufio.Reader) (string, error) {
 	return string(utf16.Decode(result)), nil
 }
 
-func readRecordEntry(r *bufio.Reader) (*cgtRecEntry, error) {
-	eTypS, err := r.ReadByte()
+func (r *grammarFileReader) readEntry(field int) (cgtRecEntry, error) {
+	entry := cgtRecEntry{offset: r.offset}
+	eTypS, err := r.readByte()
 	if err != nil {
-		return nil, err
+		return entry, r.fail(field, "the record is truncated", err)
 	}
 
 	switch eTypS {
 	case 69: // E
-		return &cgtRecEntry{typ: etEmpty, value: nil}, nil
+		entry.typ = etEmpty
 	case 66: // B
-		val, err := r.ReadByte()
-		return &cgtRecEntry{typ: etBool, value: val == 1}, err
+		var val byte
+		val, err = r.readByte()
+		entry.typ, entry.value = etBool, val == 1
 	case 98: // b
-		val, err := r.ReadByte()
-		return &cgtRecEntry{typ: etByte, value: val}, err
+		entry.typ = etByte
+		entry.value, err = r.readByte()
 	case 73: // I
-		val, err := readUInt16(r)
-		return &cgtRecEntry{typ: etInt16, value: val}, err
+		entry.typ = etInt16
+		entry.value, err = r.readUInt16()
 	case 83: // S
-		val, err := readString(r)
-		return &cgtRecEntry{typ: etString, value: val}, err
+		entry.typ = etString
+		entry.value, err = r.readString()
+	default:
+		r.offset--
+		return entry, r.fail(field, fmt.Sprintf("unknown entry type %q", eTypS), nil)
 	}
-
-	return &cgtRecEntry{typ: etEmpty, value: nil}, nil
+	if err != nil {
+		return entry, r.fail(field, "the record is truncated", err)
+	}
+	return entry, nil
 }
 
-type recordEntry (<-chan *cgtRecEntry)
+// reads the next record. Returns io.EOF if there are no more records.
+func (r *grammarFileReader) readRecord() (*record, error) {
+	for {
+		start := r.offset
+		typ, err := r.readByte()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		r.record++
+		if err != nil {
+			return nil, r.fail(-1, "unable to read the record", err)
+		}
+		if typ != 'M' {
+			r.offset = start
+			return nil, r.fail(-1, fmt.Sprintf("unknown record format %q", typ), nil)
+		}
+
+		entryCnt, err := r.readUInt16()
+		if err != nil {
+			return nil, r.fail(-1, "the record is truncated", err)
+		}
+		if entryCnt == 0 {
+			continue // skip empty records
+		}
+
+		rec := &record{index: r.record, offset: start, entries: make([]cgtRecEntry, entryCnt)}
+		for i := range rec.entries {
+			if rec.entries[i], err = r.readEntry(i); err != nil {
+				err.(*GrammarFileError).RecordType = rec.entryType()
+				return nil, err
+			}
+		}
+		rec.typ = recordId(rec.readByte())
+		if rec.err != nil {
+			return nil, rec.err
+		}
+		if len(rec.entries) > 1 {
+			if idx, ok := rec.entries[1].value.(uint16); ok {
+				if r.defined[rec.typ] == nil {
+					r.defined[rec.typ] = make(map[uint16]bool)
+				}
+				r.defined[rec.typ][idx] = true
+			}
+		}
+		return rec, nil
+	}
+}
 
-func (re recordEntry) readTillEnd() []*cgtRecEntry {
-	result := make([]*cgtRecEntry, 256)
-	idx := 0
-	for x := range re {
-		if idx >= cap(result) { // if necessary, reallocate
-			// allocate double what's needed, for future growth.
-			newSlice := make([]*cgtRecEntry, (idx+1)*2)
-			copy(newSlice, result)
-			result = newSlice
+// checks that all entries of a table are defined by a record after the last record is read
+func (r *grammarFileReader) checkDefined(typ recordId, count int, table string) error {
+	for i := 0; i < count; i++ {
+		if !r.defined[typ][uint16(i)] {
+			r.record++
+			return r.fail(-1, fmt.Sprintf("%s %d is not defined", table, i), io.ErrUnexpectedEOF)
 		}
-		result[idx] = x
-		idx++
 	}
-	result = result[0:idx]
-	return result
+	return nil
 }
 
-func readRecords(r *bufio.Reader) <-chan recordEntry {
-	result := make(chan recordEntry, 10)
+// checks that the records of the tables which are shared by cgt and egt files are complete
+func (r *grammarFileReader) checkTables(t *Tables, hasCounts bool) error {
+	if !hasCounts {
+		r.record++
+		return r.fail(-1, "the table counts are missing", io.ErrUnexpectedEOF)
+	}
+	if len(r.defined[rIdInitial]) == 0 {
+		r.record++
+		return r.fail(-1, "the initial states are missing", io.ErrUnexpectedEOF)
+	}
+	if err := r.checkDefined(rIdSymbol, len(t.Symbols), "symbol"); err != nil {
+		return err
+	}
+	if err := r.checkDefined(rIdRules, len(t.Rules), "rule"); err != nil {
+		return err
+	}
+	if err := r.checkDefined(rIdDFAStates, len(t.DFAStates), "DFA state"); err != nil {
+		return err
+	}
+	return r.checkDefined(rIdLRTables, len(t.LRStates), "LR state")
+}
 
-	go func() {
-		for {
-			typ, err := r.ReadByte()
-			if err != nil {
-				close(result)
-				return
-			}
+// a record of a grammar file. The entries are read in order and the first problem
+// is stored in err. After a problem all reads return zero values.
+type record struct {
+	index   int
+	offset  int64
+	typ     recordId
+	entries []cgtRecEntry
+	pos     int
+	err     *GrammarFileError
+}
 
-			if string(typ) == "M" {
-				entryCnt, err := readUInt16(r)
-				if err != nil {
-					close(result)
-					return
-				}
-				if entryCnt > 0 {
-					record := make(chan *cgtRecEntry, entryCnt)
-					result <- record
-					var i uint16
-					for i = 0; i < entryCnt; i++ {
-						entr, err := readRecordEntry(r)
-						if err != nil {
-							close(record)
-							close(result)
-							return
-						}
-						record <- entr
-					}
-					close(record)
-				}
-			} else {
-				close(result)
-				return
-			}
+// returns the first problem of the record or nil
+func (rec *record) error() error {
+	if rec.err != nil {
+		return rec.err
+	}
+	return nil
+}
+
+// returns the record type if the first entry is a byte or 0
+func (rec *record) entryType() byte {
+	if len(rec.entries) > 0 && rec.entries[0].typ == etByte {
+		if b, ok := rec.entries[0].value.(byte); ok {
+			return b
 		}
-	}()
+	}
+	return 0
+}
+
+// marks the record as invalid at the given entry
+func (rec *record) fail(field int, msg string) {
+	if rec.err != nil {
+		return
+	}
+	offset := rec.offset
+	if field >= 0 && field < len(rec.entries) {
+		offset = rec.entries[field].offset
+	}
+	rec.err = &GrammarFileError{Message: msg, Offset: offset, Record: rec.index, RecordType: rec.entryType(), Field: field}
+}
+
+// returns the value of the next entry if it has the expected type
+func (rec *record) next(typ cgtRecEntryTyp) interface{} {
+	if rec.err != nil {
+		return nil
+	}
+	if rec.pos >= len(rec.entries) {
+		rec.fail(-1, fmt.Sprintf("the record has %d fields but at least %d are required", len(rec.entries), rec.pos+1))
+		return nil
+	}
+	entry := rec.entries[rec.pos]
+	rec.pos++
+	if entry.typ != typ {
+		rec.fail(rec.pos-1, fmt.Sprintf("expected %v entry but found %v", EntryType(typ), EntryType(entry.typ)))
+		rec.err.Expected, rec.err.Actual = EntryType(typ), EntryType(entry.typ)
+		return nil
+	}
+	return entry.value
+}
+
+func (rec *record) readByte() byte {
+	v, _ := rec.next(etByte).(byte)
+	return v
+}
+
+func (rec *record) readBool() bool {
+	v, _ := rec.next(etBool).(bool)
+	return v
+}
+
+func (rec *record) readInt() uint16 {
+	v, _ := rec.next(etInt16).(uint16)
+	return v
+}
 
-	return result
+func (rec *record) readString() string {
+	v, _ := rec.next(etString).(string)
+	return v
+}
+
+// reads an index into a table with count entries
+func (rec *record) readIndex(count int, table string) uint16 {
+	v := rec.readInt()
+	if rec.err == nil && int(v) >= count {
+		rec.fail(rec.pos-1, fmt.Sprintf("%s index %d is out of range, the table has %d entries", table, v, count))
+		return 0
+	}
+	return v
+}
+
+// skips a reserved entry of any type
+func (rec *record) skip() {
+	if rec.err == nil && rec.pos >= len(rec.entries) {
+		rec.fail(-1, fmt.Sprintf("the record has %d fields but at least %d are required", len(rec.entries), rec.pos+1))
+	}
+	rec.pos++
+}
+
+// returns the number of entries which are not read yet
+func (rec *record) remaining() int {
+	if rec.err != nil || rec.pos >= len(rec.entries) {
+		return 0
+	}
+	return len(rec.entries) - rec.pos
+}
+
+// checks that the entries after the fixed fields are groups of the given size
+func (rec *record) readGroups(size int) int {
+	n := rec.remaining()
+	if n%size != 0 {
+		rec.fail(-1, fmt.Sprintf("the record has %d trailing fields which is not a multiple of %d", n, size))
+		return 0
+	}
+	return n / size
 }
@@ -0,0 +1,288 @@
+package gold_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/boombuler/gold"
+)
+
+// an entry of a grammar file record. The value is nil, a bool, a byte, a uint16 or a string.
+type fileEntry struct {
+	typ   gold.EntryType
+	value interface{}
+}
+
+// the decoded records of a grammar file
+type grammarFile struct {
+	header  string
+	records [][]fileEntry
+}
+
+var entryTags = map[gold.EntryType]byte{
+	gold.EntryEmpty: 'E', gold.EntryBool: 'B', gold.EntryByte: 'b', gold.EntryInt16: 'I', gold.EntryString: 'S',
+}
+
+// reads the records of a grammar file in testdata
+func readGrammarFile(t *testing.T, name string) *grammarFile {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd := bytes.NewReader(data)
+	u16 := func() uint16 {
+		var v uint16
+		if err := binary.Read(rd, binary.LittleEndian, &v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return v
+	}
+	str := func() string {
+		var chars []uint16
+		for c := u16(); c != 0; c = u16() {
+			chars = append(chars, c)
+		}
+		return string(utf16.Decode(chars))
+	}
+
+	result := &grammarFile{header: str()}
+	for rd.Len() > 0 {
+		if b, _ := rd.ReadByte(); b != 'M' {
+			t.Fatalf("%s: unknown record format %q", name, b)
+		}
+		entries := make([]fileEntry, u16())
+		for i := range entries {
+			tag, _ := rd.ReadByte()
+			switch tag {
+			case 'E':
+				entries[i] = fileEntry{gold.EntryEmpty, nil}
+			case 'B':
+				b, _ := rd.ReadByte()
+				entries[i] = fileEntry{gold.EntryBool, b == 1}
+			case 'b':
+				b, _ := rd.ReadByte()
+				entries[i] = fileEntry{gold.EntryByte, b}
+			case 'I':
+				entries[i] = fileEntry{gold.EntryInt16, u16()}
+			case 'S':
+				entries[i] = fileEntry{gold.EntryString, str()}
+			default:
+				t.Fatalf("%s: unknown entry type %q", name, tag)
+			}
+		}
+		result.records = append(result.records, entries)
+	}
+	return result
+}
+
+// encodes the grammar file. Returns the file and the offsets of the records and their entries.
+func (f *grammarFile) encode() (data []byte, records []int64, entries [][]int64) {
+	buf := new(bytes.Buffer)
+	str := func(s string) {
+		binary.Write(buf, binary.LittleEndian, append(utf16.Encode([]rune(s)), 0))
+	}
+	str(f.header)
+	for _, rec := range f.records {
+		records = append(records, int64(buf.Len()))
+		buf.WriteByte('M')
+		binary.Write(buf, binary.LittleEndian, uint16(len(rec)))
+		var offsets []int64
+		for _, e := range rec {
+			offsets = append(offsets, int64(buf.Len()))
+			buf.WriteByte(entryTags[e.typ])
+			switch v := e.value.(type) {
+			case bool:
+				if v {
+					buf.WriteByte(1)
+				} else {
+					buf.WriteByte(0)
+				}
+			case byte:
+				buf.WriteByte(v)
+			case uint16:
+				binary.Write(buf, binary.LittleEndian, v)
+			case string:
+				str(v)
+			}
+		}
+		entries = append(entries, offsets)
+	}
+	return buf.Bytes(), records, entries
+}
+
+// returns the index of the first record of the given type
+func (f *grammarFile) find(typ byte) int {
+	for i, rec := range f.records {
+		if rec[0].value == typ {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestReadTablesTruncated(t *testing.T) {
+	for _, name := range []string{"calc.egt", "calc.cgt"} {
+		data, records, _ := readGrammarFile(t, name).encode()
+		for n := 0; n < len(data); n++ {
+			_, err := gold.ReadTables(bytes.NewReader(data[:n]))
+			var gfe *gold.GrammarFileError
+			if !errors.As(err, &gfe) || !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("%s truncated at %d: got %v, want a GrammarFileError wrapping io.ErrUnexpectedEOF", name, n, err)
+			}
+			// the record which contains the end of the file or -1 for the header
+			want := -1
+			for _, start := range records {
+				if start <= int64(n) {
+					want++
+				}
+			}
+			if gfe.Record != want || gfe.Offset != int64(n) {
+				t.Fatalf("%s truncated at %d: got the error at record %d, offset %d, want record %d, offset %d: %v", name, n, gfe.Record, gfe.Offset, want, n, err)
+			}
+		}
+	}
+}
+
+func TestReadTablesMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		// breaks the file and returns the record, its type and the field of the problem
+		modify  func(f *grammarFile, counts byte) (record int, recordType byte, field int)
+		message string
+		// the expected and the actual entry type of a wrong entry
+		expected, actual gold.EntryType
+	}{
+		{"symbol index out of range", func(f *grammarFile, counts byte) (int, byte, int) {
+			r := f.find('R')
+			f.records[r][2].value = uint16(999)
+			return r, 'R', 2
+		}, "symbol index 999 is out of range", 0, 0},
+		{"LR state index out of range", func(f *grammarFile, counts byte) (int, byte, int) {
+			r := f.find('I')
+			f.records[r][2].value = uint16(999)
+			return r, 'I', 2
+		}, "LR state index 999 is out of range", 0, 0},
+		{"string instead of integer", func(f *grammarFile, counts byte) (int, byte, int) {
+			r := f.find('S')
+			f.records[r][3] = fileEntry{gold.EntryString, "x"}
+			return r, 'S', 3
+		}, "expected Integer entry but found String", gold.EntryInt16, gold.EntryString},
+		{"integer instead of string", func(f *grammarFile, counts byte) (int, byte, int) {
+			r := f.find('S')
+			f.records[r][2] = fileEntry{gold.EntryInt16, uint16(1)}
+			return r, 'S', 2
+		}, "expected String entry but found Integer", gold.EntryString, gold.EntryInt16},
+		{"missing field", func(f *grammarFile, counts byte) (int, byte, int) {
+			r := f.find('S')
+			f.records[r] = f.records[r][:3]
+			return r, 'S', -1
+		}, "the record has 3 fields but at least 4 are required", 0, 0},
+		{"record before the table counts", func(f *grammarFile, counts byte) (int, byte, int) {
+			c, r := f.find(counts), f.find('S')
+			sym := f.records[r]
+			copy(f.records[c+1:r+1], f.records[c:r])
+			f.records[c] = sym
+			return c, 'S', 1
+		}, "symbol index 0 is out of range, the table has 0 entries", 0, 0},
+		{"duplicate table counts", func(f *grammarFile, counts byte) (int, byte, int) {
+			c := f.find(counts)
+			f.records = append(f.records[:c+1], f.records[c:]...)
+			return c + 1, counts, -1
+		}, "duplicate table counts", 0, 0},
+		{"undefined symbol", func(f *grammarFile, counts byte) (int, byte, int) {
+			r := f.find('S')
+			f.records = append(f.records[:r], f.records[r+1:]...)
+			// the problem is found behind the last record
+			return len(f.records), 0, -1
+		}, "symbol 0 is not defined", 0, 0},
+	}
+	for _, format := range []struct {
+		file   string
+		counts byte
+	}{{"calc.egt", 't'}, {"calc.cgt", 'T'}} {
+		for _, tt := range tests {
+			f := readGrammarFile(t, format.file)
+			record, recordType, field := tt.modify(f, format.counts)
+			data, records, entries := f.encode()
+
+			_, err := gold.ReadTables(bytes.NewReader(data))
+			var gfe *gold.GrammarFileError
+			if !errors.As(err, &gfe) {
+				t.Errorf("%s %s: got %v, want a GrammarFileError", format.file, tt.name, err)
+				continue
+			}
+			offset := int64(len(data))
+			if record < len(records) {
+				offset = records[record]
+				if field >= 0 {
+					offset = entries[record][field]
+				}
+			}
+			if gfe.Record != record || gfe.Field != field || gfe.Offset != offset || gfe.RecordType != recordType ||
+				gfe.Expected != tt.expected || gfe.Actual != tt.actual || !strings.Contains(gfe.Message, tt.message) {
+				t.Errorf("%s %s: got %+v, want record %d (%q), field %d, offset %d, %v/%v and %q",
+					format.file, tt.name, gfe, record, recordType, field, offset, tt.expected, tt.actual, tt.message)
+			}
+
+			var parseErr *gold.GrammarFileError
+			if _, err := gold.NewParser(bytes.NewReader(data)); !errors.As(err, &parseErr) || *parseErr != *gfe {
+				t.Errorf("%s %s: NewParser returned %v", format.file, tt.name, err)
+			}
+		}
+	}
+}
+
+func TestNewParserReductionCycle(t *testing.T) {
+	for _, format := range []struct {
+		file  string
+		write func(*gold.Tables, io.Writer) error
+	}{{"calc.egt", (*gold.Tables).WriteEGT}, {"calc.cgt", (*gold.Tables).WriteCGT}} {
+		data, _, _ := readGrammarFile(t, format.file).encode()
+		tables, err := gold.ReadTables(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", format.file, err)
+		}
+		addReductionCycle(tables)
+		var buf bytes.Buffer
+		if err := format.write(tables, &buf); err != nil {
+			t.Fatalf("%s: %v", format.file, err)
+		}
+
+		// the file is well formed, but a parser would never read the next token
+		if _, err := gold.ReadTables(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Errorf("%s: ReadTables returned %v", format.file, err)
+		}
+		_, err = gold.NewParser(bytes.NewReader(buf.Bytes()))
+		var ve *gold.ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%s: got %v, want a ValidationError", format.file, err)
+			continue
+		}
+		found := false
+		for _, issue := range ve.Report.Errors() {
+			found = found || issue.Kind == gold.IssueReductionCycle
+		}
+		if !found {
+			t.Errorf("%s: got %v, want a reduction cycle", format.file, err)
+		}
+	}
+}
+
+func TestReadTablesHeader(t *testing.T) {
+	f := readGrammarFile(t, "calc.egt")
+	f.header = "GOLD Parser Tables/v9.9"
+	data, _, _ := f.encode()
+	_, err := gold.ReadTables(bytes.NewReader(data))
+	var gfe *gold.GrammarFileError
+	if !errors.As(err, &gfe) || gfe.Record != -1 || gfe.Offset != int64(len(f.header)*2+2) ||
+		!strings.Contains(gfe.Message, "unknown grammar file format") {
+		t.Errorf("got %v", err)
+	}
+}
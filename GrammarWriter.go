@@ -0,0 +1,65 @@
+package gold
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// writes the records of a grammar file
+type recordWriter struct {
+	w *bufio.Writer
+}
+
+func newRecordWriter(w io.Writer) *recordWriter {
+	return &recordWriter{w: bufio.NewWriter(w)}
+}
+
+func (rw *recordWriter) writeUInt16(v uint16) {
+	rw.w.WriteByte(byte(v))
+	rw.w.WriteByte(byte(v >> 8))
+}
+
+func (rw *recordWriter) writeString(s string) {
+	for _, c := range utf16.Encode([]rune(s)) {
+		rw.writeUInt16(c)
+	}
+	rw.writeUInt16(0)
+}
+
+// writes a multi type record. The entries can be nil for empty entries, bools, bytes, uint16s and strings.
+func (rw *recordWriter) writeRecord(entries ...interface{}) {
+	rw.w.WriteByte('M')
+	rw.writeUInt16(uint16(len(entries)))
+
+	for _, e := range entries {
+		switch v := e.(type) {
+		case nil:
+			rw.w.WriteByte('E')
+		case bool:
+			rw.w.WriteByte('B')
+			if v {
+				rw.w.WriteByte(1)
+			} else {
+				rw.w.WriteByte(0)
+			}
+		case byte:
+			rw.w.WriteByte('b')
+			rw.w.WriteByte(v)
+		case uint16:
+			rw.w.WriteByte('I')
+			rw.writeUInt16(v)
+		case string:
+			rw.w.WriteByte('S')
+			rw.writeString(v)
+		default:
+			panic(fmt.Sprintf("unsupported record entry %T", e))
+		}
+	}
+}
+
+// returns the first error which occurred while writing
+func (rw *recordWriter) flush() error {
+	return rw.w.Flush()
+}
@@ -0,0 +1,105 @@
+package gold_test
+
+import (
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestGrammarView(t *testing.T) {
+	for format, p := range grammarFormats(t) {
+		g := p.Grammar()
+		if name := g.Information().Name; name != "Calc" {
+			t.Errorf("%s: the grammar is named %q", format, name)
+		}
+
+		for i, s := range g.Symbols() {
+			if s.Id != gold.SymbolId(i) || g.Symbol(s.Id) != s {
+				t.Errorf("%s: the symbol %s has the id %d at index %d", format, s, s.Id, i)
+			}
+		}
+		if s := g.Symbol(gold.SymbolId(len(g.Symbols()))); s != nil {
+			t.Errorf("%s: got the symbol %s for an unknown id", format, s)
+		}
+		symbols := []struct {
+			name string
+			kind gold.SymbolKind
+		}{
+			{"Identifier", gold.KindTerminal},
+			{"print", gold.KindTerminal},
+			{"Expr", gold.KindNonTerminal},
+			{"<Expr>", gold.KindNonTerminal},
+			{"EOF", gold.KindEnd},
+		}
+		for _, tt := range symbols {
+			if s := g.SymbolByName(tt.name); s == nil || s.Kind != tt.kind {
+				t.Errorf("%s: got the symbol %v for %q, want a %s", format, s, tt.name, tt.kind)
+			}
+		}
+		if s := g.SymbolByName("<Identifier>"); s != nil {
+			t.Errorf("%s: got the symbol %s for <Identifier>", format, s)
+		}
+
+		rules := make(map[string]bool)
+		for i, r := range g.Rules() {
+			if r.Id != gold.RuleId(i) || g.Rule(r.Id) != r {
+				t.Errorf("%s: the rule %s has the id %d at index %d", format, r, r.Id, i)
+			}
+			rules[r.String()] = true
+		}
+		for _, r := range []string{
+			"<Program> ::= <Stmts>",
+			"<Stmts> ::= ",
+			"<Stmt> ::= Identifier = <Expr> ;",
+			"<Factor> ::= ( <Expr> )",
+		} {
+			if !rules[r] {
+				t.Errorf("%s: the rule %s is missing", format, r)
+			}
+		}
+		if len(g.Rules()) != 15 {
+			t.Errorf("%s: got %d rules, want 15", format, len(g.Rules()))
+		}
+		if g.DFAStateCount() == 0 || g.LRStateCount() == 0 {
+			t.Errorf("%s: got %d DFA states and %d LR states", format, g.DFAStateCount(), g.LRStateCount())
+		}
+	}
+}
+
+func TestGrammarViewGroups(t *testing.T) {
+	formats := grammarFormats(t)
+	if groups := formats["cgt"].Grammar().Groups(); len(groups) != 0 {
+		t.Errorf("cgt: got %d groups", len(groups))
+	}
+
+	g := formats["egt"].Grammar()
+	tests := []struct {
+		name                  string
+		container, start, end string
+		advance               gold.AdvanceMode
+		ending                gold.EndingMode
+	}{
+		{"Comment Line", "Comment", "Comment Line", "NewLine", gold.AdvanceCharacter, gold.EndingOpen},
+		{"Comment Block", "Comment", "Comment Start", "Comment End", gold.AdvanceCharacter, gold.EndingClosed},
+	}
+	if len(g.Groups()) != len(tests) {
+		t.Errorf("egt: got %d groups, want %d", len(g.Groups()), len(tests))
+	}
+	for _, tt := range tests {
+		grp := g.GroupByName(tt.name)
+		if grp == nil {
+			t.Errorf("egt: the group %s is missing", tt.name)
+			continue
+		}
+		if g.Group(grp.Id) != grp {
+			t.Errorf("egt: the group %s can not be found by its id %d", tt.name, grp.Id)
+		}
+		if grp.Container.Name != tt.container || grp.Start.Name != tt.start || grp.End.Name != tt.end ||
+			grp.AdvanceMode != tt.advance || grp.EndingMode != tt.ending {
+			t.Errorf("egt: the group %s is %s %s %s %d %d", tt.name, grp.Container, grp.Start, grp.End, grp.AdvanceMode, grp.EndingMode)
+		}
+		if grp.Start.Group != grp {
+			t.Errorf("egt: the start symbol of the group %s belongs to %v", tt.name, grp.Start.Group)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package gold
 
 type group struct {
+	Index       uint16
 	Name        string
 	Container   *symbol
 	Start       *symbol
@@ -32,6 +33,7 @@ func newGroupTable(count uint16, createGroups bool) groupTable {
 		var i uint16
 		for i = 0; i < count; i++ {
 			result[i] = new(group)
+			result[i].Index = i
 		}
 	}
 	return result
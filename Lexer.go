@@ -0,0 +1,71 @@
+package gold
+
+import (
+	"fmt"
+	"io"
+)
+
+// represents a single token read by a Lexer
+type Terminal struct {
+	// the id of the symbol which was read
+	Symbol SymbolId
+	// the name of the symbol which was read
+	Name string
+	// the kind of the symbol which was read
+	Kind SymbolKind
+	// the name of the lexical group (like a block comment) the token was read from or an
+	// empty string if the token is not part of a group
+	Group string
+	// the text of the token
+	Text string
+	// the position of the token within the source
+	Position TextPosition
+	// the position behind the last character of the token
+	End TextPosition
+}
+
+// Scans an input text into terminals by only using the DFA of the grammar.
+type Lexer interface {
+	// returns the next terminal of the input text. Noise, comments and groups are returned as well.
+	// If the input contains an unknown token, a terminal of kind KindError is returned together
+	// with a ParseError; the lexer can still be used to read the remaining input.
+	// If the input ends within a group like a block comment, the group is returned together
+	// with a ParseError.
+	// After the end of the input, the terminal of kind KindEnd is returned together with io.EOF.
+	Next() (Terminal, error)
+}
+
+// reads the tokens of an input text one by one
+type tokenizer interface {
+	next() *parserToken
+}
+
+type lexer struct {
+	tokens tokenizer
+	end    *Terminal
+}
+
+func newLexer(t tokenizer) *lexer {
+	return &lexer{tokens: t}
+}
+
+func (l *lexer) Next() (Terminal, error) {
+	if l.end != nil {
+		return *l.end, io.EOF
+	}
+
+	token := l.tokens.next()
+	result := token.toTerminal()
+	if token.Runaway {
+		return result, newRunawayError(token)
+	}
+
+	switch token.Symbol.Kind {
+	case stEnd:
+		l.end = &result
+		return result, io.EOF
+	case stError:
+		return result, &ParseError{Message: fmt.Sprintf("Unknown Token \"%s\"", token.Text), Position: token.Position}
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package gold_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestLexer(t *testing.T) {
+	const input = "x = 1.5; print x $"
+	want := []string{
+		`Terminal Identifier "x" Line 1, Column 1`,
+		`Noise Whitespace " " Line 1, Column 2`,
+		`Terminal = "=" Line 1, Column 3`,
+		`Noise Whitespace " " Line 1, Column 4`,
+		`Terminal Number "1.5" Line 1, Column 5`,
+		`Terminal ; ";" Line 1, Column 8`,
+		`Noise Whitespace " " Line 1, Column 9`,
+		`Terminal print "print" Line 1, Column 10`,
+		`Noise Whitespace " " Line 1, Column 15`,
+		`Terminal Identifier "x" Line 1, Column 16`,
+		`Noise Whitespace " " Line 1, Column 17`,
+		`Error Error "$" Line 1, Column 18`,
+		`End EOF "" Line 1, Column 19`,
+	}
+	for format, p := range grammarFormats(t) {
+		lx := p.NewLexer(strings.NewReader(input))
+		var got []string
+		for len(got) <= len(want) {
+			term, err := lx.Next()
+			s := fmt.Sprintf("%s %s %q %s", term.Kind, term.Name, term.Text, term.Position)
+			got = append(got, s)
+			if err == io.EOF {
+				break
+			}
+			var pe *gold.ParseError
+			if (term.Kind == gold.KindError) != errors.As(err, &pe) {
+				t.Errorf("%s: got the error %v for %s", format, err, s)
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s: got the terminals\n%s\nwant\n%s", format, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestLexerReadsToTheEnd(t *testing.T) {
+	tests := []struct {
+		input string
+		// the text of the last terminal in front of the end and the message of its error
+		last    string
+		lastErr string
+	}{
+		{"x = 1;", ";", ""},
+		{"x = 1; // c", "// c", ""},
+		{"x = 1; /* c */", "/* c */", ""},
+		{"x = 1; /* open", "/* open", "Runaway group"},
+		{"x = 1 $", "$", "Unknown Token"},
+	}
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			lx := p.NewLexer(strings.NewReader(tt.input))
+			var text strings.Builder
+			var last gold.Terminal
+			var lastErr error
+			for i := 0; ; i++ {
+				term, err := lx.Next()
+				if err == io.EOF {
+					if term.Kind != gold.KindEnd {
+						t.Errorf("%s %q: the end has kind %v", format, tt.input, term.Kind)
+					}
+					break
+				}
+				if i > 100 {
+					t.Fatalf("%s %q: the lexer does not stop", format, tt.input)
+				}
+				text.WriteString(term.Text)
+				last, lastErr = term, err
+			}
+			if text.String() != tt.input {
+				t.Errorf("%s %q: read %q", format, tt.input, text.String())
+			}
+			if last.Text != tt.last {
+				t.Errorf("%s %q: the last terminal is %s %q, want %q", format, tt.input, last.Name, last.Text, tt.last)
+			}
+			var pe *gold.ParseError
+			switch {
+			case tt.lastErr == "" && lastErr != nil:
+				t.Errorf("%s %q: unexpected error %v", format, tt.input, lastErr)
+			case tt.lastErr != "" && (!errors.As(lastErr, &pe) || !strings.Contains(pe.Message, tt.lastErr)):
+				t.Errorf("%s %q: got error %v, want %s", format, tt.input, lastErr, tt.lastErr)
+			}
+			// the end is returned again
+			if _, err := lx.Next(); err != io.EOF {
+				t.Errorf("%s %q: got %v after the end", format, tt.input, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package gold
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// the limits of the resources used by the parser. A limit of 0 is unlimited.
+type ParseLimits struct {
+	// the maximum number of bytes read from the input
+	MaxInputBytes int
+	// the maximum number of bytes of a token. Groups like block comments are limited as a
+	// whole and the characters read behind the longest match of the tokenizer are counted.
+	MaxTokenBytes int
+	// the maximum number of states on the stack of the parser
+	MaxStackDepth int
+	// the maximum number of nested lexical groups
+	MaxGroupDepth int
+	// the maximum number of shifted terminals and reduced rules, which is the number of
+	// tokens in the syntax tree without trimmed reductions
+	MaxNodes int
+}
+
+// the resource which is limited
+type LimitKind int
+
+const (
+	LimitInputBytes LimitKind = iota
+	LimitTokenBytes
+	LimitStackDepth
+	LimitGroupDepth
+	LimitNodes
+)
+
+// returns the name of the limit
+func (k LimitKind) String() string {
+	switch k {
+	case LimitInputBytes:
+		return "input bytes"
+	case LimitTokenBytes:
+		return "token bytes"
+	case LimitStackDepth:
+		return "stack depth"
+	case LimitGroupDepth:
+		return "group depth"
+	case LimitNodes:
+		return "nodes"
+	}
+	return "LimitKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// reports that the input exceeds one of the ParseLimits. The parsing is aborted with a
+// ParseError wrapping the LimitError, even if the error recovery is enabled.
+type LimitError struct {
+	// the exceeded limit
+	Kind LimitKind
+	// the value of the exceeded limit
+	Limit int
+	// the position at which the limit was exceeded. For LimitTokenBytes this is the start of the token.
+	Position TextPosition
+}
+
+// returns the error message as string
+func (le *LimitError) Error() string {
+	switch le.Kind {
+	case LimitInputBytes:
+		return fmt.Sprintf("the input is longer than %d bytes", le.Limit)
+	case LimitTokenBytes:
+		return fmt.Sprintf("the token is longer than %d bytes", le.Limit)
+	case LimitStackDepth:
+		return fmt.Sprintf("the parser stack is deeper than %d states", le.Limit)
+	case LimitGroupDepth:
+		return fmt.Sprintf("the groups are nested deeper than %d levels", le.Limit)
+	case LimitNodes:
+		return fmt.Sprintf("the syntax tree has more than %d nodes", le.Limit)
+	}
+	return fmt.Sprintf("the limit of %d %s is exceeded", le.Limit, le.Kind)
+}
+
+// wraps the error, so that it is returned like the other errors of the parser
+func newLimitError(le *LimitError) *ParseError {
+	return &ParseError{Message: le.Error(), Position: le.Position, Err: le}
+}
+
+// returns an error if the token which started at start is longer than allowed at the
+// current position of the reader
+func (l *ParseLimits) checkTokenBytes(start TextPosition, r *sourceReader) *LimitError {
+	if l.MaxTokenBytes > 0 && r.Position.Offset-start.Offset > l.MaxTokenBytes {
+		return &LimitError{Kind: LimitTokenBytes, Limit: l.MaxTokenBytes, Position: start}
+	}
+	return nil
+}
+
+// returns an error if the stack of the parser or the number of nodes is larger than allowed
+func (l *ParseLimits) checkParser(stackDepth, nodes int, pos TextPosition) *LimitError {
+	if l.MaxStackDepth > 0 && stackDepth > l.MaxStackDepth {
+		return &LimitError{Kind: LimitStackDepth, Limit: l.MaxStackDepth, Position: pos}
+	}
+	if l.MaxNodes > 0 && nodes > l.MaxNodes {
+		return &LimitError{Kind: LimitNodes, Limit: l.MaxNodes, Position: pos}
+	}
+	return nil
+}
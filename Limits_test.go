@@ -0,0 +1,112 @@
+package gold_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestParseLimits(t *testing.T) {
+	const input = "x = 1; y = x + 2;\n"
+	nested := "x = " + strings.Repeat("(", 50) + "1" + strings.Repeat(")", 50) + ";"
+	tests := []struct {
+		input  string
+		limits gold.ParseLimits
+		// the exceeded limit, -1 if the input is within the limits
+		kind gold.LimitKind
+	}{
+		{input, gold.ParseLimits{MaxInputBytes: 10}, gold.LimitInputBytes},
+		{input, gold.ParseLimits{MaxInputBytes: len(input)}, -1},
+		{"x = 123456789012345;", gold.ParseLimits{MaxTokenBytes: 5}, gold.LimitTokenBytes},
+		{"x = 1; /* a long block comment */", gold.ParseLimits{MaxTokenBytes: 10}, gold.LimitTokenBytes},
+		{"x = 1; // a long line comment\n", gold.ParseLimits{MaxTokenBytes: 10}, gold.LimitTokenBytes},
+		{"x = 1; /* short */", gold.ParseLimits{MaxTokenBytes: 11}, -1},
+		{nested, gold.ParseLimits{MaxStackDepth: 30}, gold.LimitStackDepth},
+		{nested, gold.ParseLimits{MaxStackDepth: 200}, -1},
+		{input, gold.ParseLimits{MaxNodes: 10}, gold.LimitNodes},
+		{input, gold.ParseLimits{MaxNodes: 100}, -1},
+	}
+	tracer := gold.Trace(gold.TracerFunc(func(*gold.ParseEvent) {}))
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			// the limits abort the parser even if the errors are recovered
+			for _, opts := range [][]gold.ParseOption{nil, {gold.RecoverErrors()}, {tracer}} {
+				opts = append(opts, gold.Limits(tt.limits))
+				_, err := p.ParseContext(context.Background(), strings.NewReader(tt.input), opts...)
+				checkLimitError(t, format+" "+tt.input, err, tt.kind)
+			}
+		}
+	}
+}
+
+func TestParseLimitsGroupDepth(t *testing.T) {
+	const grammar = `
+"Start Symbol" = <S>
+Id = {Letter}+
+Comment Start = '/*'
+Comment End = '*/'
+Comment Block @= { Nesting = All }
+<S> ::= Id <S> |
+`
+	const input = "a /* b /* c /* d */ */ */ e"
+	p, err := gold.NewParserFromTables(buildTables(t, grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		limits gold.ParseLimits
+		kind   gold.LimitKind
+	}{
+		{gold.ParseLimits{MaxGroupDepth: 2}, gold.LimitGroupDepth},
+		{gold.ParseLimits{MaxGroupDepth: 3}, -1},
+		// the nested groups are limited as a whole
+		{gold.ParseLimits{MaxTokenBytes: 12}, gold.LimitTokenBytes},
+	}
+	for _, tt := range tests {
+		_, err := p.ParseContext(context.Background(), strings.NewReader(input), gold.Limits(tt.limits))
+		checkLimitError(t, input, err, tt.kind)
+	}
+}
+
+func TestLimitError(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	tests := []struct {
+		input   string
+		limits  gold.ParseLimits
+		message string
+	}{
+		{"x = 1;\ny = 2;", gold.ParseLimits{MaxInputBytes: 8}, "the input is longer than 8 bytes at Line 2, Column 2"},
+		{"x = 1;\ny = 123456;", gold.ParseLimits{MaxTokenBytes: 4}, "the token is longer than 4 bytes at Line 2, Column 5"},
+		{"x = ((1));", gold.ParseLimits{MaxStackDepth: 4}, "the parser stack is deeper than 4 states at Line 1, Column 6"},
+		{"x = 1;", gold.ParseLimits{MaxNodes: 2}, "the syntax tree has more than 2 nodes at Line 1, Column 5"},
+	}
+	for _, tt := range tests {
+		_, err := p.ParseContext(context.Background(), strings.NewReader(tt.input), gold.Limits(tt.limits))
+		if err == nil || err.Error() != tt.message {
+			t.Errorf("%q: got %v, want %s", tt.input, err, tt.message)
+		}
+	}
+	if got := gold.LimitKind(7).String(); got != "LimitKind(7)" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func checkLimitError(t *testing.T, name string, err error, kind gold.LimitKind) {
+	t.Helper()
+	if kind < 0 {
+		if err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+		return
+	}
+	var le *gold.LimitError
+	var pe *gold.ParseError
+	if !errors.As(err, &le) || le.Kind != kind {
+		t.Errorf("%q: got %v, want a LimitError of %s", name, err, kind)
+	} else if !errors.As(err, &pe) {
+		t.Errorf("%q: the LimitError is not wrapped by a ParseError", name)
+	}
+}
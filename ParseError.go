@@ -1,6 +1,9 @@
 package gold
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // represents a error while parsing.
 type ParseError struct {
@@ -8,9 +11,34 @@ type ParseError struct {
 	Message string
 	// the position of the input text which produced the error
 	Position TextPosition
+	// the underlying error if the parsing was aborted by something else than the input,
+	// e.g. the error of the context passed to ParseContext
+	Err error
+	// the symbol of the token which caused a syntax error, nil for other errors
+	Unexpected *Symbol
+	// the terminals which would have been valid instead of the unexpected symbol, ordered by
+	// their id without duplicates
+	Expected []*Symbol
 }
 
 // returns the error message as string
 func (pe *ParseError) Error() string {
 	return fmt.Sprintf("%s at %s", pe.Message, pe.Position.String())
 }
+
+// returns the underlying error or nil
+func (pe *ParseError) Unwrap() error {
+	return pe.Err
+}
+
+// contains all errors which were found while parsing with error recovery.
+type ParseErrors []*ParseError
+
+// returns the error messages of all errors, one per line
+func (pe ParseErrors) Error() string {
+	msgs := make([]string, len(pe))
+	for i, e := range pe {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
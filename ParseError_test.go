@@ -0,0 +1,58 @@
+package gold_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestSyntaxErrorExpected(t *testing.T) {
+	tests := []struct {
+		input      string
+		unexpected string
+		expected   []string
+		message    string
+	}{
+		{"x = 1 + ;", ";", []string{"(", "-", "Identifier", "Number"},
+			`syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number at Line 1, Column 9`},
+		// the default reductions of the LALR states are done before the error is detected
+		{"print (1 ;", ";", []string{")", "+", "-"},
+			`syntax Error: unexpected ";", expected one of: ")", "+", "-" at Line 1, Column 10`},
+		{"x = 1; print", "EOF", []string{"(", "-", "Identifier", "Number"},
+			`syntax Error: unexpected EOF, expected one of: "(", "-", Identifier, Number at Line 1, Column 13`},
+		{"x print", "print", []string{"="},
+			`syntax Error: unexpected "print", expected "=" at Line 1, Column 3`},
+	}
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			_, err := p.ParseContext(context.Background(), strings.NewReader(tt.input))
+			var pe *gold.ParseError
+			if !errors.As(err, &pe) {
+				t.Errorf("%s %q: got %v, want a ParseError", format, tt.input, err)
+				continue
+			}
+			if pe.Error() != tt.message {
+				t.Errorf("%s %q: got the message\n%s\nwant\n%s", format, tt.input, pe.Error(), tt.message)
+			}
+			if pe.Unexpected == nil || pe.Unexpected.Name != tt.unexpected {
+				t.Errorf("%s %q: got the unexpected symbol %v, want %s", format, tt.input, pe.Unexpected, tt.unexpected)
+			}
+			var names []string
+			for i, s := range pe.Expected {
+				names = append(names, s.Name)
+				if p.Grammar().Symbol(s.Id) != s {
+					t.Errorf("%s %q: the expected symbol %s is not the symbol of the grammar", format, tt.input, s)
+				}
+				if i > 0 && pe.Expected[i-1].Id >= s.Id {
+					t.Errorf("%s %q: the expected symbols %v are not ordered by their id", format, tt.input, pe.Expected)
+				}
+			}
+			if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("%s %q: got the expected symbols %q, want %q", format, tt.input, names, tt.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package gold
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// the kind of a step of the tokenizer or the parser
+type ParseEventKind int
+
+const (
+	// the DFA of the tokenizer read a character and moved from State to Target
+	EventChar ParseEventKind = iota
+	// the tokenizer passed the Terminal to the parser. Noise and comments are reported too,
+	// although the parser skips them.
+	EventToken
+	// the Terminal was pushed onto the stack and the parser moved from State to Target
+	EventShift
+	// the handle of Rule is about to be popped from the stack. Symbol is the head of the rule.
+	EventReduce
+	// the non-terminal Symbol was pushed onto the stack after a reduction and the parser
+	// moved from State to Target
+	EventGoto
+	// the input was parsed successfully
+	EventAccept
+	// the Terminal is unknown or not expected in State. Error describes the problem.
+	EventError
+	// the error recovery resumed parsing in State in front of the Terminal
+	EventRecover
+)
+
+// returns the name of the event kind
+func (k ParseEventKind) String() string {
+	switch k {
+	case EventChar:
+		return "char"
+	case EventToken:
+		return "token"
+	case EventShift:
+		return "shift"
+	case EventReduce:
+		return "reduce"
+	case EventGoto:
+		return "goto"
+	case EventAccept:
+		return "accept"
+	case EventError:
+		return "error"
+	case EventRecover:
+		return "recover"
+	}
+	return "ParseEventKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// a single step of the tokenizer or the parser
+type ParseEvent struct {
+	Kind ParseEventKind
+	// the lexeme read so far for EventChar, otherwise the lookahead terminal
+	Terminal Terminal
+	// the DFA state in front of the character for EventChar, otherwise the current LR state
+	State int
+	// the DFA state behind the character for EventChar or the LR state pushed by EventShift
+	// and EventGoto
+	Target int
+	// the character read by EventChar
+	Char rune
+	// the shifted terminal, the reduced non-terminal or the lookahead
+	Symbol SymbolId
+	// the rule of EventReduce
+	Rule RuleId
+	// the error of EventError
+	Error *ParseError
+
+	symbol *symbol
+	rule   *rule
+	// the stacks of the parser, nil for events of the tokenizer used without a parser
+	states, values *stack
+}
+
+// receives the steps of the parser. See the Trace option.
+type Tracer interface {
+	// called for each step. The event must not be kept after the call returns, as its
+	// stacks are modified by the next steps.
+	Trace(e *ParseEvent)
+}
+
+// adapts a function to the Tracer interface
+type TracerFunc func(e *ParseEvent)
+
+// calls f(e)
+func (f TracerFunc) Trace(e *ParseEvent) {
+	f(e)
+}
+
+// receives the steps of the tokenizer and the parser
+type traceFunc func(e *ParseEvent)
+
+// returns the indices of the LR states on the stack of the parser from bottom to top
+func (e *ParseEvent) StateStack() []int {
+	if e.states == nil {
+		return nil
+	}
+	result := make([]int, e.states.Len())
+	for i := range result {
+		result[i] = int(e.states.nodes[i].(*lrState).Index)
+	}
+	return result
+}
+
+// returns the values on the stack of the parser from bottom to top. The values are the
+// tokens of the syntax tree or the results of the semantic actions, depending on the
+// function which is parsing. The result is only valid during the callback receiving the event.
+func (e *ParseEvent) ValueStack() []interface{} {
+	if e.values == nil {
+		return nil
+	}
+	return append([]interface{}(nil), e.values.nodes[:e.values.Len()]...)
+}
+
+// returns a one-line description of the event
+func (e *ParseEvent) String() string {
+	switch e.Kind {
+	case EventChar:
+		return fmt.Sprintf("char %s: DFA %d -> %d, lexeme %s", strconv.QuoteRune(e.Char), e.State, e.Target, strconv.Quote(e.Terminal.Text))
+	case EventShift:
+		return fmt.Sprintf("shift %s %s: %d -> %d", e.Terminal.Name, strconv.Quote(e.Terminal.Text), e.State, e.Target)
+	case EventReduce:
+		if e.rule != nil {
+			return fmt.Sprintf("reduce %s in %d", e.rule, e.State)
+		}
+		return fmt.Sprintf("reduce rule %d in %d", e.Rule, e.State)
+	case EventGoto:
+		return fmt.Sprintf("goto %s: %d -> %d", e.symbolName(), e.State, e.Target)
+	case EventError:
+		return fmt.Sprintf("error in %d: %s", e.State, e.Error.Message)
+	}
+	return fmt.Sprintf("%s %s %s in %d", e.Kind, e.Terminal.Name, strconv.Quote(e.Terminal.Text), e.State)
+}
+
+func (e *ParseEvent) symbolName() string {
+	if e.symbol != nil {
+		return e.symbol.String()
+	}
+	return fmt.Sprintf("symbol %d", e.Symbol)
+}
+
+// returns the index of the LR state on top of the stack
+func topState(states *stack) int {
+	return int(states.Peek().(*lrState).Index)
+}
+
+// returns the text read so far by the tokenizer as terminal of the symbol accepted so far
+func lexeme(t *parserToken, read []sourceRune) Terminal {
+	result := Terminal{
+		Symbol:   SymbolId(t.Symbol.Index),
+		Name:     t.Symbol.String(),
+		Kind:     SymbolKind(t.Symbol.Kind),
+		Position: t.Position,
+		End:      t.Position,
+	}
+	if len(read) > 0 {
+		text := make([]rune, len(read))
+		for i, sr := range read {
+			text[i] = sr.Rune
+		}
+		result.Text = string(text)
+		last := read[len(read)-1]
+		result.End = last.Position.advance(last.Rune, last.Size)
+	}
+	return result
+}
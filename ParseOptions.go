@@ -0,0 +1,92 @@
+package gold
+
+// configures the behaviour of ParseContext
+type ParseOption func(*parseConfig)
+
+type parseConfig struct {
+	trimReduce bool
+	recovery   *errorRecovery
+	maxErrors  int
+	keepTrivia bool
+	// receives the steps of the parser or nil
+	trace traceFunc
+	// tells if trace also receives the characters read by the tokenizer
+	traceChars bool
+	// collects the statistics of the parse or nil
+	stats  *ParseStats
+	limits ParseLimits
+	// closed when the parsing is aborted, nil if it can not be aborted. Set by the parser.
+	done <-chan struct{}
+}
+
+func newParseConfig(opts []ParseOption) *parseConfig {
+	cfg := &parseConfig{maxErrors: 100}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+	return cfg
+}
+
+// if trim is set to true, tokens which have only one non-terminal sub-node are reduced.
+func TrimReductions(trim bool) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.trimReduce = trim
+	}
+}
+
+// enables the error recovery. Instead of stopping at the first syntax error, the parser tries
+// to repair the input by inserting or deleting a single token. If that is not possible, it
+// skips the input until one of the given synchronisation terminals (or any terminal if none
+// are given) can be parsed and replaces the skipped part by an error node.
+// All errors are returned as ParseErrors.
+func RecoverErrors(syncSymbols ...SymbolId) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.recovery = newErrorRecovery(syncSymbols)
+	}
+}
+
+// sets the number of errors after which the error recovery gives up. Defaults to 100.
+func MaxErrors(count int) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.maxErrors = count
+	}
+}
+
+// limits the resources used by the parser. If the input exceeds a limit, the parsing is
+// aborted with a ParseError wrapping a *LimitError.
+func Limits(limits ParseLimits) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.limits = limits
+	}
+}
+
+// passes each scanned token and each shift, reduce, goto, accept and error of the parser to
+// the tracer. The characters read by the tokenizer are not traced. Several tracers can be
+// used together; they are called in the order of the options.
+func Trace(t Tracer) ParseOption {
+	return func(cfg *parseConfig) {
+		if t == nil {
+			return
+		}
+		previous := cfg.trace
+		cfg.trace = func(e *ParseEvent) {
+			if previous != nil {
+				previous(e)
+			}
+			if e.Kind != EventChar {
+				t.Trace(e)
+			}
+		}
+	}
+}
+
+// if keep is set to true, the noise and comments skipped by the parser are attached to the
+// adjacent terminals as leading and trailing trivia, so the input can be reconstructed from
+// the syntax-tree with Token.FullText.
+func KeepTrivia(keep bool) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.keepTrivia = keep
+	}
+}
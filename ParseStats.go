@@ -0,0 +1,139 @@
+package gold
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// the statistics of a parse collected with the CollectStats option
+type ParseStats struct {
+	// the number of tokens read per symbol, including noise and comments
+	Tokens map[SymbolId]int
+	// the number of reductions per rule
+	Reductions map[RuleId]int
+	// the maximum number of states on the stack of the parser
+	MaxStackDepth int
+	// the number of transitions of the DFA of the tokenizer. Characters which are read
+	// again behind the longest accepted token are counted again.
+	DFATransitions int
+	// the number of bytes and runes of the input up to the last token read
+	Bytes int
+	Runes int
+	// the time spent in the tokenizer and in the parser. The time of the semantic actions
+	// is part of ParseTime.
+	LexTime   time.Duration
+	ParseTime time.Duration
+	// the number of heap allocations and the allocated bytes during the parse. They are
+	// taken from runtime.MemStats, so they include the allocations of other goroutines.
+	Allocations    uint64
+	AllocatedBytes uint64
+
+	grammar Grammar
+}
+
+// collects the statistics of the parse in stats. The previous content of stats is
+// discarded. Collecting the statistics slows the parser down, as the time of the tokenizer
+// is measured for each token.
+func CollectStats(stats *ParseStats) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.stats = stats
+	}
+}
+
+// resets the statistics at the start of a parse
+func (s *ParseStats) reset(g Grammar) {
+	*s = ParseStats{
+		Tokens:     make(map[SymbolId]int),
+		Reductions: make(map[RuleId]int),
+		grammar:    g,
+	}
+}
+
+// records the depth of the stack of the parser. Does nothing if s is nil.
+func (s *ParseStats) stackDepth(depth int) {
+	if s != nil && depth > s.MaxStackDepth {
+		s.MaxStackDepth = depth
+	}
+}
+
+// records the heap allocations which were made after the previous call
+func (s *ParseStats) readMemStats(previous *runtime.MemStats) {
+	var current runtime.MemStats
+	runtime.ReadMemStats(&current)
+	s.Allocations = current.Mallocs - previous.Mallocs
+	s.AllocatedBytes = current.TotalAlloc - previous.TotalAlloc
+}
+
+// writes a report of the statistics. The tokens and reductions are sorted by their count,
+// so that the hot symbols and rules are listed first.
+func (s *ParseStats) WriteReport(w io.Writer) error {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "input:           %d bytes, %d runes\n", s.Bytes, s.Runes)
+	fmt.Fprintf(buf, "time:            %v lexing, %v parsing\n", s.LexTime, s.ParseTime)
+	fmt.Fprintf(buf, "allocations:     %d (%d bytes)\n", s.Allocations, s.AllocatedBytes)
+	fmt.Fprintf(buf, "DFA transitions: %d\n", s.DFATransitions)
+	fmt.Fprintf(buf, "max stack depth: %d\n", s.MaxStackDepth)
+
+	tokens := make(map[int]int, len(s.Tokens))
+	for id, count := range s.Tokens {
+		tokens[int(id)] = count
+	}
+	s.writeCounts(buf, "tokens", tokens, func(id int) string {
+		if sym := s.symbol(SymbolId(id)); sym != nil {
+			return sym.String()
+		}
+		return fmt.Sprintf("symbol %d", id)
+	})
+	reductions := make(map[int]int, len(s.Reductions))
+	for id, count := range s.Reductions {
+		reductions[int(id)] = count
+	}
+	s.writeCounts(buf, "reductions", reductions, func(id int) string {
+		if s.grammar != nil {
+			if rule := s.grammar.Rule(RuleId(id)); rule != nil {
+				return rule.String()
+			}
+		}
+		return fmt.Sprintf("rule %d", id)
+	})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// returns the report of the statistics
+func (s *ParseStats) String() string {
+	buf := new(bytes.Buffer)
+	s.WriteReport(buf)
+	return buf.String()
+}
+
+func (s *ParseStats) symbol(id SymbolId) *Symbol {
+	if s.grammar == nil {
+		return nil
+	}
+	return s.grammar.Symbol(id)
+}
+
+func (s *ParseStats) writeCounts(buf *bytes.Buffer, title string, counts map[int]int, name func(id int) string) {
+	ids := make([]int, 0, len(counts))
+	total := 0
+	for id, count := range counts {
+		ids = append(ids, id)
+		total += count
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	fmt.Fprintf(buf, "%-17s%d\n", title+":", total)
+	for _, id := range ids {
+		fmt.Fprintf(buf, "  %8d  %s\n", counts[id], name(id))
+	}
+}
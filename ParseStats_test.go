@@ -0,0 +1,131 @@
+package gold_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+const statsInput = "x = 1;\n// ä\nprint (x + 2) * x;"
+
+func TestParseStats(t *testing.T) {
+	// the CGT files have a line comment symbol, whose text is read without the DFA
+	formats := map[string]struct {
+		comment     string
+		transitions int
+	}{
+		"egt": {"Comment", 30},
+		"cgt": {"Comment Line", 28},
+	}
+	for name, p := range grammarFormats(t) {
+		format := formats[name]
+		g := p.Grammar()
+		var stats gold.ParseStats
+		// the statistics of a previous parse are discarded
+		p.ParseContext(context.Background(), strings.NewReader("print 1;"), gold.CollectStats(&stats))
+		if _, err := p.ParseContext(context.Background(), strings.NewReader(statsInput), gold.CollectStats(&stats)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		tokens := make(map[string]int)
+		for id, count := range stats.Tokens {
+			tokens[g.Symbol(id).Name] = count
+		}
+		wantTokens := map[string]int{
+			"Whitespace": 7, "Identifier": 3, ";": 2, "NewLine": 2, "Number": 2, "EOF": 1,
+			"(": 1, ")": 1, "*": 1, "+": 1, "=": 1, format.comment: 1, "print": 1,
+		}
+		if !reflect.DeepEqual(tokens, wantTokens) {
+			t.Errorf("%s: got the tokens %v, want %v", name, tokens, wantTokens)
+		}
+		reductions := make(map[string]int)
+		for id, count := range stats.Reductions {
+			reductions[g.Rule(id).String()] = count
+		}
+		wantReductions := map[string]int{
+			"<Term> ::= <Factor>": 4, "<Expr> ::= <Term>": 3, "<Stmts> ::= <Stmt> <Stmts>": 2,
+			"<Factor> ::= Number": 2, "<Factor> ::= Identifier": 2, "<Program> ::= <Stmts>": 1,
+			"<Stmts> ::= ": 1, "<Stmt> ::= Identifier = <Expr> ;": 1, "<Stmt> ::= print <Expr> ;": 1,
+			"<Expr> ::= <Expr> + <Term>": 1, "<Term> ::= <Term> * <Factor>": 1, "<Factor> ::= ( <Expr> )": 1,
+		}
+		if !reflect.DeepEqual(reductions, wantReductions) {
+			t.Errorf("%s: got the reductions %v, want %v", name, reductions, wantReductions)
+		}
+		if stats.MaxStackDepth != 7 || stats.DFATransitions != format.transitions || stats.Bytes != 31 || stats.Runes != 30 {
+			t.Errorf("%s: got the max stack depth %d, %d DFA transitions, %d bytes and %d runes",
+				name, stats.MaxStackDepth, stats.DFATransitions, stats.Bytes, stats.Runes)
+		}
+		if stats.LexTime < 0 || stats.ParseTime < 0 || stats.Allocations == 0 {
+			t.Errorf("%s: got the lex time %v, the parse time %v and %d allocations",
+				name, stats.LexTime, stats.ParseTime, stats.Allocations)
+		}
+	}
+}
+
+func TestParseStatsErrors(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	var stats gold.ParseStats
+	if _, err := p.ParseContext(context.Background(), strings.NewReader("x = ;"), gold.CollectStats(&stats)); err == nil {
+		t.Fatal("missing syntax error")
+	}
+	// the input up to the unexpected token
+	if stats.Bytes != 5 || len(stats.Reductions) != 0 || stats.MaxStackDepth != 3 {
+		t.Errorf("got %d bytes, the reductions %v and the max stack depth %d", stats.Bytes, stats.Reductions, stats.MaxStackDepth)
+	}
+}
+
+func TestParseStatsReport(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	var stats gold.ParseStats
+	if _, err := p.ParseContext(context.Background(), strings.NewReader(statsInput), gold.CollectStats(&stats)); err != nil {
+		t.Fatal(err)
+	}
+	stats.LexTime, stats.ParseTime, stats.Allocations, stats.AllocatedBytes = 0, 0, 0, 0
+	want := `input:           31 bytes, 30 runes
+time:            0s lexing, 0s parsing
+allocations:     0 (0 bytes)
+DFA transitions: 30
+max stack depth: 7
+tokens:          24
+         7  Whitespace
+         3  Identifier
+         2  ;
+         2  NewLine
+         2  Number
+         1  EOF
+         1  (
+         1  )
+         1  *
+         1  +
+         1  =
+         1  Comment
+         1  print
+reductions:      20
+         4  <Term> ::= <Factor>
+         3  <Expr> ::= <Term>
+         2  <Stmts> ::= <Stmt> <Stmts>
+         2  <Factor> ::= Number
+         2  <Factor> ::= Identifier
+         1  <Program> ::= <Stmts>
+         1  <Stmts> ::= 
+         1  <Stmt> ::= Identifier = <Expr> ;
+         1  <Stmt> ::= print <Expr> ;
+         1  <Expr> ::= <Expr> + <Term>
+         1  <Term> ::= <Term> * <Factor>
+         1  <Factor> ::= ( <Expr> )
+`
+	if got := stats.String(); got != want {
+		t.Errorf("got the report\n%s\nwant\n%s", got, want)
+	}
+
+	// the ids are reported without a grammar
+	stats = gold.ParseStats{Tokens: map[gold.SymbolId]int{3: 1}, Reductions: map[gold.RuleId]int{4: 2}}
+	want = "input:           0 bytes, 0 runes\ntime:            0s lexing, 0s parsing\nallocations:     0 (0 bytes)\n" +
+		"DFA transitions: 0\nmax stack depth: 0\ntokens:          1\n         1  symbol 3\nreductions:      2\n         2  rule 4\n"
+	if got := stats.String(); got != want {
+		t.Errorf("got the report\n%s\nwant\n%s", got, want)
+	}
+}
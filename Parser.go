@@ -2,9 +2,14 @@
 package gold
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
 )
 
 // Implements parsing logic
@@ -14,24 +19,59 @@ type Parser interface {
 	// if trimReduction is set to true, tokens which have only one non-terminal sub-node are reduced.
 	Parse(r io.Reader, trimReduce bool) (*Token, error)
 
+	// reads the code from the reader and returns the syntax-tree or a parsing error.
+	// the parsing is aborted with a ParseError wrapping ctx.Err() as soon as the context
+	// is cancelled or its deadline expires. The context is checked in front of each character,
+	// so a Read of r which blocks is not interrupted.
+	// If the error recovery is enabled, the partially parsed syntax-tree is returned together
+	// with the ParseErrors of all syntax errors.
+	ParseContext(ctx context.Context, r io.Reader, opts ...ParseOption) (*Token, error)
+
 	GetInformation() GrammarInformation
+
+	// creates a lexer which scans the code from the reader into terminals without parsing it.
+	NewLexer(r io.Reader) Lexer
+
+	// reads the code from the reader and evaluates it with the given semantic actions instead of
+	// building a syntax-tree. Returns the value of the start symbol or an error.
+	Evaluate(ctx context.Context, r io.Reader, actions *Actions, opts ...ParseOption) (interface{}, error)
+
+	// returns a read-only view on the symbols, rules, groups and states of the grammar
+	Grammar() Grammar
+
+	// returns a copy of the tables of the grammar, which can be modified and written to grammar files.
+	Tables() *Tables
 }
 
 type parser struct {
 	grammar      grammar
 	isCgtGrammar bool
+	view         *grammarView
 }
 
 type grammar interface {
 	getInformation() GrammarInformation
-	readTokens(rd io.Reader) <-chan *parserToken
+	newTokenizer(rd io.Reader, cfg *parseConfig) tokenizer
 	getInitialLRState() *lrState
+	getTables() *goldGrammar
 }
 
 func (p parser) GetInformation() GrammarInformation {
 	return p.grammar.getInformation()
 }
 
+func (p parser) Grammar() Grammar {
+	return p.view
+}
+
+func (p parser) Tables() *Tables {
+	return p.grammar.getTables().tables.Clone()
+}
+
+func (p parser) NewLexer(r io.Reader) Lexer {
+	return newLexer(p.grammar.newTokenizer(r, new(parseConfig)))
+}
+
 type grammarError string
 
 func (ge grammarError) Error() string {
@@ -39,53 +79,316 @@ func (ge grammarError) Error() string {
 }
 
 // Creates a new parser by reading the grammar file from the passed Reader or an error.
-// Currently only supports the cgt grammar files
+// Supports cgt and egt grammar files. Truncated or malformed files are reported as *GrammarFileError.
 func NewParser(grammar io.Reader) (Parser, error) {
+	t, err := ReadTables(grammar)
+	if err != nil {
+		return nil, err
+	}
+	return NewParserFromTables(t)
+}
 
-	rd := bufio.NewReader(grammar)
+func (p *parser) Parse(r io.Reader, trimReduce bool) (*Token, error) {
+	return p.ParseContext(context.Background(), r, TrimReductions(trimReduce))
+}
 
-	head, err := readString(rd)
-	if err != nil {
-		return nil, grammarError("Invalid grammar file format")
+func (p *parser) ParseContext(ctx context.Context, r io.Reader, opts ...ParseOption) (*Token, error) {
+	cfg := newParseConfig(opts)
+
+	builder := newTreeBuilder(cfg)
+	result, err := p.parse(ctx, r, cfg, builder)
+	tree, _ := result.(*Token)
+	builder.finish(tree)
+	return tree, err
+}
+
+func (p *parser) Evaluate(ctx context.Context, r io.Reader, actions *Actions, opts ...ParseOption) (interface{}, error) {
+	cfg := newParseConfig(opts)
+
+	return p.parse(ctx, r, cfg, &actionReducer{actions: actions, trimReduce: cfg.trimReduce})
+}
+
+// builds the values of the syntax-tree nodes from the shifted tokens and the reduced rules
+type reducer interface {
+	shift(t *parserToken) (interface{}, error)
+	reduce(r *rule, values []interface{}) (interface{}, error)
+	// creates the value of a non-terminal which was replaced by the error recovery
+	recovered(nonTerminal *symbol, values []interface{}) (interface{}, error)
+	// called for tokens which are ignored by the parser like noise and comments
+	skip(t *parserToken)
+}
+
+// reducer which builds a tree of *Token
+type treeBuilder struct {
+	trimReduce bool
+	keepTrivia bool
+
+	// the end of the last shifted token, used as position of empty non-terminals
+	lastEnd TextPosition
+
+	// the last shifted terminal and if trivia on its line is still added as trailing trivia
+	lastTerminal   *Token
+	trailingTrivia bool
+	// the trivia which is added as leading trivia to the next terminal
+	pendingTrivia []*Token
+}
+
+func newTreeBuilder(cfg *parseConfig) *treeBuilder {
+	return &treeBuilder{
+		trimReduce: cfg.trimReduce,
+		keepTrivia: cfg.keepTrivia,
+		lastEnd:    TextPosition{Line: 1, Column: 1},
 	}
+}
 
-	parser := new(parser)
+func (tb *treeBuilder) shift(t *parserToken) (interface{}, error) {
+	tb.lastEnd = t.End
+	result := t.toToken()
+	if tb.keepTrivia && !t.Missing {
+		result.LeadingTrivia = tb.pendingTrivia
+		tb.pendingTrivia = nil
+		tb.lastTerminal = result
+		tb.trailingTrivia = true
+	}
+	return result, nil
+}
 
-	switch head {
-	case cgtHeader:
-		parser.grammar = loadCGTGrammar(rd)
-		parser.isCgtGrammar = true
-	case egtHeader:
-		parser.grammar = loadEGTGrammar(rd)
-		parser.isCgtGrammar = false
-	default:
-		return nil, grammarError("Unknown grammar file format: " + head)
+func (tb *treeBuilder) skip(t *parserToken) {
+	if !tb.keepTrivia {
+		return
 	}
-	if parser.grammar == nil {
-		return nil, grammarError("Unable to read grammar file")
+
+	trivia := t.toToken()
+	trivia.IsTerminal = false
+	if tb.trailingTrivia && t.Position.Line == tb.lastTerminal.End.Line {
+		tb.lastTerminal.TrailingTrivia = append(tb.lastTerminal.TrailingTrivia, trivia)
+		// the trailing trivia ends with the line
+		tb.trailingTrivia = !strings.ContainsAny(t.Text, "\r\n")
+	} else {
+		tb.trailingTrivia = false
+		tb.pendingTrivia = append(tb.pendingTrivia, trivia)
 	}
+}
 
-	return parser, nil
+// adds the trivia at the end of the input to the last terminal or to the root
+// if the input has no terminals
+func (tb *treeBuilder) finish(root *Token) {
+	if len(tb.pendingTrivia) == 0 || root == nil {
+		return
+	}
+	if tb.lastTerminal != nil {
+		tb.lastTerminal.TrailingTrivia = append(tb.lastTerminal.TrailingTrivia, tb.pendingTrivia...)
+	} else {
+		root.TrailingTrivia = append(root.TrailingTrivia, tb.pendingTrivia...)
+	}
+	tb.pendingTrivia = nil
 }
 
-func (p *parser) Parse(r io.Reader, trimReduce bool) (*Token, error) {
-	input := p.grammar.readTokens(r)
+func (tb *treeBuilder) reduce(rule *rule, values []interface{}) (interface{}, error) {
+	if tb.trimReduce && len(rule.Symbols) == 1 && rule.Symbols[0].Kind == stNonTerminal {
+		return values[0], nil
+	}
+
+	tokens := make([]*Token, len(values))
+	for idx, v := range values {
+		tokens[idx] = v.(*Token)
+	}
+
+	result := &Token{
+		Name:       rule.NonTerminal.String(),
+		Text:       rule.String(),
+		Tokens:     tokens,
+		IsTerminal: false,
+		Symbol:     SymbolId(rule.NonTerminal.Index),
+		Rule:       RuleId(rule.Index),
+	}
+	tb.setSpan(result)
+	return result, nil
+}
+
+func (tb *treeBuilder) recovered(nonTerminal *symbol, values []interface{}) (interface{}, error) {
+	tokens := make([]*Token, 0, len(values))
+	for _, v := range values {
+		if t, ok := v.(*Token); ok {
+			tokens = append(tokens, t)
+		}
+	}
+
+	result := &Token{
+		Name:       nonTerminal.String(),
+		Text:       "",
+		Tokens:     tokens,
+		IsTerminal: false,
+		IsError:    true,
+		Symbol:     SymbolId(nonTerminal.Index),
+	}
+	tb.setSpan(result)
+	return result, nil
+}
+
+// computes the span of a non-terminal from its sub-nodes
+func (tb *treeBuilder) setSpan(t *Token) {
+	if len(t.Tokens) == 0 {
+		t.Start = tb.lastEnd
+		t.End = tb.lastEnd
+		return
+	}
+	t.Start = t.Tokens[0].Start
+	t.End = t.Tokens[len(t.Tokens)-1].End
+}
+
+// the tokens which are read by the parser
+type tokenInput struct {
+	ctx context.Context
+	// reads the tokens in the goroutine of the parser, so no goroutine is left behind when
+	// the parsing is aborted
+	tokenizer tokenizer
+	done      bool
+	stats     *ParseStats
+	pending   []*parserToken
+}
 
+// returns the next token, nil after the end of the input or the error of the context.
+func (in *tokenInput) next() (*parserToken, error) {
+	if len(in.pending) > 0 {
+		result := in.pending[0]
+		in.pending = in.pending[1:]
+		return result, nil
+	}
+	if err := in.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if in.done {
+		return nil, nil
+	}
+	var start time.Time
+	if in.stats != nil {
+		start = time.Now()
+	}
+	result := in.tokenizer.next()
+	if in.stats != nil {
+		in.stats.LexTime += time.Since(start)
+	}
+	if err := in.ctx.Err(); err != nil {
+		// the token may be cut off where the tokenizer stopped
+		in.done = true
+		return nil, err
+	}
+	if result.Err != nil {
+		in.done = true
+		return nil, result.Err
+	}
+	in.done = result.Symbol.Kind == stEnd
+	if in.stats != nil {
+		in.stats.Tokens[SymbolId(result.Symbol.Index)]++
+		in.stats.Bytes = result.End.Offset
+		in.stats.Runes = result.End.RuneOffset
+	}
+	return result, nil
+}
+
+// puts the tokens back in front of the input
+func (in *tokenInput) unread(tokens ...*parserToken) {
+	in.pending = append(append([]*parserToken(nil), tokens...), in.pending...)
+}
+
+// returns true if the parser ignores tokens of the given symbol
+func isSkipped(s *symbol) bool {
+	switch s.Kind {
+	case stGroupStart, stCommentLine, stNoise, stGroupEnd:
+		return true
+	}
+	return false
+}
+
+func (p *parser) parse(ctx context.Context, r io.Reader, cfg *parseConfig, builder reducer) (interface{}, error) {
+	// the tokenizer stops within long tokens and groups as well
+	cfg.done = ctx.Done()
 	tokenStack := newStack()
 	stateStack := newStack()
 
+	// the events of the tokenizer and the parser carry the stacks
+	if trace := cfg.trace; trace != nil {
+		cfg.trace = func(e *ParseEvent) {
+			e.states, e.values = stateStack, tokenStack
+			trace(e)
+		}
+	}
+	emit := cfg.trace
+	if stats := cfg.stats; stats != nil {
+		stats.reset(p.view)
+		var memStats runtime.MemStats
+		runtime.ReadMemStats(&memStats)
+		start := time.Now()
+		defer func() {
+			stats.ParseTime = time.Since(start) - stats.LexTime
+			stats.readMemStats(&memStats)
+		}()
+	}
+	input := &tokenInput{ctx: ctx, tokenizer: p.grammar.newTokenizer(r, cfg), stats: cfg.stats}
+
 	stateStack.Push(p.grammar.getInitialLRState())
+	cfg.stats.stackDepth(stateStack.Len())
 	var lastToken *parserToken = nil
+	var errors ParseErrors
+	// the number of shifted terminals and reduced rules
+	nodes := 0
+
+	// records an error. returns false if the parsing has to be aborted.
+	addError := func(err *ParseError) bool {
+		errors = append(errors, err)
+		return cfg.recovery != nil && len(errors) < cfg.maxErrors
+	}
+	// returns the error of an aborted parse. Without error recovery that is the only error.
+	fail := func() error {
+		if cfg.recovery == nil {
+			return errors[0]
+		}
+		return errors
+	}
+
+	for {
+		nextToken, err := input.next()
+		if le, ok := err.(*LimitError); ok {
+			return nil, newLimitError(le)
+		}
+		if err != nil {
+			return nil, newContextError(err, lastToken)
+		}
+		if nextToken == nil {
+			break
+		}
 
-	for nextToken := range input {
 		lastToken = nextToken
-		switch nextToken.Symbol.Kind {
-		case stGroupStart, stCommentLine:
+		if emit != nil {
+			emit(&ParseEvent{Kind: EventToken, Terminal: nextToken.toTerminal(), State: topState(stateStack),
+				Symbol: SymbolId(nextToken.Symbol.Index), symbol: nextToken.Symbol})
+		}
+		if nextToken.Runaway {
+			err := newRunawayError(nextToken)
+			if emit != nil {
+				emit(&ParseEvent{Kind: EventError, Terminal: nextToken.toTerminal(), State: topState(stateStack),
+					Symbol: SymbolId(nextToken.Symbol.Index), symbol: nextToken.Symbol, Error: err})
+			}
+			if !addError(err) {
+				return nil, fail()
+			}
+		}
+		if isSkipped(nextToken.Symbol) {
+			builder.skip(nextToken)
 			continue
-		case stNoise:
+		}
+		if nextToken.Symbol.Kind == stError {
+			err := &ParseError{Message: fmt.Sprintf("Unknown Token \"%s\"", nextToken.Text), Position: nextToken.Position}
+			if emit != nil {
+				emit(&ParseEvent{Kind: EventError, Terminal: nextToken.toTerminal(), State: topState(stateStack),
+					Symbol: SymbolId(nextToken.Symbol.Index), symbol: nextToken.Symbol, Error: err})
+			}
+			if !addError(err) {
+				return nil, fail()
+			}
+			builder.skip(nextToken)
 			continue
-		case stError:
-			return nil, &ParseError{Message: fmt.Sprintf("Unknown Token \"%s\"", nextToken.Text), Position: nextToken.Position}
 		}
 
 		tokenParsed := false
@@ -96,45 +399,101 @@ func (p *parser) Parse(r io.Reader, trimReduce bool) (*Token, error) {
 			action := currentState.Actions[nextToken.Symbol]
 
 			if action == nil {
-				return nil, &ParseError{Message: fmt.Sprintf("syntax Error: unexpected \"%s\"", nextToken.Text), Position: nextToken.Position}
+				syntaxError := p.newSyntaxError(stateStack, nextToken)
+				if emit != nil {
+					emit(&ParseEvent{Kind: EventError, Terminal: nextToken.toTerminal(), State: int(currentState.Index),
+						Symbol: SymbolId(nextToken.Symbol.Index), symbol: nextToken.Symbol, Error: syntaxError})
+				}
+				if !addError(syntaxError) {
+					return nil, fail()
+				}
+				ok, err := cfg.recovery.recover(input, nextToken, stateStack, tokenStack, builder)
+				if le, isLimit := err.(*LimitError); isLimit {
+					return nil, newLimitError(le)
+				}
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return nil, fail()
+				}
+				if emit != nil {
+					emit(&ParseEvent{Kind: EventRecover, Terminal: nextToken.toTerminal(), State: topState(stateStack),
+						Symbol: SymbolId(nextToken.Symbol.Index), symbol: nextToken.Symbol})
+				}
+				// the recovery puts back the tokens which have to be parsed next.
+				tokenParsed = true
+				continue
 			}
 
 			switch action.Action {
 			case actionShift:
+				if emit != nil {
+					emit(&ParseEvent{Kind: EventShift, Terminal: nextToken.toTerminal(), State: int(currentState.Index),
+						Target: int(action.TargetState.Index), Symbol: SymbolId(nextToken.Symbol.Index), symbol: nextToken.Symbol})
+				}
+				value, err := builder.shift(nextToken)
+				if err != nil {
+					return nil, newActionError(err, nextToken)
+				}
 				stateStack.Push(action.TargetState)
-				tokenStack.Push(nextToken.toToken())
+				tokenStack.Push(value)
+				cfg.stats.stackDepth(stateStack.Len())
+				nodes++
+				if le := cfg.limits.checkParser(stateStack.Len(), nodes, nextToken.Position); le != nil {
+					return nil, newLimitError(le)
+				}
 				tokenParsed = true
 
 			case actionReduce:
 				rule := action.TargetRule
-				if trimReduce && len(rule.Symbols) == 1 && rule.Symbols[0].Kind == stNonTerminal {
+				values := make([]interface{}, len(rule.Symbols))
+				if stateStack.Len() <= len(values) {
+					return nil, grammarError(fmt.Sprintf("invalid grammar: the rule %s can not be reduced in LR state %d", rule, currentState.Index))
+				}
+				if emit != nil {
+					emit(&ParseEvent{Kind: EventReduce, Terminal: nextToken.toTerminal(), State: int(currentState.Index),
+						Symbol: SymbolId(rule.NonTerminal.Index), symbol: rule.NonTerminal, Rule: RuleId(rule.Index), rule: rule})
+				}
+				if cfg.stats != nil {
+					cfg.stats.Reductions[RuleId(rule.Index)]++
+				}
+
+				for idx := len(values) - 1; idx >= 0; idx-- {
 					stateStack.Pop()
-					currentState = stateStack.Peek().(*lrState)
-				} else {
-					tokenCnt := len(rule.Symbols)
-					tokens := make([]*Token, tokenCnt)
-
-					for idx, _ := range rule.Symbols {
-						stateStack.Pop()
-						tokens[tokenCnt-1-idx] = tokenStack.Pop().(*Token)
-					}
-
-					nttoken := &Token{
-						Name:       rule.NonTerminal.String(),
-						Text:       rule.String(),
-						Tokens:     tokens,
-						IsTerminal: false,
-						Symbol:     SymbolId(rule.NonTerminal.Index),
-						Rule:       RuleId(rule.Index),
-					}
-					tokenStack.Push(nttoken)
-
-					currentState = stateStack.Peek().(*lrState)
+					values[idx] = tokenStack.Pop()
 				}
+
+				value, err := builder.reduce(rule, values)
+				if err != nil {
+					return nil, newActionError(err, nextToken)
+				}
+				tokenStack.Push(value)
+
+				currentState = stateStack.Peek().(*lrState)
 				gotoAction := currentState.Actions[rule.NonTerminal]
+				if gotoAction == nil || gotoAction.Action != actionGoto {
+					return nil, grammarError(fmt.Sprintf("invalid grammar: LR state %d has no goto for %s", currentState.Index, rule.NonTerminal))
+				}
+				if emit != nil {
+					emit(&ParseEvent{Kind: EventGoto, Terminal: nextToken.toTerminal(), State: int(currentState.Index),
+						Target: int(gotoAction.TargetState.Index), Symbol: SymbolId(rule.NonTerminal.Index), symbol: rule.NonTerminal})
+				}
 				stateStack.Push(gotoAction.TargetState)
+				cfg.stats.stackDepth(stateStack.Len())
+				nodes++
+				if le := cfg.limits.checkParser(stateStack.Len(), nodes, nextToken.Position); le != nil {
+					return nil, newLimitError(le)
+				}
 			case actionAccept:
-				return tokenStack.Pop().(*Token), nil
+				if emit != nil {
+					emit(&ParseEvent{Kind: EventAccept, Terminal: nextToken.toTerminal(), State: int(currentState.Index),
+						Symbol: SymbolId(nextToken.Symbol.Index), symbol: nextToken.Symbol})
+				}
+				if len(errors) > 0 {
+					return tokenStack.Pop(), errors
+				}
+				return tokenStack.Pop(), nil
 			}
 		}
 	}
@@ -143,3 +502,75 @@ func (p *parser) Parse(r io.Reader, trimReduce bool) (*Token, error) {
 	}
 	return nil, &ParseError{Message: "Unexpected end of file", Position: TextPosition{Line: 0, Column: 0}}
 }
+
+// creates the error for a token which can not be parsed in the current state
+func (p *parser) newSyntaxError(states *stack, token *parserToken) *ParseError {
+	current := lrStatesOf(states)
+	result := &ParseError{
+		Position:   token.Position,
+		Unexpected: p.view.Symbol(SymbolId(token.Symbol.Index)),
+	}
+
+	for _, a := range sortedActions(current[len(current)-1]) {
+		switch a.Symbol.Kind {
+		case stTerminal, stEnd:
+			if simulateSymbol(current, a.Symbol) != nil {
+				result.Expected = append(result.Expected, p.view.Symbol(SymbolId(a.Symbol.Index)))
+			}
+		}
+	}
+
+	msg := new(bytes.Buffer)
+	if token.Text != "" {
+		fmt.Fprintf(msg, "syntax Error: unexpected \"%s\"", token.Text)
+	} else {
+		fmt.Fprintf(msg, "syntax Error: unexpected %s", token.Symbol.Name)
+	}
+	switch len(result.Expected) {
+	case 0:
+	case 1:
+		fmt.Fprintf(msg, ", expected %s", quoteSymbolName(result.Expected[0]))
+	default:
+		msg.WriteString(", expected one of: ")
+		for i, s := range result.Expected {
+			if i > 0 {
+				msg.WriteString(", ")
+			}
+			msg.WriteString(quoteSymbolName(s))
+		}
+	}
+	result.Message = string(msg.Bytes())
+	return result
+}
+
+// returns the name of the symbol and quotes the names of terminals which are not made of
+// letters and digits only, like the names of operators.
+func quoteSymbolName(s *Symbol) string {
+	for _, r := range s.Name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return fmt.Sprintf("\"%s\"", s.Name)
+		}
+	}
+	return s.Name
+}
+
+// reports a group like a block comment which is not closed before the end of the input
+func newRunawayError(token *parserToken) *ParseError {
+	return &ParseError{Message: fmt.Sprintf("Runaway group %s", token.Symbol), Position: token.Position}
+}
+
+func newContextError(err error, lastToken *parserToken) *ParseError {
+	result := &ParseError{Message: err.Error(), Err: err}
+	if lastToken != nil {
+		result.Position = lastToken.Position
+	}
+	return result
+}
+
+// wraps an error returned by a semantic action. ParseErrors are passed through unchanged.
+func newActionError(err error, token *parserToken) error {
+	if _, ok := err.(*ParseError); ok {
+		return err
+	}
+	return &ParseError{Message: err.Error(), Position: token.Position, Err: err}
+}
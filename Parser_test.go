@@ -0,0 +1,107 @@
+package gold_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+// returns parsers of the calculator grammar in testdata/calc.grm loaded from an egt and a cgt file
+func grammarFormats(t *testing.T) map[string]gold.Parser {
+	t.Helper()
+	result := make(map[string]gold.Parser)
+	for _, format := range []string{"egt", "cgt"} {
+		f, err := os.Open("testdata/calc." + format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := gold.NewParser(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		result[format] = p
+	}
+	return result
+}
+
+// an input which starts with prefix and then repeats text forever. It calls cancel when it
+// is read for the given time.
+type endlessReader struct {
+	prefix   string
+	text     string
+	cancelAt int
+	cancel   func()
+	reads    int
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	if r.reads++; r.reads == r.cancelAt {
+		r.cancel()
+	}
+	if r.reads == 1 && r.prefix != "" {
+		return copy(p, r.prefix), nil
+	}
+	return copy(p, r.text), nil
+}
+
+func TestParseContextCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		text     string
+		cancelAt int
+		opts     []gold.ParseOption
+		// the reads of the input. The input is read in the goroutine of the parser, which
+		// stops in front of the next character, so the count does not change after the parser returned.
+		reads int
+	}{
+		{"cancelled before parsing", "", "x = 1;\n", 0, nil, 0},
+		{"cancelled while parsing", "", "x = 1;\n", 10, nil, 10},
+		{"error recovery", "", "x = 1;\n", 10, []gold.ParseOption{gold.RecoverErrors()}, 10},
+		{"statistics", "", "x = 1;\n", 10, []gold.ParseOption{gold.CollectStats(new(gold.ParseStats))}, 10},
+		// the input never leaves the first token or group
+		{"endless token", "x = ", "1234567890", 10, nil, 10},
+		{"endless group", "x = 1; /*", " comment", 10, nil, 10},
+		{"endless group with trivia", "x = 1; /*", " comment", 10, []gold.ParseOption{gold.KeepTrivia(true)}, 10},
+	}
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancelAt == 0 {
+				cancel()
+			}
+			r := &endlessReader{prefix: tt.prefix, text: tt.text, cancelAt: tt.cancelAt, cancel: cancel}
+			tree, err := p.ParseContext(ctx, r, tt.opts...)
+			cancel()
+			var pe *gold.ParseError
+			if !errors.Is(err, context.Canceled) || !errors.As(err, &pe) || tree != nil {
+				t.Errorf("%s %s: got %v, %v, want a ParseError wrapping context.Canceled", format, tt.name, tree, err)
+			}
+			if r.reads != tt.reads {
+				t.Errorf("%s %s: the input was read %d times, want %d", format, tt.name, r.reads, tt.reads)
+			}
+		}
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	const input = "x = 1 + 2 * 3;\nprint (x - 4.5) / 2;"
+	for format, p := range grammarFormats(t) {
+		for trim, root := range map[bool]string{false: "<Program>", true: "<Stmts>"} {
+			tree, err := p.ParseContext(context.Background(), strings.NewReader(input), gold.TrimReductions(trim))
+			if err != nil {
+				t.Errorf("%s trim %v: %v", format, trim, err)
+				continue
+			}
+			// the rule <Program> ::= <Stmts> is trimmed
+			if tree.Name != root {
+				t.Errorf("%s trim %v: the root is %s, want %s", format, trim, tree.Name, root)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package gold
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// the white space which is printed between two terminals
+type Spacing int
+
+const (
+	// no spacing was requested. If no other spacing is requested between two terminals,
+	// the default of the Printer is used.
+	SpaceDefault Spacing = iota
+	// the terminals are printed without white space
+	SpaceNone
+	// the terminals are separated by a single space
+	SpaceSingle
+	// the second terminal starts a new line
+	SpaceNewLine
+	// the terminals are separated by an empty line
+	SpaceBlankLine
+)
+
+// the layout of the tokens of a symbol or a rule. If several layouts request the spacing
+// between two terminals, the largest spacing is used.
+type Layout struct {
+	// the spacing in front of the first terminal of the token
+	Before Spacing
+	// the spacing behind the last terminal of the token
+	After Spacing
+	// the spacing between the sub-nodes of a non-terminal. Between[i] is used
+	// between the sub-nodes i and i+1.
+	Between []Spacing
+	// tells if the lines which start within the token are indented by one more level.
+	// A token which has the same symbol as its parent is not indented again, so that
+	// recursively defined lists are indented only once.
+	Indent bool
+}
+
+// prints syntax trees as source text. The text of the terminals is printed in order and the
+// white space between them is determined by the layouts registered for their symbols and
+// the symbols and rules of the non-terminals containing them.
+// Trivia is only printed if Comments is set; the original white space is never kept.
+type Printer struct {
+	// the string used for each level of indentation
+	Indent string
+	// tells if the comments kept with the KeepTrivia option are printed. Comments in front
+	// of a terminal are printed on lines of their own, comments behind a terminal are
+	// followed by a new line.
+	Comments bool
+	// returns the spacing between two terminals for which no layout requested a spacing.
+	// If nil, the terminals are separated by a single space.
+	Default func(prev, next *Token) Spacing
+
+	symbols map[SymbolId]Layout
+	rules   map[RuleId]Layout
+}
+
+// creates a printer which indents with tabs
+func NewPrinter() *Printer {
+	return &Printer{
+		Indent:  "\t",
+		symbols: make(map[SymbolId]Layout),
+		rules:   make(map[RuleId]Layout),
+	}
+}
+
+// registers the layout of all tokens of the given symbol
+func (p *Printer) SymbolLayout(id SymbolId, l Layout) *Printer {
+	p.symbols[id] = l
+	return p
+}
+
+// registers the layout of all non-terminals which were reduced by the given rule
+func (p *Printer) RuleLayout(id RuleId, l Layout) *Printer {
+	p.rules[id] = l
+	return p
+}
+
+// writes the source text of the tree to w
+func (p *Printer) Print(w io.Writer, tree *Token) error {
+	bw := bufio.NewWriter(w)
+	if tree != nil {
+		ps := &printState{printer: p, w: bw}
+		ps.node(tree, nil)
+	}
+	return bw.Flush()
+}
+
+// returns the source text of the tree
+func (p *Printer) Format(tree *Token) string {
+	buf := new(bytes.Buffer)
+	p.Print(buf, tree)
+	return buf.String()
+}
+
+// returns the combined layout of the symbol and the rule of the token
+func (p *Printer) layout(tok *Token) Layout {
+	result := p.symbols[tok.Symbol]
+	if tok.IsTerminal || tok.IsError {
+		return result
+	}
+	if rl, ok := p.rules[tok.Rule]; ok {
+		result.Before = maxSpacing(result.Before, rl.Before)
+		result.After = maxSpacing(result.After, rl.After)
+		result.Indent = result.Indent || rl.Indent
+		if rl.Between != nil {
+			result.Between = rl.Between
+		}
+	}
+	return result
+}
+
+func maxSpacing(a, b Spacing) Spacing {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type printState struct {
+	printer *Printer
+	w       *bufio.Writer
+	// the spacing requested in front of the next terminal
+	gap    Spacing
+	indent int
+	// the last printed terminal or comment
+	prev *Token
+}
+
+func (ps *printState) request(s Spacing) {
+	ps.gap = maxSpacing(ps.gap, s)
+}
+
+func (ps *printState) node(tok, parent *Token) {
+	layout := ps.printer.layout(tok)
+	ps.request(layout.Before)
+	indent := layout.Indent && (parent == nil || parent.Symbol != tok.Symbol)
+	if indent {
+		ps.indent++
+	}
+	if tok.IsTerminal {
+		ps.terminal(tok)
+	} else {
+		for i, sub := range tok.Tokens {
+			if i > 0 && i <= len(layout.Between) {
+				ps.request(layout.Between[i-1])
+			}
+			if sub != nil {
+				ps.node(sub, tok)
+			}
+		}
+	}
+	if indent {
+		ps.indent--
+	}
+	ps.request(layout.After)
+}
+
+func (ps *printState) terminal(tok *Token) {
+	if ps.printer.Comments {
+		for _, trivia := range tok.LeadingTrivia {
+			if isComment(trivia) {
+				ps.request(SpaceNewLine)
+				ps.write(trivia, strings.TrimRight(trivia.Text, "\r\n"))
+				ps.request(SpaceNewLine)
+			}
+		}
+	}
+	if tok.Text != "" {
+		ps.write(tok, tok.Text)
+	}
+	if ps.printer.Comments {
+		for _, trivia := range tok.TrailingTrivia {
+			if isComment(trivia) {
+				ps.request(SpaceSingle)
+				ps.write(trivia, strings.TrimRight(trivia.Text, "\r\n"))
+				ps.request(SpaceNewLine)
+			}
+		}
+	}
+}
+
+// tells if the trivia is not only white space
+func isComment(trivia *Token) bool {
+	return strings.TrimSpace(trivia.Text) != ""
+}
+
+func (ps *printState) write(tok *Token, text string) {
+	if ps.prev == nil {
+		ps.writeIndent()
+	} else {
+		gap := ps.gap
+		if gap == SpaceDefault {
+			gap = SpaceSingle
+			if ps.printer.Default != nil {
+				gap = ps.printer.Default(ps.prev, tok)
+			}
+		}
+		switch gap {
+		case SpaceSingle:
+			ps.w.WriteByte(' ')
+		case SpaceNewLine, SpaceBlankLine:
+			ps.w.WriteByte('\n')
+			if gap == SpaceBlankLine {
+				ps.w.WriteByte('\n')
+			}
+			ps.writeIndent()
+		}
+	}
+	ps.w.WriteString(text)
+	ps.gap = SpaceDefault
+	ps.prev = tok
+}
+
+func (ps *printState) writeIndent() {
+	for i := 0; i < ps.indent; i++ {
+		ps.w.WriteString(ps.printer.Indent)
+	}
+}
@@ -0,0 +1,89 @@
+package gold_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestPrinter(t *testing.T) {
+	const input = "x=1+2*(3-y);print -x;"
+	tree, g := parseCalc(t, input)
+	sym := func(name string) gold.SymbolId {
+		return g.SymbolByName(name).Id
+	}
+	// the layout of the calculator with a statement on each line and without spaces in front of ;
+	lines := func(p *gold.Printer) *gold.Printer {
+		return p.SymbolLayout(sym("<Stmt>"), gold.Layout{After: gold.SpaceNewLine}).
+			SymbolLayout(sym(";"), gold.Layout{Before: gold.SpaceNone})
+	}
+	tests := []struct {
+		name    string
+		printer *gold.Printer
+		want    string
+	}{
+		{"default", gold.NewPrinter(), "x = 1 + 2 * ( 3 - y ) ; print - x ;"},
+		{"symbol layouts", lines(gold.NewPrinter()), "x = 1 + 2 * ( 3 - y );\nprint - x;"},
+		{"rule layouts", lines(gold.NewPrinter()).
+			RuleLayout(ruleId(t, g, "<Factor> ::= - <Factor>"), gold.Layout{Between: []gold.Spacing{gold.SpaceNone}}).
+			RuleLayout(ruleId(t, g, "<Factor> ::= ( <Expr> )"), gold.Layout{Between: []gold.Spacing{gold.SpaceNone, gold.SpaceNone}}),
+			"x = 1 + 2 * (3 - y);\nprint -x;"},
+		// the largest spacing is used
+		{"largest spacing", lines(gold.NewPrinter()).
+			RuleLayout(ruleId(t, g, "<Stmt> ::= print <Expr> ;"), gold.Layout{Before: gold.SpaceBlankLine}).
+			SymbolLayout(sym("="), gold.Layout{Before: gold.SpaceNone, After: gold.SpaceSingle}),
+			"x= 1 + 2 * ( 3 - y );\n\nprint - x;"},
+		// the recursive <Stmts> are only indented once
+		{"indent", lines(gold.NewPrinter()).SymbolLayout(sym("<Stmts>"), gold.Layout{Indent: true}),
+			"\tx = 1 + 2 * ( 3 - y );\n\tprint - x;"},
+		{"indent string", func() *gold.Printer {
+			p := lines(gold.NewPrinter()).SymbolLayout(sym("<Stmts>"), gold.Layout{Indent: true})
+			p.Indent = "  "
+			return p
+		}(), "  x = 1 + 2 * ( 3 - y );\n  print - x;"},
+		{"default spacing", func() *gold.Printer {
+			p := lines(gold.NewPrinter())
+			p.Default = func(prev, next *gold.Token) gold.Spacing {
+				if prev.Text == "(" || next.Text == ")" || prev.Text == "-" && next.Text == "x" {
+					return gold.SpaceNone
+				}
+				return gold.SpaceSingle
+			}
+			return p
+		}(), "x = 1 + 2 * (3 - y);\nprint -x;"},
+	}
+	for _, tt := range tests {
+		if got := tt.printer.Format(tree); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+		buf := new(bytes.Buffer)
+		if err := tt.printer.Print(buf, tree); err != nil || buf.String() != tt.want {
+			t.Errorf("%s: Print wrote %q, %v", tt.name, buf.String(), err)
+		}
+	}
+	if got := gold.NewPrinter().Format(nil); got != "" {
+		t.Errorf("got %q for an empty tree", got)
+	}
+}
+
+func TestPrinterComments(t *testing.T) {
+	const input = "/* head */\nx = 1; // one\n  /* two */ print   x; /* end */"
+	p := grammarFormats(t)["egt"]
+	tree, err := p.ParseContext(context.Background(), strings.NewReader(input), gold.KeepTrivia(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	printer := gold.NewPrinter()
+	if got, want := printer.Format(tree), "x = 1 ; print x ;"; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	// the comments in front of a terminal are on lines of their own and a new line follows
+	// the comments behind a terminal
+	printer.Comments = true
+	if got, want := printer.Format(tree), "/* head */\nx = 1 ; // one\n/* two */\nprint x ; /* end */"; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
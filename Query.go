@@ -0,0 +1,437 @@
+package gold
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A compiled query which searches patterns in syntax trees. The syntax is similar to the
+// queries of tree-sitter:
+//
+//	(<Call> . Identifier="print" <Args>) @call
+//
+// A node pattern is a symbol optionally followed by = and a quoted string which must be
+// equal to the text of a terminal. Symbols are written as <Name> for non-terminals, as bare
+// names or in single quotes for all other symbols and as _ for any symbol. A quoted string
+// alone is the same as _="text".
+// A node pattern in parentheses is followed by the patterns of its sub-nodes, which must
+// match the sub-nodes in order but not necessarily consecutive. A . anchors the following
+// pattern to the first sub-node, the preceding pattern to the last sub-node or two patterns
+// to consecutive sub-nodes.
+// @name behind a pattern captures the matched token. A query may contain several patterns
+// and ; starts a comment up to the end of the line.
+type Query struct {
+	patterns []*queryNode
+	captures []string
+}
+
+// a matched pattern of a query
+type QueryMatch struct {
+	// the index of the pattern in the query
+	Pattern int
+	// the token which matched the pattern
+	Token *Token
+	// the captured tokens. The capture of a node pattern precedes the captures of its sub-patterns.
+	Captures []QueryCapture
+}
+
+// a token captured by a pattern
+type QueryCapture struct {
+	Name  string
+	Token *Token
+}
+
+// represents a syntax error of a query or a symbol which is not part of the grammar
+type QueryError struct {
+	Message string
+	// the byte offset of the error within the query
+	Offset int
+}
+
+// returns the error message as string
+func (qe *QueryError) Error() string {
+	return fmt.Sprintf("query: %s at offset %d", qe.Message, qe.Offset)
+}
+
+type queryNode struct {
+	anySymbol bool
+	symbol    SymbolId
+	hasText   bool
+	text      string
+	capture   string
+
+	children []*queryNode
+	// anchors the first child to the first sub-node
+	anchorFirst bool
+	// anchors the last child to the last sub-node
+	anchorLast bool
+	// tells for each child if it has to follow the previous child directly
+	adjacent []bool
+}
+
+// compiles a query for trees parsed with the grammar
+func CompileQuery(g Grammar, query string) (*Query, error) {
+	qp := &queryParser{grammar: g, src: query}
+	result := new(Query)
+	for {
+		qp.skipSpace()
+		if qp.eof() {
+			break
+		}
+		n, err := qp.pattern()
+		if err != nil {
+			return nil, err
+		}
+		result.patterns = append(result.patterns, n)
+	}
+	if len(result.patterns) == 0 {
+		return nil, &QueryError{"the query contains no pattern", 0}
+	}
+	result.captures = qp.captures
+	return result, nil
+}
+
+// compiles a query and panics if it is not valid
+func MustCompileQuery(g Grammar, query string) *Query {
+	q, err := CompileQuery(g, query)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// returns the names of the captures of the query
+func (q *Query) CaptureNames() []string {
+	return append([]string(nil), q.captures...)
+}
+
+// returns all matches of the query within the tree in depth-first order. If several patterns
+// match the same token, the matches are ordered by the patterns.
+func (q *Query) Matches(tree *Token) []QueryMatch {
+	var result []QueryMatch
+	q.Each(tree, func(m QueryMatch) bool {
+		result = append(result, m)
+		return true
+	})
+	return result
+}
+
+// returns the first match of the query within the tree
+func (q *Query) First(tree *Token) (QueryMatch, bool) {
+	var result QueryMatch
+	found := false
+	q.Each(tree, func(m QueryMatch) bool {
+		result, found = m, true
+		return false
+	})
+	return result, found
+}
+
+// calls f for the matches of the query within the tree until f returns false
+func (q *Query) Each(tree *Token, f func(m QueryMatch) bool) {
+	Walk(tree, VisitorFuncs{OnEnter: func(tok *Token, _ []*Token) WalkAction {
+		for idx, p := range q.patterns {
+			var captures []QueryCapture
+			if p.match(tok, &captures) && !f(QueryMatch{Pattern: idx, Token: tok, Captures: captures}) {
+				return WalkStop
+			}
+		}
+		return WalkContinue
+	}})
+}
+
+// returns the first token captured with the given name or nil
+func (m QueryMatch) Capture(name string) *Token {
+	for _, c := range m.Captures {
+		if c.Name == name {
+			return c.Token
+		}
+	}
+	return nil
+}
+
+// returns all tokens captured with the given name
+func (m QueryMatch) CaptureAll(name string) []*Token {
+	var result []*Token
+	for _, c := range m.Captures {
+		if c.Name == name {
+			result = append(result, c.Token)
+		}
+	}
+	return result
+}
+
+func (n *queryNode) match(tok *Token, captures *[]QueryCapture) bool {
+	if tok == nil {
+		return false
+	}
+	if !n.anySymbol && tok.Symbol != n.symbol {
+		return false
+	}
+	if n.hasText && (!tok.IsTerminal || tok.Text != n.text) {
+		return false
+	}
+	if n.capture != "" {
+		*captures = append(*captures, QueryCapture{n.capture, tok})
+	}
+	if len(n.children) == 0 && !n.anchorFirst && !n.anchorLast {
+		return true
+	}
+	return n.matchChildren(tok.Tokens, 0, 0, captures)
+}
+
+// matches the children from the index child against the sub-nodes beginning at start
+func (n *queryNode) matchChildren(toks []*Token, child, start int, captures *[]QueryCapture) bool {
+	if child == len(n.children) {
+		return !n.anchorLast || start == len(toks)
+	}
+	end := len(toks)
+	if (child == 0 && n.anchorFirst) || n.adjacent[child] {
+		if end > start+1 {
+			end = start + 1
+		}
+	}
+	pattern := n.children[child]
+	for i := start; i < end; i++ {
+		count := len(*captures)
+		if pattern.match(toks[i], captures) && n.matchChildren(toks, child+1, i+1, captures) {
+			return true
+		}
+		*captures = (*captures)[:count]
+	}
+	return false
+}
+
+type queryParser struct {
+	grammar  Grammar
+	src      string
+	pos      int
+	captures []string
+}
+
+func (qp *queryParser) eof() bool {
+	return qp.pos >= len(qp.src)
+}
+
+func (qp *queryParser) peek() byte {
+	if qp.eof() {
+		return 0
+	}
+	return qp.src[qp.pos]
+}
+
+func (qp *queryParser) errorf(offset int, format string, args ...interface{}) error {
+	return &QueryError{fmt.Sprintf(format, args...), offset}
+}
+
+func (qp *queryParser) skipSpace() {
+	for !qp.eof() {
+		switch c := qp.peek(); {
+		case c == ';':
+			for !qp.eof() && qp.peek() != '\n' {
+				qp.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			qp.pos++
+		default:
+			return
+		}
+	}
+}
+
+func isQueryNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-' || c >= utf8.RuneSelf
+}
+
+func (qp *queryParser) name() string {
+	start := qp.pos
+	for !qp.eof() && isQueryNameChar(qp.peek()) {
+		qp.pos++
+	}
+	return qp.src[start:qp.pos]
+}
+
+// reads a pattern with an optional capture
+func (qp *queryParser) pattern() (*queryNode, error) {
+	var n *queryNode
+	var err error
+	if qp.peek() == '(' {
+		n, err = qp.parenthesized()
+	} else {
+		n, err = qp.node()
+	}
+	if err != nil {
+		return nil, err
+	}
+	qp.skipSpace()
+	if qp.peek() == '@' {
+		start := qp.pos
+		qp.pos++
+		n.capture = qp.name()
+		if n.capture == "" {
+			return nil, qp.errorf(start, "missing capture name")
+		}
+		qp.addCapture(n.capture)
+	}
+	return n, nil
+}
+
+func (qp *queryParser) addCapture(name string) {
+	for _, c := range qp.captures {
+		if c == name {
+			return
+		}
+	}
+	qp.captures = append(qp.captures, name)
+}
+
+func (qp *queryParser) parenthesized() (*queryNode, error) {
+	open := qp.pos
+	qp.pos++
+	qp.skipSpace()
+	n, err := qp.node()
+	if err != nil {
+		return nil, err
+	}
+	anchored := false
+	for {
+		qp.skipSpace()
+		switch qp.peek() {
+		case 0:
+			return nil, qp.errorf(open, "unclosed (")
+		case ')':
+			qp.pos++
+			if anchored {
+				if len(n.children) == 0 {
+					n.anchorFirst = true
+				}
+				n.anchorLast = true
+			}
+			return n, nil
+		case '.':
+			if anchored {
+				return nil, qp.errorf(qp.pos, "duplicate anchor")
+			}
+			anchored = true
+			qp.pos++
+		default:
+			child, err := qp.pattern()
+			if err != nil {
+				return nil, err
+			}
+			if anchored && len(n.children) == 0 {
+				n.anchorFirst = true
+			}
+			n.children = append(n.children, child)
+			n.adjacent = append(n.adjacent, anchored && len(n.children) > 1)
+			anchored = false
+		}
+	}
+}
+
+// reads a symbol with an optional text or a text
+func (qp *queryParser) node() (*queryNode, error) {
+	start := qp.pos
+	n := new(queryNode)
+	switch c := qp.peek(); {
+	case c == '"':
+		text, err := qp.text()
+		if err != nil {
+			return nil, err
+		}
+		n.anySymbol, n.hasText, n.text = true, true, text
+		return n, nil
+	case c == '<':
+		end := strings.IndexByte(qp.src[qp.pos:], '>')
+		if end < 0 {
+			return nil, qp.errorf(start, "unclosed <")
+		}
+		name := qp.src[qp.pos+1 : qp.pos+end]
+		qp.pos += end + 1
+		if err := qp.resolve(n, name, true, start); err != nil {
+			return nil, err
+		}
+	case c == '\'':
+		end := strings.IndexByte(qp.src[qp.pos+1:], '\'')
+		if end < 0 {
+			return nil, qp.errorf(start, "unclosed '")
+		}
+		name := qp.src[qp.pos+1 : qp.pos+1+end]
+		qp.pos += end + 2
+		if err := qp.resolve(n, name, false, start); err != nil {
+			return nil, err
+		}
+	case isQueryNameChar(c):
+		name := qp.name()
+		if name == "_" {
+			n.anySymbol = true
+		} else if err := qp.resolve(n, name, false, start); err != nil {
+			return nil, err
+		}
+	case c == 0:
+		return nil, qp.errorf(start, "unexpected end of the query")
+	default:
+		return nil, qp.errorf(start, "unexpected %q", c)
+	}
+
+	if qp.peek() == '=' {
+		qp.pos++
+		if qp.peek() != '"' {
+			return nil, qp.errorf(qp.pos, "missing text behind =")
+		}
+		text, err := qp.text()
+		if err != nil {
+			return nil, err
+		}
+		n.hasText, n.text = true, text
+	}
+	return n, nil
+}
+
+// reads a double quoted string with the escape sequences of Go
+func (qp *queryParser) text() (string, error) {
+	start := qp.pos
+	for i := qp.pos + 1; i < len(qp.src); i++ {
+		switch qp.src[i] {
+		case '\\':
+			i++
+		case '"':
+			text, err := strconv.Unquote(qp.src[start : i+1])
+			if err != nil {
+				return "", qp.errorf(start, "invalid text %s", qp.src[start:i+1])
+			}
+			qp.pos = i + 1
+			return text, nil
+		}
+	}
+	return "", qp.errorf(start, "unclosed \"")
+}
+
+// looks up the symbol of the name. Names which do not match exactly are compared
+// case-insensitive like the symbol names of grammar files.
+func (qp *queryParser) resolve(n *queryNode, name string, nonTerminal bool, offset int) error {
+	var found *Symbol
+	for _, s := range qp.grammar.Symbols() {
+		if (s.Kind == KindNonTerminal) != nonTerminal {
+			continue
+		}
+		if s.Name == name {
+			found = s
+			break
+		}
+		if found == nil && strings.EqualFold(s.Name, name) {
+			found = s
+		}
+	}
+	if found == nil {
+		if nonTerminal {
+			name = "<" + name + ">"
+		}
+		return qp.errorf(offset, "unknown symbol %s", name)
+	}
+	n.symbol = found.Id
+	return nil
+}
@@ -0,0 +1,164 @@
+package gold_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+const queryInput = "x = 1 + 2; print x; y = -x;"
+
+// prints a match as the index of the pattern, the token and the captures
+func matchString(m gold.QueryMatch) string {
+	parts := []string{fmt.Sprint(m.Pattern), treeString(m.Token)}
+	for _, c := range m.Captures {
+		parts = append(parts, "@"+c.Name+"="+treeString(c.Token))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestQueryMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		matches []string
+	}{
+		{"sub-node", "(<Stmt> Identifier @name)", []string{
+			"0 (<Stmt> x = (<Expr> (<Factor> 1) + (<Factor> 2)) ;) @name=x",
+			"0 (<Stmt> y = (<Factor> - (<Factor> x)) ;) @name=y",
+		}},
+		{"text", `Identifier="x" @id`, []string{"0 x @id=x", "0 x @id=x", "0 x @id=x"}},
+		{"text of any symbol", `"y"`, []string{"0 y"}},
+		{"text of a non-terminal", `<Factor>="x"`, nil},
+		{"case-insensitive symbol", "PRINT", []string{"0 print"}},
+		{"quoted symbol", "(<Stmt> '=' @eq)", []string{
+			"0 (<Stmt> x = (<Expr> (<Factor> 1) + (<Factor> 2)) ;) @eq==",
+			"0 (<Stmt> y = (<Factor> - (<Factor> x)) ;) @eq==",
+		}},
+		{"anchored to the first sub-node", "(<Stmt> . print)", []string{"0 (<Stmt> print (<Factor> x) ;)"}},
+		{"not the first sub-node", "(<Stmt> . '=')", nil},
+		{"anchored to the last sub-node", `(<Factor> Identifier .) @f`, []string{"0 (<Factor> x) @f=(<Factor> x)", "0 (<Factor> x) @f=(<Factor> x)"}},
+		{"not the last sub-node", "(<Stmt> <Expr> .)", nil},
+		{"only sub-node", "(<Factor> . Number .)", []string{"0 (<Factor> 1)", "0 (<Factor> 2)"}},
+		{"consecutive sub-nodes", "(<Expr> <Factor> . '+')", []string{"0 (<Expr> (<Factor> 1) + (<Factor> 2))"}},
+		{"not consecutive", "(<Expr> <Factor> . <Factor>)", nil},
+		{"sub-nodes in order", "(<Expr> <Factor> @a <Factor> @b)", []string{
+			"0 (<Expr> (<Factor> 1) + (<Factor> 2)) @a=(<Factor> 1) @b=(<Factor> 2)",
+		}},
+		{"sub-nodes in the wrong order", "(<Stmt> ';' Identifier)", nil},
+		{"wildcard", "(_ '-' _ @operand)", []string{"0 (<Factor> - (<Factor> x)) @operand=(<Factor> x)"}},
+		{"nested patterns", `(<Stmt> Identifier @id (<Expr> _ @l "+" _ @r)) @stmt`, []string{
+			"0 (<Stmt> x = (<Expr> (<Factor> 1) + (<Factor> 2)) ;) @stmt=(<Stmt> x = (<Expr> (<Factor> 1) + (<Factor> 2)) ;) @id=x @l=(<Factor> 1) @r=(<Factor> 2)",
+		}},
+		// the captures of patterns which failed to match are removed
+		{"backtracking", "(<Expr> _ @a . <Factor>)", []string{"0 (<Expr> (<Factor> 1) + (<Factor> 2)) @a=+"}},
+		{"several patterns", "(<Stmt> . print) @p ; a comment\n Number @n", []string{
+			"1 1 @n=1", "1 2 @n=2", "0 (<Stmt> print (<Factor> x) ;) @p=(<Stmt> print (<Factor> x) ;)",
+		}},
+		{"no match", "(<Factor> '(')", nil},
+	}
+	tree, g := parseCalc(t, queryInput)
+	for _, tt := range tests {
+		q, err := gold.CompileQuery(g, tt.query)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, m := range q.Matches(tree) {
+			got = append(got, matchString(m))
+		}
+		if !reflect.DeepEqual(got, tt.matches) {
+			t.Errorf("%s: got the matches\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.matches, "\n"))
+		}
+	}
+}
+
+func TestQueryOrder(t *testing.T) {
+	tree, g := parseCalc(t, queryInput)
+	q := gold.MustCompileQuery(g, "Number @n (<Expr> _ '+' _) @e Number @m")
+	var got []string
+	for _, m := range q.Matches(tree) {
+		got = append(got, matchString(m))
+	}
+	// the matches are ordered by the tokens and then by the patterns
+	want := []string{
+		"1 (<Expr> (<Factor> 1) + (<Factor> 2)) @e=(<Expr> (<Factor> 1) + (<Factor> 2))",
+		"0 1 @n=1", "2 1 @m=1", "0 2 @n=2", "2 2 @m=2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got the matches\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if names := q.CaptureNames(); !reflect.DeepEqual(names, []string{"n", "e", "m"}) {
+		t.Errorf("got the capture names %q", names)
+	}
+
+	m, ok := q.First(tree)
+	if !ok || m.Pattern != 1 || m.Capture("e") != m.Token || m.Capture("n") != nil {
+		t.Errorf("First returned %s, %v", matchString(m), ok)
+	}
+	if _, ok := gold.MustCompileQuery(g, "'('").First(tree); ok {
+		t.Error("First found a missing symbol")
+	}
+
+	count := 0
+	q.Each(tree, func(m gold.QueryMatch) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Errorf("Each called the function %d times after it returned false", count)
+	}
+
+	m = gold.MustCompileQuery(g, "(<Expr> _ @operand '+' _ @operand)").Matches(tree)[0]
+	if ops := m.CaptureAll("operand"); len(ops) != 2 || ops[0].Tokens[0].Text != "1" || ops[1].Tokens[0].Text != "2" {
+		t.Errorf("CaptureAll returned %v", ops)
+	}
+}
+
+func TestCompileQueryErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		message string
+		offset  int
+	}{
+		{"", "the query contains no pattern", 0},
+		{" ; only a comment", "the query contains no pattern", 0},
+		{"(<Stmt> print", "unclosed (", 0},
+		{"<Stmt", "unclosed <", 0},
+		{"'print", "unclosed '", 0},
+		{`"print`, `unclosed "`, 0},
+		{`"\q"`, `invalid text "\q"`, 0},
+		{"(<Stmt>) <Nope>", "unknown symbol <Nope>", 9},
+		{"(<Stmt> Stmt)", "unknown symbol Stmt", 8},
+		{"Identifier @", "missing capture name", 11},
+		{"Identifier=x", "missing text behind =", 11},
+		{"(<Stmt> . . print)", "duplicate anchor", 10},
+		{"()", "unexpected ')'", 1},
+		{"(", "unexpected end of the query", 1},
+		{"@x", `unexpected '@'`, 0},
+	}
+	_, g := parseCalc(t, queryInput)
+	for _, tt := range tests {
+		q, err := gold.CompileQuery(g, tt.query)
+		var qe *gold.QueryError
+		if !errors.As(err, &qe) || q != nil {
+			t.Errorf("%q: got %v, want a QueryError", tt.query, err)
+			continue
+		}
+		if qe.Message != tt.message || qe.Offset != tt.offset {
+			t.Errorf("%q: got %q at %d, want %q at %d", tt.query, qe.Message, qe.Offset, tt.message, tt.offset)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompileQuery did not panic")
+		}
+	}()
+	gold.MustCompileQuery(g, "<Nope>")
+}
@@ -0,0 +1,241 @@
+package gold
+
+import "sort"
+
+// implements the error recovery of the parser
+type errorRecovery struct {
+	// the terminals on which the panic mode synchronises. empty for all terminals.
+	syncSymbols map[SymbolId]bool
+}
+
+func newErrorRecovery(syncSymbols []SymbolId) *errorRecovery {
+	result := &errorRecovery{syncSymbols: make(map[SymbolId]bool)}
+	for _, id := range syncSymbols {
+		result.syncSymbols[id] = true
+	}
+	return result
+}
+
+// the maximum number of reductions which are simulated for a single symbol
+const maxSimulationSteps = 10000
+
+// returns the states of the stack from the bottom to the top
+func lrStatesOf(states *stack) []*lrState {
+	result := make([]*lrState, states.count)
+	for i := range result {
+		result[i] = states.nodes[i].(*lrState)
+	}
+	return result
+}
+
+// simulates the parsing of the symbol on a copy of the given states.
+// returns the states after the symbol was shifted or nil if the symbol can not be parsed.
+func simulateSymbol(states []*lrState, s *symbol) []*lrState {
+	states = append([]*lrState(nil), states...)
+	for step := 0; step < maxSimulationSteps; step++ {
+		action := states[len(states)-1].Actions[s]
+		if action == nil {
+			return nil
+		}
+
+		switch action.Action {
+		case actionShift:
+			return append(states, action.TargetState)
+		case actionAccept:
+			return states
+		case actionReduce:
+			rule := action.TargetRule
+			if len(rule.Symbols) >= len(states) {
+				return nil
+			}
+			states = states[:len(states)-len(rule.Symbols)]
+			gotoAction := states[len(states)-1].Actions[rule.NonTerminal]
+			if gotoAction == nil || gotoAction.TargetState == nil {
+				return nil
+			}
+			states = append(states, gotoAction.TargetState)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// returns the actions of the state ordered by their symbols
+func sortedActions(state *lrState) []*lrAction {
+	result := make([]*lrAction, 0, len(state.Actions))
+	for _, a := range state.Actions {
+		result = append(result, a)
+	}
+	sort.Sort(actionsBySymbol(result))
+	return result
+}
+
+type actionsBySymbol []*lrAction
+
+func (a actionsBySymbol) Len() int           { return len(a) }
+func (a actionsBySymbol) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a actionsBySymbol) Less(i, j int) bool { return a[i].Symbol.Index < a[j].Symbol.Index }
+
+// reads the next token which is not ignored by the parser and puts it back into the input.
+// returns nil at the end of the input.
+func peekSignificant(in *tokenInput) (*parserToken, error) {
+	var read []*parserToken
+	defer func() {
+		in.unread(read...)
+	}()
+
+	for {
+		t, err := in.next()
+		if err != nil || t == nil {
+			return nil, err
+		}
+		read = append(read, t)
+		if !isSkipped(t.Symbol) {
+			return t, nil
+		}
+	}
+}
+
+func (er *errorRecovery) isSyncSymbol(s *symbol) bool {
+	if s.Kind == stEnd {
+		return true
+	}
+	if s.Kind != stTerminal {
+		return false
+	}
+	return len(er.syncSymbols) == 0 || er.syncSymbols[SymbolId(s.Index)]
+}
+
+// tries to recover from a syntax error at the given token. The stacks are modified and the
+// tokens which have to be parsed next are put back into the input.
+// returns false if the parser is not able to recover.
+func (er *errorRecovery) recover(in *tokenInput, token *parserToken, states, values *stack, builder reducer) (bool, error) {
+	current := lrStatesOf(states)
+
+	// 1. insert a single missing terminal
+	for _, a := range sortedActions(current[len(current)-1]) {
+		if a.Symbol.Kind != stTerminal {
+			continue
+		}
+		if next := simulateSymbol(current, a.Symbol); next != nil && simulateSymbol(next, token.Symbol) != nil {
+			missing := &parserToken{Symbol: a.Symbol, Position: token.Position, End: token.Position, Missing: true}
+			in.unread(missing, token)
+			return true, nil
+		}
+	}
+
+	// 2. delete the unexpected token
+	if token.Symbol.Kind != stEnd {
+		next, err := peekSignificant(in)
+		if err != nil {
+			return false, err
+		}
+		if next != nil && next.Symbol.Kind != stError && simulateSymbol(current, next.Symbol) != nil {
+			builder.skip(token)
+			return true, nil
+		}
+	}
+
+	// 3. panic mode: skip tokens until a synchronisation terminal can be parsed
+	var skipped []interface{}
+	for {
+		if er.isSyncSymbol(token.Symbol) {
+			ok, err := er.synchronize(token, states, values, skipped, builder)
+			if ok || err != nil {
+				if ok {
+					in.unread(token)
+				}
+				return ok, err
+			}
+		}
+		if token.Symbol.Kind == stEnd {
+			return false, nil
+		}
+		if token.Symbol.Kind != stError {
+			value, err := builder.shift(token)
+			if err != nil {
+				return false, newActionError(err, token)
+			}
+			skipped = append(skipped, value)
+		} else {
+			builder.skip(token)
+		}
+
+		var err error
+		for token, err = in.next(); err == nil && token != nil && isSkipped(token.Symbol); token, err = in.next() {
+			builder.skip(token)
+		}
+		if err != nil {
+			return false, err
+		}
+		if token == nil {
+			return false, nil
+		}
+	}
+}
+
+// searches the stack for a state which can parse the token after a non-terminal was replaced
+// by an error node. returns false if there is no such state.
+func (er *errorRecovery) synchronize(token *parserToken, states, values *stack, skipped []interface{}, builder reducer) (bool, error) {
+	current := lrStatesOf(states)
+
+	for depth := len(current); depth > 0; depth-- {
+		base := current[:depth]
+
+		for _, a := range sortedActions(base[depth-1]) {
+			if a.Action != actionGoto || a.TargetState == nil {
+				continue
+			}
+			if simulateSymbol(append(base[:depth:depth], a.TargetState), token.Symbol) == nil {
+				continue
+			}
+
+			removed := make([]interface{}, len(current)-depth)
+			for idx := len(removed) - 1; idx >= 0; idx-- {
+				states.Pop()
+				removed[idx] = values.Pop()
+			}
+			value, err := builder.recovered(a.Symbol, append(removed, skipped...))
+			if err != nil {
+				return false, newActionError(err, token)
+			}
+			values.Push(value)
+			states.Push(a.TargetState)
+			return true, nil
+		}
+
+		// the token can be parsed after the states above the base are removed. The value of
+		// the non-terminal which led to the top of the base is replaced by an error node
+		// containing the removed and skipped values, so that they are kept in the tree.
+		if depth > 1 && depth < len(current) && simulateSymbol(base, token.Symbol) != nil {
+			nonTerminal := gotoSymbol(base[depth-2], base[depth-1])
+			if nonTerminal == nil {
+				continue
+			}
+			removed := make([]interface{}, len(current)-depth+1)
+			for idx := len(removed) - 1; idx >= 0; idx-- {
+				states.Pop()
+				removed[idx] = values.Pop()
+			}
+			value, err := builder.recovered(nonTerminal, append(removed, skipped...))
+			if err != nil {
+				return false, newActionError(err, token)
+			}
+			values.Push(value)
+			states.Push(base[depth-1])
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// returns the non-terminal of the goto from one state to another or nil if there is no such goto
+func gotoSymbol(from, to *lrState) *symbol {
+	for _, a := range sortedActions(from) {
+		if a.Action == actionGoto && a.TargetState == to {
+			return a.Symbol
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package gold_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+// prints a syntax tree in one line. Non-terminals are printed as (<Name> children), the error
+// nodes of the recovery as (!<Name> children) and missing terminals as [Name].
+func treeString(tok *gold.Token) string {
+	if tok == nil {
+		return "nil"
+	}
+	if tok.IsTerminal {
+		if tok.IsError {
+			return "[" + tok.Name + "]"
+		}
+		return tok.Text
+	}
+	buf := new(strings.Builder)
+	buf.WriteString("(")
+	if tok.IsError {
+		buf.WriteString("!")
+	}
+	buf.WriteString(tok.Name)
+	for _, c := range tok.Tokens {
+		buf.WriteString(" ")
+		buf.WriteString(treeString(c))
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// returns the strategy which was used to recover from the errors in the tree: "insertion" if the
+// tree contains missing terminals, "panic" if it contains error nodes and "deletion" otherwise
+func recoveryStrategy(tree string) string {
+	switch {
+	case strings.Contains(tree, "(!"):
+		return "panic"
+	case strings.Contains(tree, "["):
+		return "insertion"
+	}
+	return "deletion"
+}
+
+func TestRecoverErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		// the synchronisation terminals of the panic mode
+		sync     []string
+		strategy string
+		errors   []string
+		tree     string
+	}{
+		{"x = ; y = 2;", nil, "insertion",
+			[]string{`syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number at Line 1, Column 5`},
+			"(<Stmts> (<Stmt> x = (<Factor> [Identifier]) ;) (<Stmts> (<Stmt> y = (<Factor> 2) ;) (<Stmts>)))"},
+		{"x = 1 2; print x;", nil, "insertion",
+			[]string{`syntax Error: unexpected "2", expected one of: "*", "+", "-", "/", ";" at Line 1, Column 7`},
+			"(<Stmts> (<Stmt> x = (<Term> (<Factor> 1) [*] (<Factor> 2)) ;) (<Stmts> (<Stmt> print (<Factor> x) ;) (<Stmts>)))"},
+		{"x = 1", nil, "insertion",
+			[]string{`syntax Error: unexpected EOF, expected one of: "*", "+", "-", "/", ";" at Line 1, Column 6`},
+			"(<Stmts> (<Stmt> x = (<Factor> 1) [;]) (<Stmts>))"},
+		{"x = 1 + + 2; print x;", nil, "insertion",
+			[]string{`syntax Error: unexpected "+", expected one of: "(", "-", Identifier, Number at Line 1, Column 9`},
+			"(<Stmts> (<Stmt> x = (<Expr> (<Expr> (<Factor> 1) + (<Factor> [Identifier])) + (<Factor> 2)) ;) (<Stmts> (<Stmt> print (<Factor> x) ;) (<Stmts>)))"},
+		{"print 1 2 3; x = 1;", nil, "insertion",
+			[]string{`syntax Error: unexpected "2", expected one of: "*", "+", "-", "/", ";" at Line 1, Column 9`, `syntax Error: unexpected "3", expected one of: "*", "+", "-", "/", ";" at Line 1, Column 11`},
+			"(<Stmts> (<Stmt> print (<Term> (<Term> (<Factor> 1) [*] (<Factor> 2)) [*] (<Factor> 3)) ;) (<Stmts> (<Stmt> x = (<Factor> 1) ;) (<Stmts>)))"},
+		{"x = 1 ) ; y = 2;", nil, "deletion",
+			[]string{`syntax Error: unexpected ")", expected one of: "+", "-", ";" at Line 1, Column 7`},
+			"(<Stmts> (<Stmt> x = (<Factor> 1) ;) (<Stmts> (<Stmt> y = (<Factor> 2) ;) (<Stmts>)))"},
+		{"x = 1 $ ; y = 2;", nil, "deletion",
+			[]string{`Unknown Token "$" at Line 1, Column 7`},
+			"(<Stmts> (<Stmt> x = (<Factor> 1) ;) (<Stmts> (<Stmt> y = (<Factor> 2) ;) (<Stmts>)))"},
+		{"x = (1 + 2 print x; y = 3;", nil, "panic",
+			[]string{`syntax Error: unexpected "print", expected one of: ")", "*", "+", "-", "/" at Line 1, Column 12`},
+			"(<Stmts> (!<Stmt> x = ( (<Factor> 1) + 2) (<Stmts> (<Stmt> print (<Factor> x) ;) (<Stmts> (<Stmt> y = (<Factor> 3) ;) (<Stmts>))))"},
+		{"x = 1; print ) ) ) 2; y = 3;", nil, "panic",
+			[]string{`syntax Error: unexpected ")", expected one of: "(", "-", Identifier, Number at Line 1, Column 14`},
+			"(<Stmts> (<Stmt> x = (<Factor> 1) ;) (<Stmts> (<Stmt> print (!<Expr> ) ) ) 2) ;) (<Stmts> (<Stmt> y = (<Factor> 3) ;) (<Stmts>))))"},
+		{"x = 1 ) ) ; y = 2;", nil, "panic",
+			[]string{`syntax Error: unexpected ")", expected one of: "+", "-", ";" at Line 1, Column 7`},
+			"(<Stmts> (<Stmt> x = (!<Expr> (<Factor> 1) ) )) ;) (<Stmts> (<Stmt> y = (<Factor> 2) ;) (<Stmts>)))"},
+		// the panic mode skips the input up to the next synchronisation terminal
+		{"x = (1 + 2 print x; y = 3;", []string{";"}, "panic",
+			[]string{`syntax Error: unexpected "print", expected one of: ")", "*", "+", "-", "/" at Line 1, Column 12`},
+			"(<Stmts> (<Stmt> x = (!<Expr> ( (<Factor> 1) + 2 print x) ;) (<Stmts> (<Stmt> y = (<Factor> 3) ;) (<Stmts>)))"},
+	}
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			var sync []gold.SymbolId
+			for _, name := range tt.sync {
+				sync = append(sync, p.Grammar().SymbolByName(name).Id)
+			}
+			tree, err := p.ParseContext(context.Background(), strings.NewReader(tt.input), gold.RecoverErrors(sync...), gold.TrimReductions(true))
+			var errs gold.ParseErrors
+			if !errors.As(err, &errs) {
+				t.Errorf("%s %q: got %v, want ParseErrors", format, tt.input, err)
+				continue
+			}
+			var msgs []string
+			for _, e := range errs {
+				msgs = append(msgs, e.Error())
+			}
+			if !reflect.DeepEqual(msgs, tt.errors) {
+				t.Errorf("%s %q: got the errors\n%s\nwant\n%s", format, tt.input, strings.Join(msgs, "\n"), strings.Join(tt.errors, "\n"))
+			}
+			got := treeString(tree)
+			if got != tt.tree {
+				t.Errorf("%s %q: got the tree\n%s\nwant\n%s", format, tt.input, got, tt.tree)
+			}
+			if s := recoveryStrategy(got); s != tt.strategy {
+				t.Errorf("%s %q: recovered by %s, want %s", format, tt.input, s, tt.strategy)
+			}
+		}
+	}
+}
+
+func TestRecoverErrorsKeepsRemovedValues(t *testing.T) {
+	// the states of the list <L> can parse ";", but no goto from them can
+	const grammar = `
+"Start Symbol" = <S>
+Id = {Letter}+
+<S> ::= <L> ';' | <L> '+' <E> '!'
+<L> ::= Id | Id ',' <L>
+<E> ::= Id | '(' <E> ')'
+`
+	p, err := gold.NewParserFromTables(buildTables(t, grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input string
+		err   string
+		tree  string
+	}{
+		{"a , b + ( c d ;", `syntax Error: unexpected "d", expected ")" at Line 1, Column 13`,
+			"(<S> (!<L> (<L> a , (<L> b)) + ( c d) ;)"},
+		{"a + ( ( c d e ;", `syntax Error: unexpected "d", expected ")" at Line 1, Column 11`,
+			"(<S> (!<L> (<L> a) + ( ( c d e) ;)"},
+	}
+	sync := p.Grammar().SymbolByName(";").Id
+	for _, tt := range tests {
+		tree, err := p.ParseContext(context.Background(), strings.NewReader(tt.input), gold.RecoverErrors(sync), gold.KeepTrivia(true))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%q: got the error %v, want %s", tt.input, err, tt.err)
+		}
+		if got := treeString(tree); got != tt.tree {
+			t.Errorf("%q: got the tree\n%s\nwant\n%s", tt.input, got, tt.tree)
+		}
+		// the values removed from the stack are kept in the error node
+		if got := tree.FullText(); got != tt.input {
+			t.Errorf("%q: FullText is %q", tt.input, got)
+		}
+	}
+}
+
+func TestRecoverErrorsMaxErrors(t *testing.T) {
+	const input = "x = 1 ) ; y = 2 ) ; z = 3 ) ; w = 4 ) ;"
+	tests := []struct {
+		max    int
+		errors int
+		tree   bool
+	}{
+		{0, 4, true},
+		{5, 4, true},
+		// the parser gives up when the maximum is reached
+		{4, 4, false},
+		{2, 2, false},
+	}
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			opts := []gold.ParseOption{gold.RecoverErrors()}
+			if tt.max > 0 {
+				opts = append(opts, gold.MaxErrors(tt.max))
+			}
+			tree, err := p.ParseContext(context.Background(), strings.NewReader(input), opts...)
+			var errs gold.ParseErrors
+			if !errors.As(err, &errs) || len(errs) != tt.errors {
+				t.Errorf("%s max %d: got %v, want %d errors", format, tt.max, err, tt.errors)
+				continue
+			}
+			for i, e := range errs {
+				if want := 7 + 10*i; e.Position.Column != want {
+					t.Errorf("%s max %d: the error %d is at column %d, want %d", format, tt.max, i, e.Position.Column, want)
+				}
+			}
+			if (tree != nil) != tt.tree {
+				t.Errorf("%s max %d: got the tree %s", format, tt.max, treeString(tree))
+			}
+		}
+	}
+}
+
+func TestSyntaxErrorWithoutRecovery(t *testing.T) {
+	for format, p := range grammarFormats(t) {
+		tree, err := p.ParseContext(context.Background(), strings.NewReader("x = ; y = ;"))
+		var pe *gold.ParseError
+		if !errors.As(err, &pe) || tree != nil {
+			t.Fatalf("%s: got %v, %v, want a ParseError without tree", format, tree, err)
+		}
+		if pe.Error() != `syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number at Line 1, Column 5` {
+			t.Errorf("%s: got %v", format, pe)
+		}
+	}
+}
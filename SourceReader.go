@@ -13,6 +13,10 @@ type TextPosition struct {
 	Line int
 	// The Column, starts with column 1
 	Column int
+	// The byte offset from the start of the input, starts with 0
+	Offset int
+	// The rune offset from the start of the input, starts with 0
+	RuneOffset int
 }
 
 // returns a string representing the textposition
@@ -20,48 +24,91 @@ func (t TextPosition) String() string {
 	return fmt.Sprintf("Line %d, Column %d", t.Line, t.Column)
 }
 
+// returns the position behind the given rune of the given size
+func (t TextPosition) advance(r rune, size int) TextPosition {
+	t.Offset += size
+	t.RuneOffset++
+	if r == '\n' {
+		t.Line++
+		t.Column = 1
+	} else {
+		t.Column++
+	}
+	return t
+}
+
+type sourceRune struct {
+	Rune     rune
+	Size     int
+	Position TextPosition
+}
+
 type sourceReader struct {
 	bufReader    *bufio.Reader
 	unreadBuffer *stack
 	Rune         rune
-	Position     TextPosition
+	// the position of the next rune
+	Position TextPosition
+
+	last sourceRune
+
+	// the maximum number of bytes which are read or 0
+	maxBytes int
+	// set if the input is longer than maxBytes
+	err *LimitError
+	// the reader stops as if the input ended when done is closed. nil if it never stops.
+	done <-chan struct{}
 }
 
-func newSourceReader(r io.Reader) *sourceReader {
-	result := &sourceReader{bufReader: bufio.NewReader(r)}
+func newSourceReader(r io.Reader, maxBytes int, done <-chan struct{}) *sourceReader {
+	result := &sourceReader{bufReader: bufio.NewReader(r), maxBytes: maxBytes, done: done}
 	result.Position.Line = 1
-	result.Position.Column = 0
+	result.Position.Column = 1
 	result.unreadBuffer = newStack()
 	return result
 }
 
 func (r *sourceReader) Next() bool {
 	if r.unreadBuffer.count > 0 {
-		r.Rune = r.unreadBuffer.Pop().(rune)
+		r.last = r.unreadBuffer.Pop().(sourceRune)
+		r.Rune = r.last.Rune
+		r.Position = r.last.Position.advance(r.last.Rune, r.last.Size)
 		return true
 	}
 
-	cur, _, err := r.bufReader.ReadRune()
+	if r.done != nil {
+		select {
+		case <-r.done:
+			return false
+		default:
+		}
+	}
+	cur, size, err := r.bufReader.ReadRune()
 	r.Rune = cur
 	if err != nil {
 		return false
 	}
-	if string(cur) == "\n" {
-		r.Position.Line++
-		r.Position.Column = 0
-	} else {
-		r.Position.Column++
+	if r.maxBytes > 0 && r.Position.Offset+size > r.maxBytes {
+		r.err = &LimitError{Kind: LimitInputBytes, Limit: r.maxBytes, Position: r.Position}
+		return false
 	}
+	r.last = sourceRune{Rune: cur, Size: size, Position: r.Position}
+	r.Position = r.Position.advance(cur, size)
 
 	return true
 }
 
-func (sr *sourceReader) UnreadAll(runes []rune) {
+// puts the runes which were read before back into the reader.
+func (sr *sourceReader) unreadRunes(runes []sourceRune) {
 	for i := len(runes) - 1; i >= 0; i-- {
 		sr.unreadBuffer.Push(runes[i])
 	}
+	if len(runes) > 0 {
+		sr.Position = runes[0].Position
+	}
 }
 
 func (sr *sourceReader) UnreadLast() {
-	sr.unreadBuffer.Push(sr.Rune)
+	sr.unreadBuffer.Push(sr.last)
+	sr.Position = sr.last.Position
 }
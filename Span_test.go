@@ -0,0 +1,201 @@
+package gold_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/boombuler/gold"
+)
+
+// returns the position of the byte offset in the input
+func positionAt(input string, offset int) gold.TextPosition {
+	before := input[:offset]
+	result := gold.TextPosition{Line: 1, Column: 1, Offset: offset, RuneOffset: utf8.RuneCountInString(before)}
+	if idx := strings.LastIndexByte(before, '\n'); idx >= 0 {
+		result.Line += strings.Count(before, "\n")
+		before = before[idx+1:]
+	}
+	result.Column += utf8.RuneCountInString(before)
+	return result
+}
+
+// returns the text of the input between the byte offsets as the tokenizer reads it. Each
+// byte of an invalid UTF-8 sequence is read as utf8.RuneError.
+func sourceText(input string, start, end int) string {
+	return string([]rune(input[start:end]))
+}
+
+// the inputs of the span tests with multi-byte characters and invalid UTF-8
+var spanInputs = []string{
+	"x = 1;",
+	"x = 1; /* ä€ */\nprint  (x +\t2.5) ;",
+	"/* 😀 */ x\n=\n1;\n\n",
+	"x = 1; // ä€\nprint x; // 😀\n",
+	"x = 1; /* \xff\xfe ä */\nprint x; // \xc3 €\n/* \xe2\x82 */",
+}
+
+// returns the parsers of grammarFormats and a parser of the calculator grammar built from its
+// source, whose groups advance by character
+func spanParsers(t *testing.T) map[string]gold.Parser {
+	t.Helper()
+	result := grammarFormats(t)
+	p, err := gold.NewParserFromTables(buildTables(t, calcGrammar(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result["grm"] = p
+	return result
+}
+
+func TestLexerSpans(t *testing.T) {
+	for format, p := range spanParsers(t) {
+		for _, input := range spanInputs {
+			lx := p.NewLexer(strings.NewReader(input))
+			offset := 0
+			for {
+				term, err := lx.Next()
+				if want := positionAt(input, offset); term.Position != want {
+					t.Errorf("%s %q: %q starts at %+v, want %+v", format, input, term.Text, term.Position, want)
+				}
+				if term.End.Offset < offset || term.End.Offset > len(input) {
+					t.Fatalf("%s %q: %q ends at %+v", format, input, term.Text, term.End)
+				}
+				if want := positionAt(input, term.End.Offset); term.End != want || sourceText(input, offset, term.End.Offset) != term.Text {
+					t.Errorf("%s %q: %q ends at %+v, want %+v", format, input, term.Text, term.End, want)
+				}
+				offset = term.End.Offset
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%s %q: %v", format, input, err)
+				}
+			}
+			if offset != len(input) {
+				t.Errorf("%s %q: the terminals end at the offset %d", format, input, offset)
+			}
+		}
+	}
+}
+
+func TestGroupSpans(t *testing.T) {
+	// the words contain invalid UTF-8, which is read as utf8.RuneError. The comment advances
+	// by character, so the rest of each word in the comment is read again up to the end of the
+	// comment, which is part of the word.
+	const grammar = `
+"Start Symbol" = <Words>
+{Word Char} = {All Printable} - {Whitespace}
+Word = {Word Char}+
+Comment Start = '/*'
+Comment End = '*/'
+<Words> ::= Word <Words> |
+`
+	p, err := gold.NewParserFromTables(buildTables(t, grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{
+		"a /* bä€c*/ d",
+		"a\xffb /* c\xffd\xfe\xfe*/ e\xc3",
+		"/* \xe2\x82\xacx\xe2\x82*/\n\xff",
+	}
+	for _, input := range inputs {
+		lx := p.NewLexer(strings.NewReader(input))
+		offset := 0
+		for {
+			term, err := lx.Next()
+			if term.Position != positionAt(input, offset) || term.End.Offset < offset || term.End.Offset > len(input) ||
+				term.End != positionAt(input, term.End.Offset) || sourceText(input, offset, term.End.Offset) != term.Text {
+				t.Fatalf("%q: %q has the span %+v - %+v", input, term.Text, term.Position, term.End)
+			}
+			offset = term.End.Offset
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%q: %v", input, err)
+			}
+		}
+		tree, err := p.ParseContext(context.Background(), strings.NewReader(input), gold.KeepTrivia(true))
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+		checkSpans(t, input, input, tree)
+	}
+}
+
+func TestTreeSpans(t *testing.T) {
+	for format, p := range spanParsers(t) {
+		for _, input := range spanInputs {
+			tree, err := p.Parse(strings.NewReader(input), false)
+			if err != nil {
+				t.Errorf("%s %q: %v", format, input, err)
+				continue
+			}
+			checkSpans(t, format+" "+input, input, tree)
+		}
+	}
+}
+
+func TestTriviaSpans(t *testing.T) {
+	for format, p := range spanParsers(t) {
+		for _, input := range spanInputs {
+			tree, err := p.ParseContext(context.Background(), strings.NewReader(input), gold.KeepTrivia(true))
+			if err != nil {
+				t.Errorf("%s %q: %v", format, input, err)
+				continue
+			}
+			// the terminals and their trivia cover the input without gaps
+			offset := 0
+			gold.Inspect(tree, func(tok *gold.Token) bool {
+				if !tok.IsTerminal {
+					return true
+				}
+				for _, part := range append(append(append([]*gold.Token(nil), tok.LeadingTrivia...), tok), tok.TrailingTrivia...) {
+					if part.Start != positionAt(input, offset) || part.End.Offset < offset || part.End.Offset > len(input) ||
+						part.End != positionAt(input, part.End.Offset) || sourceText(input, offset, part.End.Offset) != part.Text {
+						t.Errorf("%s %q: %q has the span %+v - %+v", format, input, part.Text, part.Start, part.End)
+						return false
+					}
+					offset = part.End.Offset
+				}
+				return true
+			})
+			if offset != len(input) {
+				t.Errorf("%s %q: the terminals end at the offset %d", format, input, offset)
+			}
+		}
+	}
+}
+
+// checks that the terminals cover their text and the non-terminals span their sub-nodes.
+// returns the first and the last terminal of the token or nil if it has no terminals.
+func checkSpans(t *testing.T, name, input string, tok *gold.Token) (first, last *gold.Token) {
+	t.Helper()
+	if tok.IsTerminal {
+		if tok.End.Offset > len(input) || tok.Start != positionAt(input, tok.Start.Offset) ||
+			tok.End != positionAt(input, tok.End.Offset) || sourceText(input, tok.Start.Offset, tok.End.Offset) != tok.Text {
+			t.Errorf("%s: the terminal %q has the span %+v - %+v", name, tok.Text, tok.Start, tok.End)
+		}
+		return tok, tok
+	}
+	for _, c := range tok.Tokens {
+		f, l := checkSpans(t, name, input, c)
+		if first == nil {
+			first = f
+		}
+		if l != nil {
+			last = l
+		}
+	}
+	if first == nil {
+		if tok.Start != tok.End {
+			t.Errorf("%s: the empty %s has the span %+v - %+v", name, tok.Name, tok.Start, tok.End)
+		}
+	} else if tok.Start != first.Start || tok.End != last.End {
+		t.Errorf("%s: %s has the span %+v - %+v, want %+v - %+v", name, tok.Name, tok.Start, tok.End, first.Start, last.End)
+	}
+	return first, last
+}
@@ -24,6 +24,43 @@ const (
 	stError       symbolType = 7 // Error Terminal. If the parser encounters an error reading a token, this kind of symbol can used to differentiate it from other terminal types.
 )
 
+// describes the kind of a grammar symbol
+type SymbolKind byte
+
+const (
+	KindNonTerminal SymbolKind = SymbolKind(stNonTerminal) // Normal Nonterminal
+	KindTerminal    SymbolKind = SymbolKind(stTerminal)    // Normal Terminal
+	KindNoise       SymbolKind = SymbolKind(stNoise)       // Noise terminal like whitespace, ignored by the parser
+	KindEnd         SymbolKind = SymbolKind(stEnd)         // End of the input
+	KindGroupStart  SymbolKind = SymbolKind(stGroupStart)  // Start of a lexical group like a block comment
+	KindGroupEnd    SymbolKind = SymbolKind(stGroupEnd)    // End of a lexical group like a block comment
+	KindCommentLine SymbolKind = SymbolKind(stCommentLine) // Line Comment Terminal
+	KindError       SymbolKind = SymbolKind(stError)       // Error Terminal, used for unknown tokens
+)
+
+// returns the name of the symbol kind
+func (k SymbolKind) String() string {
+	switch k {
+	case KindNonTerminal:
+		return "NonTerminal"
+	case KindTerminal:
+		return "Terminal"
+	case KindNoise:
+		return "Noise"
+	case KindEnd:
+		return "End"
+	case KindGroupStart:
+		return "GroupStart"
+	case KindGroupEnd:
+		return "GroupEnd"
+	case KindCommentLine:
+		return "CommentLine"
+	case KindError:
+		return "Error"
+	}
+	return fmt.Sprintf("SymbolKind(%d)", byte(k))
+}
+
 func newSymbolTable(count uint16, createSymbols bool) symbolTable {
 	result := make(symbolTable, count)
 	if createSymbols {
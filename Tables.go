@@ -0,0 +1,381 @@
+package gold
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the kind of an action of the LALR state machine
+type ActionKind byte
+
+const (
+	ActionShift  ActionKind = ActionKind(actionShift)  // shifts the terminal and goes to the target state
+	ActionReduce ActionKind = ActionKind(actionReduce) // reduces the target rule
+	ActionGoto   ActionKind = ActionKind(actionGoto)   // goes to the target state after a reduction
+	ActionAccept ActionKind = ActionKind(actionAccept) // accepts the input
+)
+
+// contains the tables of a grammar in the form they are stored in grammar files.
+// All references between the tables are indices into the tables.
+type Tables struct {
+	// the properties of the grammar. The values of the properties "Name", "Version", "Author"
+	// and "About" are returned by Parser.GetInformation.
+	Properties []TableProperty
+	// tells if the grammar is case sensitive. Only stored in cgt files.
+	CaseSensitive bool
+	// the start symbol of the grammar. Only stored in cgt files.
+	StartSymbol SymbolId
+
+	InitialDFAState uint16
+	InitialLRState  uint16
+
+	CharSets  []TableCharSet
+	Symbols   []TableSymbol
+	Groups    []TableGroup
+	Rules     []TableRule
+	DFAStates []TableDFAState
+	LRStates  []TableLRState
+}
+
+// a named property of a grammar
+type TableProperty struct {
+	Name  string
+	Value string
+}
+
+// a set of characters of the unicode plane, described by ranges
+type TableCharSet struct {
+	Plane  uint16
+	Ranges []TableCharRange
+}
+
+// a range of characters, including the start and the end
+type TableCharRange struct {
+	Start uint16
+	End   uint16
+}
+
+type TableSymbol struct {
+	Name string
+	Kind SymbolKind
+}
+
+type TableGroup struct {
+	Name        string
+	Container   SymbolId
+	Start       SymbolId
+	End         SymbolId
+	AdvanceMode AdvanceMode
+	EndingMode  EndingMode
+	Nesting     []GroupId
+}
+
+type TableRule struct {
+	Head    SymbolId
+	Symbols []SymbolId
+}
+
+type TableDFAState struct {
+	// tells if the state accepts the AcceptSymbol
+	Accept       bool
+	AcceptSymbol SymbolId
+	Edges        []TableDFAEdge
+}
+
+type TableDFAEdge struct {
+	CharSet uint16
+	Target  uint16
+}
+
+type TableLRState struct {
+	Actions []TableLRAction
+}
+
+type TableLRAction struct {
+	Symbol SymbolId
+	Action ActionKind
+	// the target state for shift and goto actions or the rule for reduce actions
+	Target uint16
+}
+
+// returns the value of the property with the given name or an empty string
+func (t *Tables) Property(name string) string {
+	for _, p := range t.Properties {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// returns the information stored in the properties of the grammar
+func (t *Tables) Information() GrammarInformation {
+	return GrammarInformation{
+		Name:    t.Property("Name"),
+		Version: t.Property("Version"),
+		Author:  t.Property("Author"),
+		About:   t.Property("About"),
+	}
+}
+
+// returns a deep copy of the tables
+func (t *Tables) Clone() *Tables {
+	c := *t
+	c.Properties = append([]TableProperty(nil), t.Properties...)
+	c.CharSets = make([]TableCharSet, len(t.CharSets))
+	for i, cs := range t.CharSets {
+		c.CharSets[i] = TableCharSet{Plane: cs.Plane, Ranges: append([]TableCharRange(nil), cs.Ranges...)}
+	}
+	c.Symbols = append([]TableSymbol(nil), t.Symbols...)
+	c.Groups = make([]TableGroup, len(t.Groups))
+	for i, g := range t.Groups {
+		c.Groups[i] = g
+		c.Groups[i].Nesting = append([]GroupId(nil), g.Nesting...)
+	}
+	c.Rules = make([]TableRule, len(t.Rules))
+	for i, r := range t.Rules {
+		c.Rules[i] = TableRule{Head: r.Head, Symbols: append([]SymbolId(nil), r.Symbols...)}
+	}
+	c.DFAStates = make([]TableDFAState, len(t.DFAStates))
+	for i, ds := range t.DFAStates {
+		c.DFAStates[i] = ds
+		c.DFAStates[i].Edges = append([]TableDFAEdge(nil), ds.Edges...)
+	}
+	c.LRStates = make([]TableLRState, len(t.LRStates))
+	for i, ls := range t.LRStates {
+		c.LRStates[i] = TableLRState{Actions: append([]TableLRAction(nil), ls.Actions...)}
+	}
+	return &c
+}
+
+// returns the name of the symbol like Symbol.String or its index if it is unknown
+func (t *Tables) symbolName(id SymbolId) string {
+	if int(id) < len(t.Symbols) {
+		s := t.Symbols[id]
+		return (&Symbol{Name: s.Name, Kind: s.Kind}).String()
+	}
+	return fmt.Sprintf("#%d", id)
+}
+
+// returns the rule in BNF notation like Rule.String
+func (t *Tables) ruleString(id RuleId) string {
+	r := t.Rules[id]
+	names := make([]string, len(r.Symbols))
+	for i, s := range r.Symbols {
+		names[i] = t.symbolName(s)
+	}
+	return t.symbolName(r.Head) + " ::= " + strings.Join(names, " ")
+}
+
+// tells if the state accepts the input
+func (s TableLRState) accepts() bool {
+	for _, a := range s.Actions {
+		if a.Action == ActionAccept {
+			return true
+		}
+	}
+	return false
+}
+
+// checks that the tables can be indexed by uint16 values
+func (t *Tables) checkCounts() error {
+	if len(t.Symbols) > 0xFFFF || len(t.CharSets) > 0xFFFF || len(t.Rules) > 0xFFFF ||
+		len(t.DFAStates) > 0xFFFF || len(t.LRStates) > 0xFFFF || len(t.Groups) > 0xFFFF {
+		return grammarError("grammar tables are too large")
+	}
+	return nil
+}
+
+// Creates a new parser from the tables of a grammar or returns an error if the tables
+// are inconsistent. Grammars without groups are scanned like cgt grammars.
+// The error is a *ValidationError if the tables are not valid.
+func NewParserFromTables(t *Tables) (Parser, error) {
+	if err := t.Validate().Err(); err != nil {
+		return nil, err
+	}
+
+	g, err := newGoldGrammar(t)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := new(parser)
+	if len(g.groups) == 0 {
+		parser.grammar = &cgtGrammar{goldGrammar: *g}
+		parser.isCgtGrammar = true
+	} else {
+		parser.grammar = &egtGrammar{goldGrammar: *g}
+	}
+	parser.view = newGrammarView(parser.grammar.getTables())
+	return parser, nil
+}
+
+func newGoldGrammar(t *Tables) (*goldGrammar, error) {
+	g := new(goldGrammar)
+	g.GrammarInformation = t.Information()
+	g.tables = t.Clone()
+
+	if err := t.checkCounts(); err != nil {
+		return nil, err
+	}
+
+	g.symbols = newSymbolTable(uint16(len(t.Symbols)), true)
+	g.charSets = make([]charSet, len(t.CharSets))
+	g.rules = newRuleTable(uint16(len(t.Rules)))
+	g.dfaStates = newDFAStateTable(uint16(len(t.DFAStates)))
+	g.lrStates = newLRStateTable(uint16(len(t.LRStates)))
+	g.groups = newGroupTable(uint16(len(t.Groups)), true)
+
+	symbolAt := func(id SymbolId, what string) (*symbol, error) {
+		if int(id) >= len(g.symbols) {
+			return nil, grammarError(fmt.Sprintf("%s references unknown symbol %d", what, id))
+		}
+		return g.symbols[id], nil
+	}
+
+	if int(t.InitialDFAState) >= len(g.dfaStates) {
+		return nil, grammarError(fmt.Sprintf("unknown initial DFA state %d", t.InitialDFAState))
+	}
+	g.initialDFAState = t.InitialDFAState
+	if int(t.InitialLRState) >= len(g.lrStates) {
+		return nil, grammarError(fmt.Sprintf("unknown initial LR state %d", t.InitialLRState))
+	}
+	g.initialLRState = t.InitialLRState
+
+	for idx, ts := range t.Symbols {
+		s := g.symbols[idx]
+		s.Index = uint16(idx)
+		s.Name = ts.Name
+		s.Kind = symbolType(ts.Kind)
+
+		switch s.Kind {
+		case stEnd:
+			g.endSymbol = s
+		case stError:
+			g.errorSymbol = s
+		}
+	}
+
+	for idx, tcs := range t.CharSets {
+		cs := &rangedCharSet{plane: tcs.Plane, ranges: make(charRanges, len(tcs.Ranges))}
+		for i, r := range tcs.Ranges {
+			cs.ranges[i] = charRange{start: r.Start, end: r.End}
+		}
+		cs.optimize()
+		g.charSets[idx] = cs
+	}
+
+	for idx, tg := range t.Groups {
+		group := g.groups[idx]
+		group.Name = tg.Name
+		var err error
+		what := fmt.Sprintf("group %d", idx)
+		if group.Container, err = symbolAt(tg.Container, what); err != nil {
+			return nil, err
+		}
+		if group.Start, err = symbolAt(tg.Start, what); err != nil {
+			return nil, err
+		}
+		if group.End, err = symbolAt(tg.End, what); err != nil {
+			return nil, err
+		}
+		group.Container.Group = group
+		group.Start.Group = group
+		group.End.Group = group
+		group.AdvanceMode = advanceMode(tg.AdvanceMode)
+		group.EndingMode = endingMode(tg.EndingMode)
+
+		group.Nested = newGroupTable(uint16(len(tg.Nesting)), false)
+		for i, n := range tg.Nesting {
+			if int(n) >= len(g.groups) {
+				return nil, grammarError(fmt.Sprintf("%s references unknown group %d", what, n))
+			}
+			group.Nested[i] = g.groups[n]
+		}
+	}
+
+	for idx, tr := range t.Rules {
+		r := g.rules[idx]
+		what := fmt.Sprintf("rule %d", idx)
+		var err error
+		if r.NonTerminal, err = symbolAt(tr.Head, what); err != nil {
+			return nil, err
+		}
+		r.Symbols = newSymbolTable(uint16(len(tr.Symbols)), false)
+		for i, id := range tr.Symbols {
+			if r.Symbols[i], err = symbolAt(id, what); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for idx, ts := range t.DFAStates {
+		state := g.dfaStates[idx]
+		what := fmt.Sprintf("DFA state %d", idx)
+		if ts.Accept {
+			var err error
+			if state.AcceptSymbol, err = symbolAt(ts.AcceptSymbol, what); err != nil {
+				return nil, err
+			}
+		}
+
+		edges := make([]dfaEdge, len(ts.Edges))
+		for i, e := range ts.Edges {
+			if int(e.CharSet) >= len(g.charSets) {
+				return nil, grammarError(fmt.Sprintf("%s references unknown character set %d", what, e.CharSet))
+			}
+			if int(e.Target) >= len(g.dfaStates) {
+				return nil, grammarError(fmt.Sprintf("%s references unknown DFA state %d", what, e.Target))
+			}
+			edges[i].CharSet = g.charSets[e.CharSet]
+			edges[i].Target = g.dfaStates[e.Target]
+		}
+		state.TransitionVector = newTransitionVector(edges)
+	}
+
+	for idx, ts := range t.LRStates {
+		state := g.lrStates[idx]
+		state.Index = uint16(idx)
+		state.Actions = make(lrActionTable)
+		what := fmt.Sprintf("LR state %d", idx)
+
+		for _, ta := range ts.Actions {
+			symb, err := symbolAt(ta.Symbol, what)
+			if err != nil {
+				return nil, err
+			}
+			actn := &lrAction{Symbol: symb, Action: action(ta.Action)}
+			if (actn.Action == actionGoto) != (symb.Kind == stNonTerminal) {
+				return nil, grammarError(fmt.Sprintf("%s contains action %d for symbol %s", what, ta.Action, symb.Name))
+			}
+			switch actn.Action {
+			case actionShift, actionGoto:
+				if int(ta.Target) >= len(g.lrStates) {
+					return nil, grammarError(fmt.Sprintf("%s references unknown LR state %d", what, ta.Target))
+				}
+				actn.TargetState = g.lrStates[ta.Target]
+			case actionReduce:
+				if int(ta.Target) >= len(g.rules) {
+					return nil, grammarError(fmt.Sprintf("%s references unknown rule %d", what, ta.Target))
+				}
+				actn.TargetRule = g.rules[ta.Target]
+			case actionAccept:
+			default:
+				return nil, grammarError(fmt.Sprintf("%s contains unknown action %d", what, ta.Action))
+			}
+			state.Actions[symb] = actn
+		}
+	}
+
+	for _, s := range g.symbols {
+		if s.Kind == stGroupStart && s.Group == nil && len(g.groups) > 0 {
+			return nil, grammarError(fmt.Sprintf("the group start symbol %s has no group", s.Name))
+		}
+	}
+
+	if g.endSymbol == nil || g.errorSymbol == nil {
+		return nil, grammarError("grammar has no end or error symbol")
+	}
+
+	return g, nil
+}
@@ -0,0 +1,177 @@
+package gold_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+	"github.com/boombuler/gold/builder"
+)
+
+func buildTables(t *testing.T, src string) *gold.Tables {
+	t.Helper()
+	result, err := builder.Build(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Tables
+}
+
+// returns the source of the calculator grammar in testdata/calc.grm
+func calcGrammar(t *testing.T) string {
+	t.Helper()
+	src, err := os.ReadFile("testdata/calc.grm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
+
+func TestWriteTablesRoundTrip(t *testing.T) {
+	grammars := []struct {
+		name string
+		src  string
+	}{
+		{"calculator", calcGrammar(t)},
+		{"case insensitive", `
+"Name" = 'Keywords'
+"Case Sensitive" = False
+"Start Symbol" = <S>
+Id = {Letter}+
+<S> ::= begin Id end
+`},
+		{"nested groups", `
+"Start Symbol" = <S>
+Id = {Letter}+
+Comment Start = '/*'
+Comment End = '*/'
+Comment Block @= { Nesting = All }
+<S> ::= Id <S> |
+`},
+	}
+	formats := []struct {
+		name  string
+		write func(*gold.Tables, io.Writer) error
+		// returns the part of the tables which is stored in the format
+		stored func(*gold.Tables) interface{}
+	}{
+		{"egt", (*gold.Tables).WriteEGT, func(t *gold.Tables) interface{} {
+			return t
+		}},
+		// cgt files store the groups as comments and only some of the properties
+		{"cgt", (*gold.Tables).WriteCGT, func(t *gold.Tables) interface{} {
+			names := make([]string, len(t.Symbols))
+			for i, s := range t.Symbols {
+				names[i] = s.Name
+			}
+			return []interface{}{t.CaseSensitive, t.StartSymbol, t.InitialDFAState, t.InitialLRState,
+				t.CharSets, names, t.Rules, t.DFAStates, t.LRStates}
+		}},
+	}
+	for _, g := range grammars {
+		tables := buildTables(t, g.src)
+		normalize(tables)
+		for _, f := range formats {
+			buf := new(bytes.Buffer)
+			if err := f.write(tables, buf); err != nil {
+				t.Errorf("%s %s: %v", g.name, f.name, err)
+				continue
+			}
+			written := buf.Bytes()
+			read, err := gold.ReadTables(bytes.NewReader(written))
+			if err != nil {
+				t.Errorf("%s %s: %v", g.name, f.name, err)
+				continue
+			}
+			if err := read.Validate().Err(); err != nil {
+				t.Errorf("%s %s: %v", g.name, f.name, err)
+			}
+			normalize(read)
+			if got, want := f.stored(read), f.stored(tables); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s %s: the tables read differ from the tables written:\n%+v\n%+v", g.name, f.name, got, want)
+			}
+
+			buf = new(bytes.Buffer)
+			if err := f.write(read, buf); err != nil {
+				t.Errorf("%s %s: %v", g.name, f.name, err)
+			} else if !bytes.Equal(buf.Bytes(), written) {
+				t.Errorf("%s %s: writing the tables read again gives another file", g.name, f.name)
+			}
+		}
+	}
+}
+
+func TestReadTablesOfGrammarFiles(t *testing.T) {
+	built := buildTables(t, calcGrammar(t))
+	normalize(built)
+	for _, format := range []string{"egt", "cgt"} {
+		f, err := os.Open("testdata/calc." + format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read, err := gold.ReadTables(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		normalize(read)
+		if !reflect.DeepEqual(read.Rules, built.Rules) || !reflect.DeepEqual(read.LRStates, built.LRStates) ||
+			!reflect.DeepEqual(read.DFAStates, built.DFAStates) {
+			t.Errorf("%s: the tables of the file differ from the tables built from calc.grm", format)
+		}
+		if info := read.Information(); info.Name != "Calc" {
+			t.Errorf("%s: got the information %+v", format, info)
+		}
+		p, err := gold.NewParserFromTables(read)
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+		} else if _, err := p.Parse(strings.NewReader("x = 1;"), false); err != nil {
+			t.Errorf("%s: %v", format, err)
+		}
+	}
+}
+
+func TestCloneTables(t *testing.T) {
+	tables := buildTables(t, calcGrammar(t))
+	clone := tables.Clone()
+	normalize(tables)
+	normalize(clone)
+	if !reflect.DeepEqual(clone, tables) {
+		t.Fatal("the clone differs from the tables")
+	}
+	clone.Symbols[0].Name = "changed"
+	clone.Rules[0].Symbols[0] = 42
+	clone.LRStates[0].Actions[0].Target = 42
+	clone.DFAStates[0].Edges[0].Target = 42
+	if reflect.DeepEqual(clone, tables) {
+		t.Error("the clone shares its tables with the original")
+	}
+}
+
+// replaces the empty lists of the tables by nil, as the readers and the builder create
+// them differently
+func normalize(t *gold.Tables) {
+	if len(t.Groups) == 0 {
+		t.Groups = nil
+	}
+	for i := range t.Groups {
+		if len(t.Groups[i].Nesting) == 0 {
+			t.Groups[i].Nesting = nil
+		}
+	}
+	for i := range t.Rules {
+		if len(t.Rules[i].Symbols) == 0 {
+			t.Rules[i].Symbols = nil
+		}
+	}
+	for i := range t.DFAStates {
+		if len(t.DFAStates[i].Edges) == 0 {
+			t.DFAStates[i].Edges = nil
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package gold
 
+import "bytes"
+
 type parserToken struct {
 	// Token symbol.
 	Symbol *symbol
@@ -9,6 +11,23 @@ type parserToken struct {
 
 	// Position within the source
 	Position TextPosition
+
+	// Position behind the last character of the token
+	End TextPosition
+
+	// true if the token is missing in the source and was inserted by the error recovery
+	Missing bool
+
+	// true if the input ended within a group which can only be closed by its end symbol,
+	// like a block comment
+	Runaway bool
+
+	// the exceeded limit which stopped the tokenizer or nil
+	Err *LimitError
+
+	// the runes of the text as they were read from the input, so that they can be read again
+	// with their original sizes
+	runes []sourceRune
 }
 
 type SymbolId uint16
@@ -30,9 +49,50 @@ type Token struct {
 	// tells if the token is a terminal or a non-terminal
 	IsTerminal bool
 
+	// tells if the token was created by the error recovery. Such a token is either a missing
+	// terminal or a non-terminal which contains the tokens which could not be parsed.
+	IsError bool
+
 	Symbol SymbolId
 
 	Rule RuleId
+
+	// the position of the first character of the token within the source
+	Start TextPosition
+
+	// the position behind the last character of the token. For non-terminals the span
+	// is computed from the sub-nodes. Tokens without any text have an empty span.
+	End TextPosition
+
+	// the noise and comments in front of a terminal. Only set if the KeepTrivia option is used.
+	LeadingTrivia []*Token
+
+	// the noise and comments behind a terminal up to the end of its line. Only set if the
+	// KeepTrivia option is used. The trivia at the end of the input is added to the last terminal.
+	TrailingTrivia []*Token
+}
+
+// returns the source text of the token including the trivia of all terminals
+func (t *Token) FullText() string {
+	buf := new(bytes.Buffer)
+	t.writeFullText(buf)
+	return string(buf.Bytes())
+}
+
+func (t *Token) writeFullText(buf *bytes.Buffer) {
+	for _, trivia := range t.LeadingTrivia {
+		buf.WriteString(trivia.Text)
+	}
+	if t.IsTerminal {
+		buf.WriteString(t.Text)
+	} else {
+		for _, sub := range t.Tokens {
+			sub.writeFullText(buf)
+		}
+	}
+	for _, trivia := range t.TrailingTrivia {
+		buf.WriteString(trivia.Text)
+	}
 }
 
 func (pt *parserToken) toToken() *Token {
@@ -41,6 +101,24 @@ func (pt *parserToken) toToken() *Token {
 		Text:       pt.Text,
 		Tokens:     nil,
 		IsTerminal: pt.Symbol.Kind == stTerminal,
+		IsError:    pt.Missing,
 		Symbol:     SymbolId(pt.Symbol.Index),
+		Start:      pt.Position,
+		End:        pt.End,
+	}
+}
+
+func (pt *parserToken) toTerminal() Terminal {
+	result := Terminal{
+		Symbol:   SymbolId(pt.Symbol.Index),
+		Name:     pt.Symbol.String(),
+		Kind:     SymbolKind(pt.Symbol.Kind),
+		Text:     pt.Text,
+		Position: pt.Position,
+		End:      pt.End,
+	}
+	if pt.Symbol.Group != nil {
+		result.Group = pt.Symbol.Group.Name
 	}
+	return result
 }
@@ -0,0 +1,104 @@
+package gold_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestTrace(t *testing.T) {
+	for name, p := range grammarFormats(t) {
+		var events, stacks []string
+		tracer := gold.TracerFunc(func(e *gold.ParseEvent) {
+			events = append(events, e.String())
+			if e.Kind == gold.EventShift {
+				stacks = append(stacks, fmt.Sprint(e.StateStack(), len(e.ValueStack())))
+			}
+		})
+		if _, err := p.ParseContext(context.Background(), strings.NewReader("x = 1;"), gold.Trace(tracer)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(events, debugEvents) {
+			t.Errorf("%s: got the events\n%s\nwant\n%s", name, strings.Join(events, "\n"), strings.Join(debugEvents, "\n"))
+		}
+		// the stacks before the shift
+		want := []string{"[0] 0", "[0 1] 1", "[0 1 6] 2", "[0 1 6 15] 3"}
+		if !reflect.DeepEqual(stacks, want) {
+			t.Errorf("%s: got the stacks %q, want %q", name, stacks, want)
+		}
+	}
+}
+
+func TestTraceErrors(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	var events []string
+	tracer := gold.TracerFunc(func(e *gold.ParseEvent) {
+		if e.Kind == gold.EventError {
+			events = append(events, e.String())
+		}
+	})
+	p.ParseContext(context.Background(), strings.NewReader("x = ; print $;"), gold.Trace(tracer), gold.RecoverErrors())
+	want := []string{
+		`error in 6: syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number`,
+		`error in 2: Unknown Token "$"`,
+		// the unknown token is skipped, which leaves "print ;"
+		`error in 2: syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number`,
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got the errors\n%s\nwant\n%s", strings.Join(events, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTraceOrder(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	var calls []string
+	tracer := func(name string) gold.Tracer {
+		return gold.TracerFunc(func(e *gold.ParseEvent) {
+			if e.Kind == gold.EventAccept {
+				calls = append(calls, name)
+			}
+		})
+	}
+	_, err := p.ParseContext(context.Background(), strings.NewReader("print 1;"), gold.Trace(tracer("first")), gold.Trace(nil), gold.Trace(tracer("second")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("got the calls %v, want %v", calls, want)
+	}
+}
+
+func TestTraceDebugger(t *testing.T) {
+	p := grammarFormats(t)["egt"]
+	var traced int
+	tracer := gold.TracerFunc(func(e *gold.ParseEvent) {
+		if e.Kind == gold.EventChar {
+			t.Errorf("the tracer got %s", e)
+		}
+		traced++
+	})
+	d := gold.NewDebugger(p, strings.NewReader("x = 1;"), gold.Trace(tracer))
+	defer d.Close()
+	var stepped, chars int
+	for {
+		e, ok := d.Step()
+		if !ok {
+			break
+		}
+		if e.Kind == gold.EventChar {
+			chars++
+		} else {
+			stepped++
+		}
+	}
+	if _, err := d.Result(); err != nil {
+		t.Fatal(err)
+	}
+	if traced != len(debugEvents) || stepped != len(debugEvents) || chars != len("x = 1;") {
+		t.Errorf("got %d traced events, %d stepped events and %d characters", traced, stepped, chars)
+	}
+}
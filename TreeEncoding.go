@@ -0,0 +1,308 @@
+package gold
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// represents a serialized syntax tree which can not be read or does not match the grammar
+type TreeError struct {
+	Message string
+	// the path of the invalid token, e.g. /0/2/leading/0 for the first leading trivia of the
+	// third sub-node of the first sub-node of the root. Empty if the error is not related to a token.
+	Path string
+	// the underlying error of the decoder or nil
+	Err error
+}
+
+// returns the error message as string
+func (te *TreeError) Error() string {
+	if te.Path == "" {
+		return "tree: " + te.Message
+	}
+	return fmt.Sprintf("tree: %s at %s", te.Message, te.Path)
+}
+
+// returns the underlying error or nil
+func (te *TreeError) Unwrap() error {
+	return te.Err
+}
+
+// writes the tree as JSON. Each token is an object with the fields name, symbol, rule (for
+// non-terminals), terminal, error, text (for terminals and trivia), start and end (if the
+// positions are known), tokens, leading and trailing (the trivia).
+func WriteJSON(w io.Writer, tree *Token) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(encodeTree(tree, false))
+}
+
+// reads a tree written by WriteJSON and checks it against the grammar
+func ReadJSON(r io.Reader, g Grammar) (*Token, error) {
+	var et *encodedToken
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&et); err != nil {
+		return nil, &TreeError{Message: err.Error(), Err: err}
+	}
+	return decodeTree(g, "", et, false)
+}
+
+// writes the tree as XML. Each token is a token element with the attributes name, symbol,
+// rule (for non-terminals), terminal, error, text (for terminals and trivia), start and end
+// (if the positions are known, as line:column:offset:runeoffset). The sub-nodes are nested
+// token elements and the trivia is contained in leading and trailing elements.
+func WriteXML(w io.Writer, tree *Token) error {
+	if tree == nil {
+		return nil
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(encodeTree(tree, false)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// reads a tree written by WriteXML and checks it against the grammar
+func ReadXML(r io.Reader, g Grammar) (*Token, error) {
+	var et *encodedToken
+	if err := xml.NewDecoder(r).Decode(&et); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, &TreeError{Message: err.Error(), Err: err}
+	}
+	return decodeTree(g, "", et, false)
+}
+
+// the serialized form of a token
+type encodedToken struct {
+	XMLName  xml.Name        `json:"-" xml:"token"`
+	Name     string          `json:"name" xml:"name,attr"`
+	Symbol   SymbolId        `json:"symbol" xml:"symbol,attr"`
+	Rule     *RuleId         `json:"rule,omitempty" xml:"rule,attr,omitempty"`
+	Terminal bool            `json:"terminal" xml:"terminal,attr"`
+	Error    bool            `json:"error,omitempty" xml:"error,attr,omitempty"`
+	Text     string          `json:"text,omitempty" xml:"text,attr,omitempty"`
+	Start    *treePosition   `json:"start,omitempty" xml:"start,attr,omitempty"`
+	End      *treePosition   `json:"end,omitempty" xml:"end,attr,omitempty"`
+	Tokens   []*encodedToken `json:"tokens,omitempty" xml:"token"`
+	Leading  *encodedTrivia  `json:"leading,omitempty" xml:"leading"`
+	Trailing *encodedTrivia  `json:"trailing,omitempty" xml:"trailing"`
+}
+
+// the trivia of a token. It is a pointer of the encodedToken, so that XML omits empty trivia.
+type encodedTrivia struct {
+	Tokens []*encodedToken `xml:"token"`
+}
+
+func (et *encodedTrivia) MarshalJSON() ([]byte, error) {
+	return json.Marshal(et.Tokens)
+}
+
+func (et *encodedTrivia) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(&et.Tokens)
+}
+
+func newEncodedTrivia(trivia []*Token) *encodedTrivia {
+	if len(trivia) == 0 {
+		return nil
+	}
+	result := new(encodedTrivia)
+	for _, t := range trivia {
+		result.Tokens = append(result.Tokens, encodeTree(t, true))
+	}
+	return result
+}
+
+func (et *encodedTrivia) tokens() []*encodedToken {
+	if et == nil {
+		return nil
+	}
+	return et.Tokens
+}
+
+type treePosition struct {
+	Line       int `json:"line"`
+	Column     int `json:"column"`
+	Offset     int `json:"offset"`
+	RuneOffset int `json:"runeOffset"`
+}
+
+func (tp treePosition) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d", tp.Line, tp.Column, tp.Offset, tp.RuneOffset)
+}
+
+func (tp treePosition) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: tp.String()}, nil
+}
+
+func (tp *treePosition) UnmarshalXMLAttr(attr xml.Attr) error {
+	return tp.parse(attr.Value)
+}
+
+// parses the position in the form line:column:offset:runeoffset
+func (tp *treePosition) parse(s string) error {
+	parts := strings.Split(s, ":")
+	values := make([]int, len(parts))
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || len(parts) != 4 {
+			return fmt.Errorf("invalid position %q", s)
+		}
+		values[i] = v
+	}
+	*tp = treePosition{values[0], values[1], values[2], values[3]}
+	return nil
+}
+
+// returns the serialized form of the tree. The text of non-terminals is omitted as it
+// contains the rule.
+func encodeTree(tok *Token, trivia bool) *encodedToken {
+	if tok == nil {
+		return nil
+	}
+	result := &encodedToken{
+		Name:     tok.Name,
+		Symbol:   tok.Symbol,
+		Terminal: tok.IsTerminal,
+		Error:    tok.IsError,
+	}
+	switch {
+	case tok.IsTerminal || trivia:
+		result.Text = tok.Text
+	case !tok.IsError:
+		rule := tok.Rule
+		result.Rule = &rule
+	}
+	if tok.Start != (TextPosition{}) || tok.End != (TextPosition{}) {
+		result.Start = &treePosition{tok.Start.Line, tok.Start.Column, tok.Start.Offset, tok.Start.RuneOffset}
+		result.End = &treePosition{tok.End.Line, tok.End.Column, tok.End.Offset, tok.End.RuneOffset}
+	}
+	for _, sub := range tok.Tokens {
+		result.Tokens = append(result.Tokens, encodeTree(sub, false))
+	}
+	result.Leading = newEncodedTrivia(tok.LeadingTrivia)
+	result.Trailing = newEncodedTrivia(tok.TrailingTrivia)
+	return result
+}
+
+// creates the tree from its serialized form and checks it against the grammar. The names of
+// the tokens are optional and the text of non-terminals is taken from their rule.
+func decodeTree(g Grammar, path string, et *encodedToken, trivia bool) (*Token, error) {
+	if et == nil {
+		if path == "" {
+			return nil, nil
+		}
+		return nil, &TreeError{Message: "missing token", Path: path}
+	}
+	fail := func(format string, args ...interface{}) (*Token, error) {
+		if path == "" {
+			return nil, &TreeError{Message: fmt.Sprintf(format, args...), Path: "/"}
+		}
+		return nil, &TreeError{Message: fmt.Sprintf(format, args...), Path: path}
+	}
+
+	sym := g.Symbol(et.Symbol)
+	if sym == nil {
+		return fail("unknown symbol %d", et.Symbol)
+	}
+	if et.Name != "" && et.Name != sym.String() {
+		return fail("the name %q does not match the symbol %s", et.Name, sym)
+	}
+	tok := &Token{
+		Name:       sym.String(),
+		Text:       et.Text,
+		IsTerminal: et.Terminal,
+		IsError:    et.Error,
+		Symbol:     sym.Id,
+	}
+	if et.Start != nil {
+		tok.Start = TextPosition{et.Start.Line, et.Start.Column, et.Start.Offset, et.Start.RuneOffset}
+	}
+	if et.End != nil {
+		tok.End = TextPosition{et.End.Line, et.End.Column, et.End.Offset, et.End.RuneOffset}
+	}
+
+	switch {
+	case trivia:
+		if sym.Kind == KindNonTerminal || sym.Kind == KindTerminal || et.Terminal {
+			return fail("the trivia %s is not noise", sym)
+		}
+	case sym.Kind != KindNonTerminal && sym.Kind != KindTerminal && sym.Kind != KindEnd:
+		return fail("the symbol %s can only be trivia", sym)
+	case sym.Kind != KindNonTerminal:
+		if et.Terminal != (sym.Kind == KindTerminal) {
+			return fail("the terminal flag does not match the symbol %s", sym)
+		}
+	case et.Terminal:
+		return fail("the non-terminal %s is marked as terminal", sym)
+	case et.Text != "":
+		return fail("the non-terminal %s has a text", sym)
+	}
+
+	if sym.Kind == KindNonTerminal && !et.Error {
+		if et.Rule == nil {
+			return fail("the non-terminal %s has no rule", sym)
+		}
+		rule := g.Rule(*et.Rule)
+		if rule == nil {
+			return fail("unknown rule %d", *et.Rule)
+		}
+		if rule.Head.Id != sym.Id {
+			return fail("the rule %s does not produce %s", rule, sym)
+		}
+		// trimmed reductions replace sub-nodes but keep their number
+		if len(et.Tokens) != len(rule.Symbols) {
+			return fail("%d sub-nodes do not match the rule %s", len(et.Tokens), rule)
+		}
+		tok.Rule = rule.Id
+		tok.Text = rule.String()
+	} else if et.Rule != nil {
+		return fail("the symbol %s can not have a rule", sym)
+	}
+
+	if len(et.Tokens) > 0 && sym.Kind != KindNonTerminal {
+		return fail("the symbol %s has sub-nodes", sym)
+	}
+	// the root keeps the trivia of an input without terminals
+	if (len(et.Leading.tokens()) > 0 || len(et.Trailing.tokens()) > 0) && !et.Terminal && path != "" {
+		return fail("the symbol %s has trivia", sym)
+	}
+
+	if sym.Kind == KindNonTerminal {
+		tok.Tokens = make([]*Token, 0, len(et.Tokens))
+	}
+	for idx, sub := range et.Tokens {
+		t, err := decodeTree(g, path+"/"+strconv.Itoa(idx), sub, false)
+		if err != nil {
+			return nil, err
+		}
+		tok.Tokens = append(tok.Tokens, t)
+	}
+	for idx, sub := range et.Leading.tokens() {
+		t, err := decodeTree(g, path+"/leading/"+strconv.Itoa(idx), sub, true)
+		if err != nil {
+			return nil, err
+		}
+		tok.LeadingTrivia = append(tok.LeadingTrivia, t)
+	}
+	for idx, sub := range et.Trailing.tokens() {
+		t, err := decodeTree(g, path+"/trailing/"+strconv.Itoa(idx), sub, true)
+		if err != nil {
+			return nil, err
+		}
+		tok.TrailingTrivia = append(tok.TrailingTrivia, t)
+	}
+	return tok, nil
+}
@@ -0,0 +1,166 @@
+package gold_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+var treeEncodings = []struct {
+	name  string
+	write func(io.Writer, *gold.Token) error
+	read  func(io.Reader, gold.Grammar) (*gold.Token, error)
+}{
+	{"json", gold.WriteJSON, gold.ReadJSON},
+	{"xml", gold.WriteXML, gold.ReadXML},
+	{"sexpr", gold.WriteSExpr, gold.ReadSExpr},
+}
+
+// returns the differences of two trees
+func treeDiff(path string, got, want *gold.Token) []string {
+	if got == nil || want == nil {
+		if got != want {
+			return []string{fmt.Sprintf("%s: got %v, want %v", path, got, want)}
+		}
+		return nil
+	}
+	var result []string
+	if got.Name != want.Name || got.Text != want.Text || got.IsTerminal != want.IsTerminal || got.IsError != want.IsError ||
+		got.Symbol != want.Symbol || got.Rule != want.Rule || got.Start != want.Start || got.End != want.End {
+		result = append(result, fmt.Sprintf("%s: got %+v, want %+v", path, *got, *want))
+	}
+	for _, sub := range []struct {
+		name      string
+		got, want []*gold.Token
+	}{{"", got.Tokens, want.Tokens}, {"leading/", got.LeadingTrivia, want.LeadingTrivia}, {"trailing/", got.TrailingTrivia, want.TrailingTrivia}} {
+		if len(sub.got) != len(sub.want) {
+			result = append(result, fmt.Sprintf("%s/%s: got %d tokens, want %d", path, sub.name, len(sub.got), len(sub.want)))
+			continue
+		}
+		for i := range sub.got {
+			result = append(result, treeDiff(fmt.Sprintf("%s/%s%d", path, sub.name, i), sub.got[i], sub.want[i])...)
+		}
+	}
+	return result
+}
+
+func TestTreeEncodingRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []gold.ParseOption
+	}{
+		{"tree", "x = 1 + 2; print -x;", nil},
+		{"trimmed", "x = 1 + 2; print -x;", []gold.ParseOption{gold.TrimReductions(true)}},
+		{"trivia", "/* a */ x = 1; // b \"c\" <d> & (e)\n\tprint x;\n", []gold.ParseOption{gold.KeepTrivia(true)}},
+		{"errors", "x = 1 2; y = (1 + 2 print x; z = 3;", []gold.ParseOption{gold.RecoverErrors()}},
+		{"multi-byte", "x = 1; /* ä€😀 */ print x;", []gold.ParseOption{gold.KeepTrivia(true)}},
+		{"empty", "", nil},
+		{"only trivia", "  // only a comment\n", []gold.ParseOption{gold.KeepTrivia(true)}},
+		{"trimmed trivia", "x = 1; // a\n", []gold.ParseOption{gold.KeepTrivia(true), gold.TrimReductions(true)}},
+	}
+	p := grammarFormats(t)["egt"]
+	for _, tt := range tests {
+		tree, err := p.ParseContext(context.Background(), strings.NewReader(tt.input), tt.opts...)
+		if tree == nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, enc := range treeEncodings {
+			buf := new(bytes.Buffer)
+			if err := enc.write(buf, tree); err != nil {
+				t.Errorf("%s %s: %v", enc.name, tt.name, err)
+				continue
+			}
+			written := buf.String()
+			read, err := enc.read(buf, p.Grammar())
+			if err != nil {
+				t.Errorf("%s %s: %v\n%s", enc.name, tt.name, err, written)
+				continue
+			}
+			if diff := treeDiff("", read, tree); len(diff) > 0 {
+				t.Errorf("%s %s: the tree read differs:\n%s", enc.name, tt.name, strings.Join(diff, "\n"))
+			}
+			if read.FullText() != tree.FullText() {
+				t.Errorf("%s %s: got the text %q", enc.name, tt.name, read.FullText())
+			}
+		}
+	}
+
+	for _, enc := range treeEncodings {
+		buf := new(bytes.Buffer)
+		if err := enc.write(buf, nil); err != nil {
+			t.Errorf("%s: %v", enc.name, err)
+		}
+		if read, err := enc.read(buf, p.Grammar()); read != nil || err != nil {
+			t.Errorf("%s: read %v, %v for an empty tree", enc.name, read, err)
+		}
+	}
+}
+
+func TestReadTreeErrors(t *testing.T) {
+	// the symbols of the calculator grammar: 9 is =, 14 Identifier, 20 <Factor>, 22 <Stmt> and 23 <Stmts>.
+	// The rule 2 is <Stmts> ::= and 12 <Factor> ::= Identifier.
+	tests := []struct {
+		name    string
+		json    string
+		sexpr   string
+		message string
+	}{
+		{"unknown symbol", `{"symbol": 99}`, `("" :symbol 99)`, "tree: unknown symbol 99 at /"},
+		{"wrong name", `{"name": "<Stmt>", "symbol": 23, "rule": 2}`, `("<Stmt>" :symbol 23 :rule 2)`,
+			`tree: the name "<Stmt>" does not match the symbol <Stmts> at /`},
+		{"missing rule", `{"symbol": 23}`, `("" :symbol 23)`, "tree: the non-terminal <Stmts> has no rule at /"},
+		{"rule of another symbol", `{"symbol": 22, "rule": 2}`, `("" :symbol 22 :rule 2)`,
+			"tree: the rule <Stmts> ::=  does not produce <Stmt> at /"},
+		{"wrong number of sub-nodes", `{"symbol": 20, "rule": 12}`, `("" :symbol 20 :rule 12)`,
+			"tree: 0 sub-nodes do not match the rule <Factor> ::= Identifier at /"},
+		{"terminal flag", `{"symbol": 20, "rule": 12, "tokens": [{"symbol": 14}]}`, `("" :symbol 20 :rule 12 ("" :symbol 14))`,
+			"tree: the terminal flag does not match the symbol Identifier at /0"},
+		{"missing sub-node", `{"symbol": 20, "rule": 12, "tokens": [null]}`, "",
+			"tree: missing token at /0"},
+		{"trivia of a non-terminal", `{"symbol": 21, "rule": 0, "tokens": [{"symbol": 23, "rule": 2, "leading": [{"symbol": 18, "text": " "}]}]}`,
+			`("" :symbol 21 :rule 0 ("" :symbol 23 :rule 2 :leading (("" :symbol 18 :text " "))))`, "tree: the symbol <Stmts> has trivia at /0"},
+		{"trivia which is no noise", `{"symbol": 14, "terminal": true, "text": "x", "leading": [{"symbol": 9, "text": "="}]}`,
+			`("" :symbol 14 :terminal :text "x" :leading (("" :symbol 9 :text "=")))`, "tree: the trivia = is not noise at /leading/0"},
+		{"noise in the tree", `{"symbol": 18, "text": " "}`, `("" :symbol 18 :text " ")`, "tree: the symbol Whitespace can only be trivia at /"},
+	}
+	g := grammarFormats(t)["egt"].Grammar()
+	for _, tt := range tests {
+		_, err := gold.ReadJSON(strings.NewReader(tt.json), g)
+		var te *gold.TreeError
+		if !errors.As(err, &te) || err.Error() != tt.message {
+			t.Errorf("json %s: got %v, want %s", tt.name, err, tt.message)
+		}
+		if tt.sexpr == "" {
+			continue
+		}
+		if _, err := gold.ReadSExpr(strings.NewReader(tt.sexpr), g); !errors.As(err, &te) || err.Error() != tt.message {
+			t.Errorf("sexpr %s: got %v, want %s", tt.name, err, tt.message)
+		}
+	}
+}
+
+func TestReadTreeSyntaxErrors(t *testing.T) {
+	g := grammarFormats(t)["egt"].Grammar()
+	var te *gold.TreeError
+	if _, err := gold.ReadJSON(strings.NewReader(`{"symbol": "x"}`), g); !errors.As(err, &te) || te.Err == nil {
+		t.Errorf("json: got %v, want a TreeError with the error of the decoder", err)
+	}
+	if _, err := gold.ReadJSON(strings.NewReader(`{"symbol": 23, "rule": 2, "unknown": 1}`), g); !errors.As(err, &te) {
+		t.Errorf("json: got %v for an unknown field", err)
+	}
+	if _, err := gold.ReadXML(strings.NewReader(`<token symbol="23" rule="2" start="1:2"></token>`), g); !errors.As(err, &te) || te.Err == nil {
+		t.Errorf("xml: got %v for an invalid position", err)
+	}
+	for _, src := range []string{`("" :symbol 23 :rule 2`, `("" :symbol 23 :rule 2) x`, `("" :unknown 1)`} {
+		if _, err := gold.ReadSExpr(strings.NewReader(src), g); !errors.As(err, &te) {
+			t.Errorf("sexpr %q: got %v", src, err)
+		}
+	}
+}
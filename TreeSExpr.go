@@ -0,0 +1,292 @@
+package gold
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// writes the tree as S-expression. Each token is a list which starts with the quoted name of
+// the symbol followed by the keywords :symbol, :rule (for non-terminals), :terminal and :error
+// (if set), :text (for terminals and trivia), :start and :end (if the positions are known, as
+// a list of line, column, offset and rune offset), :leading and :trailing (lists of the
+// trivia) and the sub-nodes:
+//
+//	("<Stmt>" :symbol 12 :rule 5 :start (1 1 0 0) :end (1 3 2 2)
+//	  ("print" :symbol 9 :terminal :text "print" ...)
+//	  ...)
+func WriteSExpr(w io.Writer, tree *Token) error {
+	bw := bufio.NewWriter(w)
+	if tree == nil {
+		bw.WriteString("()")
+	} else {
+		writeSExpr(bw, encodeTree(tree, false), 0)
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+func writeSExpr(w *bufio.Writer, et *encodedToken, depth int) {
+	fmt.Fprintf(w, "(%s :symbol %d", strconv.Quote(et.Name), et.Symbol)
+	if et.Rule != nil {
+		fmt.Fprintf(w, " :rule %d", *et.Rule)
+	}
+	if et.Terminal {
+		w.WriteString(" :terminal")
+	}
+	if et.Error {
+		w.WriteString(" :error")
+	}
+	if et.Terminal || et.Text != "" {
+		fmt.Fprintf(w, " :text %s", strconv.Quote(et.Text))
+	}
+	if et.Start != nil {
+		fmt.Fprintf(w, " :start (%d %d %d %d)", et.Start.Line, et.Start.Column, et.Start.Offset, et.Start.RuneOffset)
+	}
+	if et.End != nil {
+		fmt.Fprintf(w, " :end (%d %d %d %d)", et.End.Line, et.End.Column, et.End.Offset, et.End.RuneOffset)
+	}
+	for _, trivia := range []struct {
+		keyword string
+		tokens  []*encodedToken
+	}{{":leading", et.Leading.tokens()}, {":trailing", et.Trailing.tokens()}} {
+		if len(trivia.tokens) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, " %s (", trivia.keyword)
+		for i, t := range trivia.tokens {
+			if i > 0 {
+				w.WriteString(" ")
+			}
+			writeSExpr(w, t, depth+1)
+		}
+		w.WriteString(")")
+	}
+	for _, sub := range et.Tokens {
+		w.WriteString("\n")
+		w.WriteString(strings.Repeat("  ", depth+1))
+		writeSExpr(w, sub, depth+1)
+	}
+	w.WriteString(")")
+}
+
+// reads a tree written by WriteSExpr and checks it against the grammar. Comments start
+// with ; and end at the end of the line.
+func ReadSExpr(r io.Reader, g Grammar) (*Token, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, &TreeError{Message: err.Error(), Err: err}
+	}
+	sp := &sexprParser{src: string(src)}
+	sp.skipSpace()
+	var et *encodedToken
+	if strings.HasPrefix(sp.src[sp.pos:], "()") {
+		sp.pos += 2
+	} else if et, err = sp.token(); err != nil {
+		return nil, err
+	}
+	sp.skipSpace()
+	if sp.pos < len(sp.src) {
+		return nil, sp.errorf("unexpected %q behind the tree", sp.src[sp.pos])
+	}
+	return decodeTree(g, "", et, false)
+}
+
+type sexprParser struct {
+	src string
+	pos int
+}
+
+func (sp *sexprParser) errorf(format string, args ...interface{}) error {
+	return &TreeError{Message: fmt.Sprintf(format, args...) + fmt.Sprintf(" at offset %d", sp.pos)}
+}
+
+func (sp *sexprParser) skipSpace() {
+	for sp.pos < len(sp.src) {
+		switch sp.src[sp.pos] {
+		case ' ', '\t', '\r', '\n':
+			sp.pos++
+		case ';':
+			for sp.pos < len(sp.src) && sp.src[sp.pos] != '\n' {
+				sp.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// reads the next character which is not white space
+func (sp *sexprParser) expect(c byte) error {
+	sp.skipSpace()
+	if sp.pos >= len(sp.src) {
+		return sp.errorf("unexpected end of the tree, expected %q", c)
+	}
+	if sp.src[sp.pos] != c {
+		return sp.errorf("unexpected %q, expected %q", sp.src[sp.pos], c)
+	}
+	sp.pos++
+	return nil
+}
+
+func (sp *sexprParser) peek() byte {
+	sp.skipSpace()
+	if sp.pos >= len(sp.src) {
+		return 0
+	}
+	return sp.src[sp.pos]
+}
+
+// reads an atom which ends at white space or a parenthesis
+func (sp *sexprParser) atom() string {
+	sp.skipSpace()
+	start := sp.pos
+	for sp.pos < len(sp.src) && !strings.ContainsRune(" \t\r\n();\"", rune(sp.src[sp.pos])) {
+		sp.pos++
+	}
+	return sp.src[start:sp.pos]
+}
+
+func (sp *sexprParser) int() (int, error) {
+	start := sp.pos
+	a := sp.atom()
+	v, err := strconv.Atoi(a)
+	if err != nil {
+		sp.pos = start
+		return 0, sp.errorf("invalid number %q", a)
+	}
+	return v, nil
+}
+
+func (sp *sexprParser) uint16() (uint16, error) {
+	start := sp.pos
+	v, err := sp.int()
+	if err == nil && (v < 0 || v > 0xFFFF) {
+		sp.pos = start
+		err = sp.errorf("invalid index %d", v)
+	}
+	return uint16(v), err
+}
+
+// reads a quoted string with the escape sequences of Go
+func (sp *sexprParser) string() (string, error) {
+	if sp.peek() != '"' {
+		return "", sp.errorf("missing string")
+	}
+	start := sp.pos
+	for i := sp.pos + 1; i < len(sp.src); i++ {
+		switch sp.src[i] {
+		case '\\':
+			i++
+		case '"':
+			s, err := strconv.Unquote(sp.src[start : i+1])
+			if err != nil {
+				return "", sp.errorf("invalid string")
+			}
+			sp.pos = i + 1
+			return s, nil
+		}
+	}
+	return "", sp.errorf("unclosed string")
+}
+
+func (sp *sexprParser) position() (*treePosition, error) {
+	if err := sp.expect('('); err != nil {
+		return nil, err
+	}
+	var values [4]int
+	for i := range values {
+		var err error
+		if values[i], err = sp.int(); err != nil {
+			return nil, err
+		}
+	}
+	if err := sp.expect(')'); err != nil {
+		return nil, err
+	}
+	return &treePosition{values[0], values[1], values[2], values[3]}, nil
+}
+
+func (sp *sexprParser) tokenList() ([]*encodedToken, error) {
+	if err := sp.expect('('); err != nil {
+		return nil, err
+	}
+	var result []*encodedToken
+	for sp.peek() == '(' {
+		et, err := sp.token()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, et)
+	}
+	return result, sp.expect(')')
+}
+
+func (sp *sexprParser) token() (*encodedToken, error) {
+	if err := sp.expect('('); err != nil {
+		return nil, err
+	}
+	et := new(encodedToken)
+	var err error
+	if et.Name, err = sp.string(); err != nil {
+		return nil, err
+	}
+	hasSymbol := false
+	for {
+		switch sp.peek() {
+		case 0:
+			return nil, sp.errorf("unexpected end of the tree")
+		case ')':
+			sp.pos++
+			if !hasSymbol {
+				return nil, sp.errorf("missing :symbol of %s", et.Name)
+			}
+			return et, nil
+		case '(':
+			sub, err := sp.token()
+			if err != nil {
+				return nil, err
+			}
+			et.Tokens = append(et.Tokens, sub)
+			continue
+		}
+
+		start := sp.pos
+		switch keyword := sp.atom(); keyword {
+		case ":symbol":
+			var id uint16
+			id, err = sp.uint16()
+			et.Symbol, hasSymbol = SymbolId(id), true
+		case ":rule":
+			var id uint16
+			id, err = sp.uint16()
+			rule := RuleId(id)
+			et.Rule = &rule
+		case ":terminal":
+			et.Terminal = true
+		case ":error":
+			et.Error = true
+		case ":text":
+			et.Text, err = sp.string()
+		case ":start":
+			et.Start, err = sp.position()
+		case ":end":
+			et.End, err = sp.position()
+		case ":leading":
+			et.Leading = new(encodedTrivia)
+			et.Leading.Tokens, err = sp.tokenList()
+		case ":trailing":
+			et.Trailing = new(encodedTrivia)
+			et.Trailing.Tokens, err = sp.tokenList()
+		default:
+			sp.pos = start
+			return nil, sp.errorf("unexpected %q", keyword)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package gold_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestKeepTriviaFullText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"comments", "x = 1 + 2 * 3; // set x\n/* block */ print (x - 4.5) / 2 ;\n"},
+		{"surrounding space", "  \n x = 1;\t \n\n"},
+		{"line comment at the end", "x = 1; // c"},
+		{"block comment at the end", "x = 1; /* c */"},
+	}
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			tree, err := p.ParseContext(context.Background(), strings.NewReader(tt.input), gold.KeepTrivia(true), gold.RecoverErrors())
+			if err != nil {
+				t.Errorf("%s %s: %v", format, tt.name, err)
+				continue
+			}
+			if got := tree.FullText(); got != tt.input {
+				t.Errorf("%s %s: FullText is %q, want %q", format, tt.name, got, tt.input)
+			}
+		}
+	}
+}
+
+func TestRunawayGroup(t *testing.T) {
+	const input = "x = 1;\nprint x; /* open\n"
+	// the CGT files have no groups, so the comment is reported by its start symbol
+	messages := map[string]string{
+		"egt": "Runaway group Comment at Line 2, Column 10",
+		"cgt": "Runaway group Comment Start at Line 2, Column 10",
+	}
+	for format, p := range grammarFormats(t) {
+		// the comment is kept in the tree even though it is not closed
+		tree, err := p.ParseContext(context.Background(), strings.NewReader(input), gold.KeepTrivia(true), gold.RecoverErrors())
+		var errs gold.ParseErrors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Error() != messages[format] {
+			t.Errorf("%s: got the error %v", format, err)
+		}
+		if tree == nil || tree.FullText() != input {
+			t.Errorf("%s: got the tree %v", format, tree)
+		}
+
+		// without the error recovery the runaway group stops the parser
+		tree, err = p.ParseContext(context.Background(), strings.NewReader(input))
+		var pe *gold.ParseError
+		if tree != nil || !errors.As(err, &pe) || pe.Position.Line != 2 || pe.Position.Column != 10 {
+			t.Errorf("%s: got %v, %v", format, tree, err)
+		}
+	}
+}
+
+// prints the terminals of the tree with their trivia as [leading]text[trailing]
+func triviaString(tok *gold.Token) string {
+	var parts []string
+	var walk func(tok *gold.Token)
+	walk = func(tok *gold.Token) {
+		if !tok.IsTerminal {
+			for _, c := range tok.Tokens {
+				walk(c)
+			}
+			return
+		}
+		texts := func(trivia []*gold.Token) string {
+			var result []string
+			for _, tr := range trivia {
+				result = append(result, fmt.Sprintf("%q", tr.Text))
+			}
+			return strings.Join(result, " ")
+		}
+		parts = append(parts, fmt.Sprintf("[%s]%q[%s]", texts(tok.LeadingTrivia), tok.Text, texts(tok.TrailingTrivia)))
+	}
+	walk(tok)
+	return strings.Join(parts, " ")
+}
+
+func TestKeepTriviaAttachment(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// the trivia up to the end of the line trails the terminal in front of it
+		{"x = 1; // c\n  print x;",
+			`[]"x"[" "] []"="[" "] []"1"[] []";"[" " "// c" "\n"] ["  "]"print"[" "] []"x"[] []";"[]`},
+		{"/* a */ x = 1; /* b */",
+			`["/* a */" " "]"x"[" "] []"="[" "] []"1"[] []";"[" " "/* b */"]`},
+		// the trivia at the end of the input trails the last terminal
+		{"x = 1;\n\n  \n",
+			`[]"x"[" "] []"="[" "] []"1"[] []";"["\n" "\n" "  " "\n"]`},
+	}
+	for format, p := range grammarFormats(t) {
+		for _, tt := range tests {
+			tree, err := p.ParseContext(context.Background(), strings.NewReader(tt.input), gold.KeepTrivia(true))
+			if err != nil {
+				t.Errorf("%s %q: %v", format, tt.input, err)
+				continue
+			}
+			if got := triviaString(tree); got != tt.want {
+				t.Errorf("%s %q: got\n%s\nwant\n%s", format, tt.input, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestWithoutTrivia(t *testing.T) {
+	for format, p := range grammarFormats(t) {
+		tree, err := p.ParseContext(context.Background(), strings.NewReader("x = 1; // c\n"))
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if got := triviaString(tree); got != `[]"x"[] []"="[] []"1"[] []";"[]` {
+			t.Errorf("%s: got %s", format, got)
+		}
+	}
+}
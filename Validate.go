@@ -0,0 +1,494 @@
+package gold
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the kind of a problem found by validating grammar tables
+type IssueKind byte
+
+const (
+	IssueInvalidReference    IssueKind = iota // an index references an entry which does not exist
+	IssueMissingSymbol                        // the end or the error symbol is missing
+	IssueInvalidAction                        // an LR action does not fit its symbol
+	IssueMissingGoto                          // a state can not continue after a reduction
+	IssueInvalidAccept                        // a DFA state accepts a symbol which is no terminal
+	IssueInvalidGroup                         // a lexical group is inconsistent
+	IssueReductionCycle                       // the parser can reduce forever without a shift
+	IssueUnreachableDFAState                  // a DFA state can not be reached from the initial state
+	IssueUnreachableLRState                   // an LR state can not be reached from the initial state
+	IssueUnusedSymbol                         // a symbol is never used by the rules or never scanned
+	IssueUnusedRule                           // a rule is never reduced
+)
+
+// tells if the issue prevents parsing. All other issues are warnings.
+func (k IssueKind) IsError() bool {
+	return k < IssueUnreachableDFAState
+}
+
+// a problem found by validating grammar tables
+type Issue struct {
+	Kind    IssueKind
+	Message string
+}
+
+// the result of validating grammar tables
+type ValidationReport struct {
+	Issues []Issue
+}
+
+func (r *ValidationReport) add(kind IssueKind, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+// returns all issues which prevent parsing
+func (r *ValidationReport) Errors() []Issue {
+	var result []Issue
+	for _, i := range r.Issues {
+		if i.Kind.IsError() {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// returns all issues which do not prevent parsing
+func (r *ValidationReport) Warnings() []Issue {
+	var result []Issue
+	for _, i := range r.Issues {
+		if !i.Kind.IsError() {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// returns a *ValidationError if the report contains errors or nil
+func (r *ValidationReport) Err() error {
+	if len(r.Errors()) == 0 {
+		return nil
+	}
+	return &ValidationError{Report: r}
+}
+
+// returns all issues, one per line. Warnings are marked as such.
+func (r *ValidationReport) String() string {
+	if len(r.Issues) == 0 {
+		return "no issues"
+	}
+	msgs := make([]string, len(r.Issues))
+	for i, issue := range r.Issues {
+		msgs[i] = issue.Message
+		if !issue.Kind.IsError() {
+			msgs[i] = "warning: " + issue.Message
+		}
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// reports grammar tables which can not be used for parsing
+type ValidationError struct {
+	// the report containing the errors and warnings
+	Report *ValidationReport
+}
+
+// returns the messages of all issues which prevent parsing
+func (ve *ValidationError) Error() string {
+	errs := ve.Report.Errors()
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+	}
+	return "invalid grammar: " + strings.Join(msgs, "\n")
+}
+
+// checks the consistency of the tables. Reports invalid references, missing gotos after reductions,
+// cycles of reductions, DFA states accepting non-terminals and missing end or error symbols as
+// errors. Unreachable states, unused symbols and unused rules are reported as warnings.
+func (t *Tables) Validate() *ValidationReport {
+	v := &tableValidator{t: t, report: new(ValidationReport)}
+	v.validateSymbols()
+	v.validateGroups()
+	v.validateRules()
+	v.validateDFA()
+	v.validateLR()
+	if len(v.report.Errors()) == 0 {
+		v.findUnused()
+	}
+	return v.report
+}
+
+type tableValidator struct {
+	t      *Tables
+	report *ValidationReport
+}
+
+func (v *tableValidator) symbolName(id SymbolId) string {
+	return v.t.symbolName(id)
+}
+
+// checks a reference into a table with count entries and reports invalid references
+func (v *tableValidator) ref(idx, count int, what, table string) bool {
+	if idx < count {
+		return true
+	}
+	v.report.add(IssueInvalidReference, "%s references the unknown %s %d", what, table, idx)
+	return false
+}
+
+func (v *tableValidator) validateSymbols() {
+	hasEnd, hasError := false, false
+	for idx, s := range v.t.Symbols {
+		switch s.Kind {
+		case KindEnd:
+			hasEnd = true
+		case KindError:
+			hasError = true
+		case KindNonTerminal, KindTerminal, KindNoise, KindGroupStart, KindGroupEnd, KindCommentLine:
+		default:
+			v.report.add(IssueInvalidReference, "the symbol %d has the unknown kind %d", idx, s.Kind)
+		}
+	}
+	if !hasEnd {
+		v.report.add(IssueMissingSymbol, "the grammar has no end symbol")
+	}
+	if !hasError {
+		v.report.add(IssueMissingSymbol, "the grammar has no error symbol")
+	}
+	v.ref(int(v.t.StartSymbol), len(v.t.Symbols), "the grammar", "start symbol")
+}
+
+func (v *tableValidator) validateGroups() {
+	symbols := len(v.t.Symbols)
+	inGroup := make(map[SymbolId]bool)
+	for idx, g := range v.t.Groups {
+		what := fmt.Sprintf("the group %s", g.Name)
+		v.ref(int(g.Container), symbols, what, "symbol")
+		v.ref(int(g.End), symbols, what, "symbol")
+		if v.ref(int(g.Start), symbols, what, "symbol") {
+			inGroup[g.Start] = true
+			if v.t.Symbols[g.Start].Kind != KindGroupStart {
+				v.report.add(IssueInvalidGroup, "%s starts with %s which is no group start symbol", what, v.symbolName(g.Start))
+			}
+		}
+		for _, n := range g.Nesting {
+			v.ref(int(n), len(v.t.Groups), what, "group")
+		}
+		if g.AdvanceMode != AdvanceToken && g.AdvanceMode != AdvanceCharacter {
+			v.report.add(IssueInvalidGroup, "the group %d has the unknown advance mode %d", idx, g.AdvanceMode)
+		}
+		if g.EndingMode != EndingOpen && g.EndingMode != EndingClosed {
+			v.report.add(IssueInvalidGroup, "the group %d has the unknown ending mode %d", idx, g.EndingMode)
+		}
+	}
+	if len(v.t.Groups) > 0 {
+		for idx, s := range v.t.Symbols {
+			if s.Kind == KindGroupStart && !inGroup[SymbolId(idx)] {
+				v.report.add(IssueInvalidGroup, "the group start symbol %s belongs to no group", v.symbolName(SymbolId(idx)))
+			}
+		}
+	}
+}
+
+func (v *tableValidator) validateRules() {
+	symbols := len(v.t.Symbols)
+	for idx, r := range v.t.Rules {
+		what := fmt.Sprintf("the rule %d", idx)
+		if v.ref(int(r.Head), symbols, what, "symbol") && v.t.Symbols[r.Head].Kind != KindNonTerminal {
+			v.report.add(IssueInvalidReference, "%s reduces to %s which is no non-terminal", what, v.symbolName(r.Head))
+		}
+		for _, s := range r.Symbols {
+			v.ref(int(s), symbols, what, "symbol")
+		}
+	}
+}
+
+func (v *tableValidator) validateDFA() {
+	t := v.t
+	if !v.ref(int(t.InitialDFAState), len(t.DFAStates), "the grammar", "initial DFA state") {
+		return
+	}
+	for idx, state := range t.DFAStates {
+		what := fmt.Sprintf("the DFA state %d", idx)
+		if state.Accept && v.ref(int(state.AcceptSymbol), len(t.Symbols), what, "symbol") {
+			switch t.Symbols[state.AcceptSymbol].Kind {
+			case KindNonTerminal, KindEnd, KindError:
+				v.report.add(IssueInvalidAccept, "%s accepts %s which is no terminal", what, v.symbolName(state.AcceptSymbol))
+			}
+		}
+		for _, e := range state.Edges {
+			v.ref(int(e.CharSet), len(t.CharSets), what, "character set")
+			v.ref(int(e.Target), len(t.DFAStates), what, "DFA state")
+		}
+	}
+}
+
+func (v *tableValidator) validateLR() {
+	t := v.t
+	if !v.ref(int(t.InitialLRState), len(t.LRStates), "the grammar", "initial LR state") {
+		return
+	}
+	valid := true
+	for idx, state := range t.LRStates {
+		what := fmt.Sprintf("the LR state %d", idx)
+		for _, a := range state.Actions {
+			if !v.ref(int(a.Symbol), len(t.Symbols), what, "symbol") {
+				valid = false
+				continue
+			}
+			nonTerminal := t.Symbols[a.Symbol].Kind == KindNonTerminal
+			switch a.Action {
+			case ActionShift, ActionGoto:
+				valid = v.ref(int(a.Target), len(t.LRStates), what, "LR state") && valid
+			case ActionReduce:
+				valid = v.ref(int(a.Target), len(t.Rules), what, "rule") && valid
+			case ActionAccept:
+			default:
+				v.report.add(IssueInvalidAction, "%s contains the unknown action %d", what, a.Action)
+				valid = false
+				continue
+			}
+			if (a.Action == ActionGoto) != nonTerminal {
+				v.report.add(IssueInvalidAction, "%s contains the action %d for %s", what, a.Action, v.symbolName(a.Symbol))
+				valid = false
+			}
+		}
+	}
+	if valid {
+		errors := len(v.report.Errors())
+		v.validateGotos()
+		if len(v.report.Errors()) == errors {
+			v.validateReductions()
+		}
+	}
+}
+
+// returns the target state of the shift or goto on the symbol or -1
+// returns the action of the state for the symbol or nil
+func (v *tableValidator) action(state int, sym SymbolId) *TableLRAction {
+	actions := v.t.LRStates[state].Actions
+	for i := range actions {
+		if actions[i].Symbol == sym {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+func (v *tableValidator) transition(state int, sym SymbolId) int {
+	for _, a := range v.t.LRStates[state].Actions {
+		if a.Symbol == sym && (a.Action == ActionShift || a.Action == ActionGoto) {
+			return int(a.Target)
+		}
+	}
+	return -1
+}
+
+// returns the LR states which can be reached from the initial state
+func (v *tableValidator) reachableLRStates() []bool {
+	reached := make([]bool, len(v.t.LRStates))
+	todo := []int{int(v.t.InitialLRState)}
+	reached[v.t.InitialLRState] = true
+	for len(todo) > 0 {
+		state := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		for _, a := range v.t.LRStates[state].Actions {
+			if (a.Action == ActionShift || a.Action == ActionGoto) && !reached[a.Target] {
+				reached[a.Target] = true
+				todo = append(todo, int(a.Target))
+			}
+		}
+	}
+	return reached
+}
+
+// checks that every state which can be uncovered by a reduction has a goto on the reduced non-terminal
+func (v *tableValidator) validateGotos() {
+	t := v.t
+	reached := v.reachableLRStates()
+
+	// the states with a transition to each state by symbol
+	preds := make([]map[SymbolId][]int, len(t.LRStates))
+	for idx := range preds {
+		preds[idx] = make(map[SymbolId][]int)
+	}
+	for idx, state := range t.LRStates {
+		if !reached[idx] {
+			continue
+		}
+		for _, a := range state.Actions {
+			if a.Action == ActionShift || a.Action == ActionGoto {
+				preds[a.Target][a.Symbol] = append(preds[a.Target][a.Symbol], idx)
+			}
+		}
+	}
+
+	for idx, state := range t.LRStates {
+		if !reached[idx] {
+			continue
+		}
+		for _, a := range state.Actions {
+			if a.Action != ActionReduce {
+				continue
+			}
+			rule := t.Rules[a.Target]
+			// walk back along the symbols of the rule to find the states below the handle
+			states := map[int]bool{idx: true}
+			for i := len(rule.Symbols) - 1; i >= 0; i-- {
+				prev := make(map[int]bool)
+				for s := range states {
+					for _, p := range preds[s][rule.Symbols[i]] {
+						prev[p] = true
+					}
+				}
+				states = prev
+			}
+			for s := range states {
+				if v.transition(s, rule.Head) < 0 {
+					v.report.add(IssueMissingGoto, "the LR state %d has no goto for %s after reducing the rule %d in state %d",
+						s, v.symbolName(rule.Head), a.Target, idx)
+				}
+			}
+		}
+	}
+}
+
+func (v *tableValidator) findUnused() {
+	t := v.t
+
+	reachedDFA := make([]bool, len(t.DFAStates))
+	scanned := make(map[SymbolId]bool)
+	todo := []int{int(t.InitialDFAState)}
+	reachedDFA[t.InitialDFAState] = true
+	for len(todo) > 0 {
+		state := t.DFAStates[todo[len(todo)-1]]
+		todo = todo[:len(todo)-1]
+		if state.Accept {
+			scanned[state.AcceptSymbol] = true
+		}
+		for _, e := range state.Edges {
+			if !reachedDFA[e.Target] {
+				reachedDFA[e.Target] = true
+				todo = append(todo, int(e.Target))
+			}
+		}
+	}
+	for idx, reached := range reachedDFA {
+		if !reached {
+			v.report.add(IssueUnreachableDFAState, "the DFA state %d is unreachable", idx)
+		}
+	}
+
+	reduced := make([]bool, len(t.Rules))
+	for idx, reached := range v.reachableLRStates() {
+		if !reached {
+			v.report.add(IssueUnreachableLRState, "the LR state %d is unreachable", idx)
+			continue
+		}
+		for _, a := range t.LRStates[idx].Actions {
+			if a.Action == ActionReduce {
+				reduced[a.Target] = true
+			}
+		}
+	}
+	for idx, r := range reduced {
+		if !r {
+			v.report.add(IssueUnusedRule, "the rule %d is never reduced", idx)
+		}
+	}
+
+	used := map[SymbolId]bool{t.StartSymbol: true}
+	for _, r := range t.Rules {
+		for _, s := range r.Symbols {
+			used[s] = true
+		}
+	}
+	for _, g := range t.Groups {
+		scanned[g.Container] = true
+	}
+	for idx, s := range t.Symbols {
+		id := SymbolId(idx)
+		switch s.Kind {
+		case KindNonTerminal:
+			if !used[id] {
+				v.report.add(IssueUnusedSymbol, "the non-terminal %s is not used in any rule", v.symbolName(id))
+			}
+		case KindTerminal:
+			if !used[id] {
+				v.report.add(IssueUnusedSymbol, "the terminal %s is not used in any rule", v.symbolName(id))
+			}
+			if !scanned[id] {
+				v.report.add(IssueUnusedSymbol, "the terminal %s is never scanned", v.symbolName(id))
+			}
+		}
+	}
+}
+
+// checks that the parser can not reduce forever without a shift. In such a cycle the stack never
+// shrinks below some state, so every cycle is found by running the reductions on each lookahead
+// from each goto, as long as they do not pop the state below the goto. Each cycle is reported once.
+func (v *tableValidator) validateReductions() {
+	t := v.t
+	reported := make(map[[2]int]bool)
+	for base, reached := range v.reachableLRStates() {
+		if !reached {
+			continue
+		}
+		for _, g := range t.LRStates[base].Actions {
+			if g.Action != ActionGoto {
+				continue
+			}
+			for _, la := range t.LRStates[g.Target].Actions {
+				if la.Action != ActionGoto {
+					v.runReductions([]int{base, int(g.Target)}, la.Symbol, reported)
+				}
+			}
+		}
+	}
+}
+
+func (v *tableValidator) runReductions(stack []int, lookahead SymbolId, reported map[[2]int]bool) {
+	var seen []string
+	var tops []int
+	for len(stack) <= len(v.t.LRStates)+1 {
+		key := fmt.Sprint(stack)
+		for i, k := range seen {
+			if k != key {
+				continue
+			}
+			// report the cycle by the smallest state on top of the stack within the cycle
+			first := tops[i]
+			for _, top := range tops[i:] {
+				if top < first {
+					first = top
+				}
+			}
+			if !reported[[2]int{first, int(lookahead)}] {
+				reported[[2]int{first, int(lookahead)}] = true
+				v.report.add(IssueReductionCycle, "the LR state %d reduces in a cycle without a shift on %s",
+					first, v.symbolName(lookahead))
+			}
+			return
+		}
+		seen = append(seen, key)
+		tops = append(tops, stack[len(stack)-1])
+
+		a := v.action(stack[len(stack)-1], lookahead)
+		if a == nil || a.Action != ActionReduce {
+			return
+		}
+		rule := v.t.Rules[a.Target]
+		if len(rule.Symbols) >= len(stack) {
+			// pops the state below the goto, the cycle is found from a lower goto
+			return
+		}
+		stack = append(stack[:len(stack)-len(rule.Symbols):len(stack)-len(rule.Symbols)],
+			v.transition(stack[len(stack)-len(rule.Symbols)-1], rule.Head))
+		if stack[len(stack)-1] < 0 {
+			return
+		}
+	}
+	v.report.add(IssueReductionCycle, "the LR state %d reduces without a shift on %s until the stack overflows",
+		stack[1], v.symbolName(lookahead))
+}
@@ -0,0 +1,124 @@
+package gold_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+// adds the rule <X> ::= <X> and reduces it in the state after the first goto on <X>
+// with a lookahead, so the parser returns to the same state without a shift
+func addReductionCycle(t *gold.Tables) {
+	for _, s := range t.LRStates {
+		for _, g := range s.Actions {
+			if g.Action != gold.ActionGoto {
+				continue
+			}
+			actions := t.LRStates[g.Target].Actions
+			for i := range actions {
+				if actions[i].Action != gold.ActionGoto {
+					t.Rules = append(t.Rules, gold.TableRule{Head: g.Symbol, Symbols: []gold.SymbolId{g.Symbol}})
+					actions[i] = gold.TableLRAction{Symbol: actions[i].Symbol, Action: gold.ActionReduce, Target: uint16(len(t.Rules) - 1)}
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		// breaks the tables, nil for valid tables
+		modify func(t *gold.Tables)
+		kind   gold.IssueKind
+	}{
+		{"valid", nil, 0},
+		{"invalid start symbol", func(t *gold.Tables) { t.Rules[0].Head = gold.SymbolId(len(t.Symbols)) }, gold.IssueInvalidReference},
+		{"invalid DFA edge", func(t *gold.Tables) { t.DFAStates[0].Edges[0].Target = uint16(len(t.DFAStates)) }, gold.IssueInvalidReference},
+		{"missing goto", func(t *gold.Tables) {
+			for i, s := range t.LRStates {
+				for j, a := range s.Actions {
+					if a.Action == gold.ActionGoto {
+						t.LRStates[i].Actions = append(s.Actions[:j:j], s.Actions[j+1:]...)
+						return
+					}
+				}
+			}
+		}, gold.IssueMissingGoto},
+		{"reduction cycle", addReductionCycle, gold.IssueReductionCycle},
+	}
+	for _, tt := range tests {
+		tables := buildTables(t, calcGrammar(t))
+		if tt.modify != nil {
+			tt.modify(tables)
+		}
+		report := tables.Validate()
+		err := report.Err()
+		if tt.modify == nil {
+			if err != nil || len(report.Issues) != 0 {
+				t.Errorf("%s: got the issues %v", tt.name, report.Issues)
+			}
+			continue
+		}
+		var ve *gold.ValidationError
+		if !errors.As(err, &ve) || ve.Report != report {
+			t.Errorf("%s: got %v, want a ValidationError of the report", tt.name, err)
+			continue
+		}
+		found := false
+		for _, issue := range report.Errors() {
+			found = found || issue.Kind == tt.kind
+		}
+		if !found {
+			t.Errorf("%s: got %v, want an issue of kind %d", tt.name, report, tt.kind)
+		}
+		if _, err := gold.NewParserFromTables(tables); !errors.As(err, &ve) {
+			t.Errorf("%s: NewParserFromTables returned %v", tt.name, err)
+		}
+	}
+}
+
+func TestValidateWarnings(t *testing.T) {
+	tables := buildTables(t, calcGrammar(t))
+	// an LR state which no action leads to
+	tables.LRStates = append(tables.LRStates, gold.TableLRState{})
+	report := tables.Validate()
+	if report.Err() != nil || len(report.Errors()) != 0 {
+		t.Fatalf("got the errors %v", report.Errors())
+	}
+	warnings := report.Warnings()
+	if len(warnings) != 1 || warnings[0].Kind != gold.IssueUnreachableLRState {
+		t.Errorf("got the warnings %v, want an unreachable LR state", warnings)
+	}
+	if _, err := gold.NewParserFromTables(tables); err != nil {
+		t.Errorf("NewParserFromTables returned %v", err)
+	}
+}
+
+func TestValidationReportString(t *testing.T) {
+	tables := buildTables(t, calcGrammar(t))
+	if got := tables.Validate().String(); got != "no issues" {
+		t.Errorf("got %q for valid tables", got)
+	}
+
+	// the warnings are marked in the report
+	tables.LRStates = append(tables.LRStates, gold.TableLRState{})
+	report := tables.Validate()
+	if want := "warning: " + report.Warnings()[0].Message; report.String() != want {
+		t.Errorf("got the report\n%s\nwant\n%s", report, want)
+	}
+
+	// the report is no error, but Err returns one with the issues which prevent parsing
+	tables.DFAStates[0].Edges[0].Target = uint16(len(tables.DFAStates))
+	report = tables.Validate()
+	const message = "the DFA state 0 references the unknown DFA state 26"
+	if got := fmt.Sprint(report); got != message {
+		t.Errorf("got the report %q, want %q", got, message)
+	}
+	if err := report.Err(); err == nil || err.Error() != "invalid grammar: "+message {
+		t.Errorf("got the error %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package gold
+
+// tells Walk how to continue after a token was visited
+type WalkAction int
+
+const (
+	// continues with the sub-nodes of the token or with the next token
+	WalkContinue WalkAction = iota
+	// skips the sub-nodes of the token. The token is not left. Returned by Leave it is the same as WalkContinue.
+	WalkSkip
+	// stops the walk
+	WalkStop
+)
+
+// is called by Walk for every token of a syntax tree. path contains the ancestors of the token
+// starting with the root of the tree. The path is reused by Walk and must be copied to keep it
+// after the call.
+type Visitor interface {
+	// is called before the sub-nodes of the token are visited
+	Enter(tok *Token, path []*Token) WalkAction
+	// is called after the sub-nodes of the token were visited
+	Leave(tok *Token, path []*Token) WalkAction
+}
+
+// a Visitor which calls functions. Nil functions return WalkContinue.
+type VisitorFuncs struct {
+	OnEnter func(tok *Token, path []*Token) WalkAction
+	OnLeave func(tok *Token, path []*Token) WalkAction
+}
+
+func (v VisitorFuncs) Enter(tok *Token, path []*Token) WalkAction {
+	if v.OnEnter == nil {
+		return WalkContinue
+	}
+	return v.OnEnter(tok, path)
+}
+
+func (v VisitorFuncs) Leave(tok *Token, path []*Token) WalkAction {
+	if v.OnLeave == nil {
+		return WalkContinue
+	}
+	return v.OnLeave(tok, path)
+}
+
+// walks the syntax tree in depth-first order and calls the visitor for every token.
+// The trivia of the terminals is not visited. Returns false if the walk was stopped.
+func Walk(tree *Token, v Visitor) bool {
+	if tree == nil {
+		return true
+	}
+	return walk(tree, v, make([]*Token, 0, 16))
+}
+
+func walk(tok *Token, v Visitor, path []*Token) bool {
+	switch v.Enter(tok, path) {
+	case WalkStop:
+		return false
+	case WalkSkip:
+		return true
+	}
+	path = append(path, tok)
+	for _, sub := range tok.Tokens {
+		if sub != nil && !walk(sub, v, path) {
+			return false
+		}
+	}
+	return v.Leave(tok, path[:len(path)-1]) != WalkStop
+}
+
+// calls f for every token of the syntax tree in depth-first order. If f returns false, the
+// sub-nodes of the token are skipped.
+func Inspect(tree *Token, f func(tok *Token) bool) {
+	Walk(tree, VisitorFuncs{OnEnter: func(tok *Token, _ []*Token) WalkAction {
+		if f(tok) {
+			return WalkContinue
+		}
+		return WalkSkip
+	}})
+}
+
+// returns the first token of the tree in depth-first order for which match returns true
+// or nil if there is no such token
+func (t *Token) Find(match func(tok *Token) bool) *Token {
+	var result *Token
+	Walk(t, VisitorFuncs{OnEnter: func(tok *Token, _ []*Token) WalkAction {
+		if match(tok) {
+			result = tok
+			return WalkStop
+		}
+		return WalkContinue
+	}})
+	return result
+}
+
+// returns all tokens of the tree in depth-first order for which match returns true
+func (t *Token) FindAll(match func(tok *Token) bool) []*Token {
+	var result []*Token
+	Inspect(t, func(tok *Token) bool {
+		if match(tok) {
+			result = append(result, tok)
+		}
+		return true
+	})
+	return result
+}
+
+// returns the first token of the given symbol or nil
+func (t *Token) FindSymbol(id SymbolId) *Token {
+	return t.Find(isSymbol(id))
+}
+
+// returns all tokens of the given symbol
+func (t *Token) FindAllSymbols(id SymbolId) []*Token {
+	return t.FindAll(isSymbol(id))
+}
+
+// returns the first non-terminal which was reduced by the given rule or nil
+func (t *Token) FindRule(id RuleId) *Token {
+	return t.Find(isRule(id))
+}
+
+// returns all non-terminals which were reduced by the given rule
+func (t *Token) FindAllRules(id RuleId) []*Token {
+	return t.FindAll(isRule(id))
+}
+
+func isSymbol(id SymbolId) func(tok *Token) bool {
+	return func(tok *Token) bool {
+		return tok.Symbol == id
+	}
+}
+
+func isRule(id RuleId) func(tok *Token) bool {
+	return func(tok *Token) bool {
+		// the non-terminals replaced by the error recovery have no rule
+		return !tok.IsTerminal && !tok.IsError && tok.Rule == id
+	}
+}
+
+// returns the terminals of the tree in the order of the source
+func (t *Token) Terminals() []*Token {
+	var result []*Token
+	t.EachTerminal(func(tok *Token) bool {
+		result = append(result, tok)
+		return true
+	})
+	return result
+}
+
+// calls f for the terminals of the tree in the order of the source until f returns false.
+// Returns false if f returned false.
+func (t *Token) EachTerminal(f func(tok *Token) bool) bool {
+	return Walk(t, VisitorFuncs{OnEnter: func(tok *Token, _ []*Token) WalkAction {
+		if tok.IsTerminal && !f(tok) {
+			return WalkStop
+		}
+		return WalkContinue
+	}})
+}
@@ -0,0 +1,178 @@
+package gold_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+// parses the input with the calculator grammar and trimmed reductions
+func parseCalc(t *testing.T, input string) (*gold.Token, gold.Grammar) {
+	t.Helper()
+	p := grammarFormats(t)["egt"]
+	tree, err := p.ParseContext(context.Background(), strings.NewReader(input), gold.TrimReductions(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, p.Grammar()
+}
+
+// returns the text of a terminal or the name of a non-terminal
+func tokenName(tok *gold.Token) string {
+	if tok.IsTerminal {
+		return tok.Text
+	}
+	return tok.Name
+}
+
+// records the calls of the visitor as "enter name depth" and "leave name depth"
+type recordingVisitor struct {
+	calls []string
+	// returns the action for a call
+	action func(call string) gold.WalkAction
+}
+
+func (v *recordingVisitor) record(kind string, tok *gold.Token, path []*gold.Token) gold.WalkAction {
+	call := fmt.Sprintf("%s %s %d", kind, tokenName(tok), len(path))
+	v.calls = append(v.calls, call)
+	if v.action != nil {
+		return v.action(call)
+	}
+	return gold.WalkContinue
+}
+
+func (v *recordingVisitor) Enter(tok *gold.Token, path []*gold.Token) gold.WalkAction {
+	return v.record("enter", tok, path)
+}
+
+func (v *recordingVisitor) Leave(tok *gold.Token, path []*gold.Token) gold.WalkAction {
+	return v.record("leave", tok, path)
+}
+
+func TestWalk(t *testing.T) {
+	tree, _ := parseCalc(t, "x = 1; print x;")
+	all := []string{
+		"enter <Stmts> 0",
+		"enter <Stmt> 1", "enter x 2", "leave x 2", "enter = 2", "leave = 2",
+		"enter <Factor> 2", "enter 1 3", "leave 1 3", "leave <Factor> 2", "enter ; 2", "leave ; 2", "leave <Stmt> 1",
+		"enter <Stmts> 1",
+		"enter <Stmt> 2", "enter print 3", "leave print 3",
+		"enter <Factor> 3", "enter x 4", "leave x 4", "leave <Factor> 3", "enter ; 3", "leave ; 3", "leave <Stmt> 2",
+		"enter <Stmts> 2", "leave <Stmts> 2",
+		"leave <Stmts> 1",
+		"leave <Stmts> 0",
+	}
+	tests := []struct {
+		name   string
+		action map[string]gold.WalkAction
+		result bool
+		calls  []string
+	}{
+		{"continue", nil, true, all},
+		// the sub-nodes are skipped and the token is not left
+		{"skip", map[string]gold.WalkAction{"enter <Stmt> 1": gold.WalkSkip}, true,
+			append([]string{"enter <Stmts> 0", "enter <Stmt> 1"}, all[13:]...)},
+		{"skip terminal", map[string]gold.WalkAction{"enter x 2": gold.WalkSkip}, true,
+			append(all[:3:3], all[4:]...)},
+		{"skip when leaving", map[string]gold.WalkAction{"leave <Stmt> 1": gold.WalkSkip}, true, all},
+		{"stop", map[string]gold.WalkAction{"enter <Factor> 3": gold.WalkStop}, false, all[:18]},
+		{"stop when leaving", map[string]gold.WalkAction{"leave <Stmt> 1": gold.WalkStop}, false, all[:13]},
+	}
+	for _, tt := range tests {
+		v := &recordingVisitor{action: func(call string) gold.WalkAction { return tt.action[call] }}
+		if result := gold.Walk(tree, v); result != tt.result {
+			t.Errorf("%s: Walk returned %v", tt.name, result)
+		}
+		if !reflect.DeepEqual(v.calls, tt.calls) {
+			t.Errorf("%s: got the calls\n%s\nwant\n%s", tt.name, strings.Join(v.calls, "\n"), strings.Join(tt.calls, "\n"))
+		}
+	}
+
+	if !gold.Walk(nil, new(recordingVisitor)) {
+		t.Error("the walk of an empty tree was stopped")
+	}
+}
+
+func TestWalkPath(t *testing.T) {
+	tree, _ := parseCalc(t, "x = 1; print x;")
+	var paths []string
+	gold.Walk(tree, gold.VisitorFuncs{OnLeave: func(tok *gold.Token, path []*gold.Token) gold.WalkAction {
+		if tok.IsTerminal && tok.Text == "x" {
+			var names []string
+			for _, p := range path {
+				names = append(names, tokenName(p))
+			}
+			paths = append(paths, strings.Join(names, " "))
+		}
+		return gold.WalkContinue
+	}})
+	if want := []string{"<Stmts> <Stmt>", "<Stmts> <Stmts> <Stmt> <Factor>"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got the paths %q, want %q", paths, want)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tree, _ := parseCalc(t, "x = 1; print x;")
+	var names []string
+	gold.Inspect(tree, func(tok *gold.Token) bool {
+		names = append(names, tokenName(tok))
+		return tok.Name != "<Stmt>"
+	})
+	if got := strings.Join(names, " "); got != "<Stmts> <Stmt> <Stmts> <Stmt> <Stmts>" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestFind(t *testing.T) {
+	tree, g := parseCalc(t, "x = 1; print x - 2;")
+	factor := g.SymbolByName("<Factor>").Id
+	if tok := tree.FindSymbol(factor); tok == nil || tok.Tokens[0].Text != "1" {
+		t.Errorf("FindSymbol returned %v", tok)
+	}
+	if toks := tree.FindAllSymbols(factor); len(toks) != 3 {
+		t.Errorf("FindAllSymbols returned %d tokens", len(toks))
+	}
+	if tok := tree.FindSymbol(g.SymbolByName("(").Id); tok != nil {
+		t.Errorf("FindSymbol returned %v for a missing symbol", tok)
+	}
+
+	var print gold.RuleId
+	for _, r := range g.Rules() {
+		if r.String() == "<Stmt> ::= print <Expr> ;" {
+			print = r.Id
+		}
+	}
+	if tok := tree.FindRule(print); tok == nil || tok.Tokens[0].Text != "print" {
+		t.Errorf("FindRule returned %v", tok)
+	}
+	if toks := tree.FindAllRules(print); len(toks) != 1 {
+		t.Errorf("FindAllRules returned %d tokens", len(toks))
+	}
+	if toks := tree.FindAll(func(tok *gold.Token) bool { return tok.Text == "x" }); len(toks) != 2 || toks[0].Start.Column != 1 {
+		t.Errorf("FindAll returned %v", toks)
+	}
+}
+
+func TestTerminals(t *testing.T) {
+	tree, _ := parseCalc(t, "x = 1; print x - 2;")
+	var texts []string
+	for _, tok := range tree.Terminals() {
+		texts = append(texts, tok.Text)
+	}
+	if got := strings.Join(texts, " "); got != "x = 1 ; print x - 2 ;" {
+		t.Errorf("got the terminals %s", got)
+	}
+
+	texts = nil
+	result := tree.EachTerminal(func(tok *gold.Token) bool {
+		texts = append(texts, tok.Text)
+		return tok.Text != "print"
+	})
+	if got := strings.Join(texts, " "); result || got != "x = 1 ; print" {
+		t.Errorf("EachTerminal returned %v after %s", result, got)
+	}
+}
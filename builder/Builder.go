@@ -0,0 +1,664 @@
+// Package builder compiles grammars written in the GOLD Meta-Language into the tables used by
+// the gold parser engine, without the need of the GOLD Builder.
+package builder
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/gold"
+)
+
+// represents a problem in the grammar source
+type Error struct {
+	// the line of the grammar source, 0 if the problem is not related to a line
+	Line    int
+	Message string
+}
+
+// returns the error message with the line number
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return e.Message
+}
+
+// contains all problems found in a grammar source
+type Errors []*Error
+
+// returns the error messages of all errors, one per line
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// the result of a successful build
+type Result struct {
+	// the tables which can be used with gold.NewParserFromTables
+	Tables *gold.Tables
+	// describes problems which did not prevent the build, like resolved shift-reduce conflicts
+	Warnings []string
+}
+
+// Builds the grammar tables from the source of a .grm file
+func Build(src io.Reader) (*Result, error) {
+	source, err := parseSource(src)
+	if err != nil {
+		return nil, err
+	}
+	return newGrammarBuilder(source).build()
+}
+
+// Builds the grammar from the source of a .grm file and creates a parser for it
+func NewParser(src io.Reader) (gold.Parser, error) {
+	result, err := Build(src)
+	if err != nil {
+		return nil, err
+	}
+	return gold.NewParserFromTables(result.Tables)
+}
+
+// a symbol while it is built
+type symbolDef struct {
+	name  string
+	kind  gold.SymbolKind
+	regex regexNode
+	// tells if the symbol is the container of a group
+	container bool
+	// tells if the symbol is used in the rules
+	used bool
+	id   gold.SymbolId
+	line int
+}
+
+type groupDef struct {
+	name string
+	// the name of the container symbol
+	prefix    string
+	container *symbolDef
+	start     *symbolDef
+	end       *symbolDef
+	isLine    bool
+	attrs     map[string][]string
+	line      int
+	id        gold.GroupId
+}
+
+type grammarBuilder struct {
+	src *grammarSource
+
+	caseSensitive  bool
+	autoWhitespace bool
+	properties     []gold.TableProperty
+	startSymbol    string
+	startLine      int
+
+	sets      map[string]*setDecl
+	setValues map[string]runeSet
+	resolving map[string]bool
+
+	// terminals by name, literal terminals by their text
+	terminals    map[string]*symbolDef
+	literals     map[string]*symbolDef
+	nonTerminals map[string]*symbolDef
+	symbols      []*symbolDef
+	groups       []*groupDef
+	attributes   map[string]*attributeDecl
+
+	errs     Errors
+	warnings []string
+}
+
+func newGrammarBuilder(src *grammarSource) *grammarBuilder {
+	return &grammarBuilder{
+		src:            src,
+		autoWhitespace: true,
+		sets:           make(map[string]*setDecl),
+		setValues:      make(map[string]runeSet),
+		resolving:      make(map[string]bool),
+		terminals:      make(map[string]*symbolDef),
+		literals:       make(map[string]*symbolDef),
+		nonTerminals:   make(map[string]*symbolDef),
+		attributes:     make(map[string]*attributeDecl),
+	}
+}
+
+func (b *grammarBuilder) errorf(line int, format string, args ...interface{}) {
+	b.errs = append(b.errs, &Error{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func isTrue(value string) bool {
+	return strings.EqualFold(value, "true")
+}
+
+func (b *grammarBuilder) readParameters() {
+	values := make(map[string]string)
+	for _, p := range b.src.parameters {
+		value := strings.Join(p.lines, "\n")
+		switch strings.ToLower(p.name) {
+		case "case sensitive":
+			b.caseSensitive = isTrue(value)
+		case "auto whitespace":
+			b.autoWhitespace = isTrue(value)
+		case "start symbol":
+			if len(p.nonTerminals) != 1 {
+				b.errorf(p.line, "the start symbol must be a single non-terminal")
+				continue
+			}
+			b.startSymbol = p.nonTerminals[0]
+			b.startLine = p.line
+		case "virtual terminals":
+			for _, name := range strings.Fields(value) {
+				b.addSymbol(&symbolDef{name: name, kind: gold.KindTerminal, line: p.line})
+			}
+		}
+		values[p.name] = value
+	}
+
+	for _, name := range []string{"Name", "Version", "Author", "About"} {
+		b.properties = append(b.properties, gold.TableProperty{Name: name, Value: values[name]})
+	}
+	b.properties = append(b.properties,
+		gold.TableProperty{Name: "Character Set", Value: "Unicode"},
+		gold.TableProperty{Name: "Character Mapping", Value: "None"},
+		gold.TableProperty{Name: "Generated By", Value: "github.com/boombuler/gold/builder"},
+	)
+}
+
+// parses character codes like #65, &41 and ranges like &41 .. &5A
+func parseCharCodes(text string) (runeSet, bool) {
+	parseCode := func(code string) (rune, bool) {
+		code = strings.TrimSpace(code)
+		if len(code) < 2 {
+			return 0, false
+		}
+		base := 10
+		switch code[0] {
+		case '#':
+		case '&':
+			base = 16
+		default:
+			return 0, false
+		}
+		v, err := strconv.ParseUint(code[1:], base, 32)
+		return rune(v), err == nil
+	}
+
+	parts := strings.Split(text, "..")
+	switch len(parts) {
+	case 1:
+		if r, ok := parseCode(parts[0]); ok {
+			return runeSetOf(r), true
+		}
+	case 2:
+		lo, ok1 := parseCode(parts[0])
+		hi, ok2 := parseCode(parts[1])
+		if ok1 && ok2 {
+			return newRuneSet(runeRange{lo, hi}), true
+		}
+	}
+	return nil, false
+}
+
+// returns the characters of a set literal or a named set
+func (b *grammarBuilder) resolveSet(item setItem) (runeSet, error) {
+	if item.literal {
+		return runeSetOf([]rune(item.text)...), nil
+	}
+	if set, ok := parseCharCodes(item.text); ok {
+		return set, nil
+	}
+
+	key := strings.ToLower(item.text)
+	if set, ok := b.setValues[key]; ok {
+		return set, nil
+	}
+	if decl, ok := b.sets[key]; ok {
+		if b.resolving[key] {
+			return nil, &Error{Line: decl.line, Message: fmt.Sprintf("the set {%s} is defined recursively", decl.name)}
+		}
+		b.resolving[key] = true
+		defer delete(b.resolving, key)
+
+		var result runeSet
+		for idx, it := range decl.expr.items {
+			set, err := b.resolveSet(it)
+			if err != nil {
+				return nil, err
+			}
+			if idx == 0 {
+				result = set
+			} else if decl.expr.ops[idx-1] == '+' {
+				result = result.union(set)
+			} else {
+				result = result.subtract(set)
+			}
+		}
+		b.setValues[key] = result
+		return result, nil
+	}
+	for name, set := range predefinedSets {
+		if strings.EqualFold(name, item.text) {
+			return set, nil
+		}
+	}
+	return nil, &Error{Line: item.line, Message: fmt.Sprintf("unknown set {%s}", item.text)}
+}
+
+func (b *grammarBuilder) addSymbol(s *symbolDef) {
+	key := strings.ToLower(s.name)
+	if existing, ok := b.terminals[key]; ok {
+		b.errorf(s.line, "the terminal %s is already defined in line %d", s.name, existing.line)
+		return
+	}
+	b.terminals[key] = s
+	b.symbols = append(b.symbols, s)
+}
+
+// returns the value of the attribute of the declaration with the given name
+func (b *grammarBuilder) attribute(name, attr string) []string {
+	if decl, ok := b.attributes[strings.ToLower(name)]; ok {
+		for key, values := range decl.attrs {
+			if strings.EqualFold(key, attr) {
+				return values
+			}
+		}
+	}
+	return nil
+}
+
+// returns the symbol kind for the value of a Type attribute
+func kindOfType(value string, kind gold.SymbolKind) gold.SymbolKind {
+	switch strings.ToLower(value) {
+	case "noise":
+		return gold.KindNoise
+	case "content":
+		return gold.KindTerminal
+	}
+	return kind
+}
+
+func (b *grammarBuilder) readTerminals() {
+	for _, a := range b.src.attributes {
+		b.attributes[strings.ToLower(a.name)] = a
+	}
+
+	groups := make(map[string]*groupDef)
+	// a prefix can have a line group and a block group, like "Comment Line" and "Comment Start"
+	groupOf := func(prefix string, isLine bool, line int) *groupDef {
+		name := prefix + " Block"
+		if isLine {
+			name = prefix + " Line"
+		}
+		key := strings.ToLower(name)
+		if g, ok := groups[key]; ok {
+			return g
+		}
+		g := &groupDef{name: name, prefix: prefix, isLine: isLine, line: line}
+		groups[key] = g
+		b.groups = append(b.groups, g)
+		return g
+	}
+
+	for _, t := range b.src.terminals {
+		words := strings.Fields(t.name)
+		suffix := ""
+		if len(words) > 1 {
+			suffix = strings.ToLower(words[len(words)-1])
+		}
+
+		switch suffix {
+		case "start", "line":
+			g := groupOf(strings.Join(words[:len(words)-1], " "), suffix == "line", t.line)
+			if g.start != nil {
+				b.errorf(t.line, "the start of the group %s is already defined", g.name)
+				continue
+			}
+			g.start = &symbolDef{name: t.name, kind: gold.KindGroupStart, regex: t.regex, line: t.line}
+			b.addSymbol(g.start)
+		case "end":
+			g := groupOf(strings.Join(words[:len(words)-1], " "), false, t.line)
+			if g.end != nil {
+				b.errorf(t.line, "the end of the group %s is already defined", g.name)
+				continue
+			}
+			g.end = &symbolDef{name: t.name, kind: gold.KindGroupEnd, regex: t.regex, line: t.line}
+			b.addSymbol(g.end)
+		default:
+			kind := gold.KindTerminal
+			if strings.EqualFold(t.name, "Whitespace") || strings.EqualFold(t.name, "Comment") {
+				kind = gold.KindNoise
+			}
+			b.addSymbol(&symbolDef{name: t.name, kind: kind, regex: t.regex, line: t.line})
+		}
+	}
+
+	for _, g := range b.groups {
+		if g.start == nil {
+			b.errorf(g.line, "the group %s has no start", g.name)
+			continue
+		}
+		if g.isLine {
+			g.end = b.terminals["newline"]
+			if g.end == nil {
+				g.end = &symbolDef{
+					name: "NewLine",
+					kind: gold.KindNoise,
+					regex: regexAlternatives{
+						regexSequence{regexSet{text: "CR"}, regexSet{text: "LF"}},
+						regexSet{text: "CR"},
+						regexSet{text: "LF"},
+					},
+					line: g.line,
+				}
+				b.addSymbol(g.end)
+			}
+		} else if g.end == nil {
+			b.errorf(g.line, "the group %s has no end", g.name)
+			continue
+		}
+
+		g.attrs = make(map[string][]string)
+		for _, name := range []string{g.prefix, g.name} {
+			if decl, ok := b.attributes[strings.ToLower(name)]; ok {
+				for k, v := range decl.attrs {
+					g.attrs[strings.ToLower(k)] = v
+				}
+			}
+		}
+
+		g.container = b.terminals[strings.ToLower(g.prefix)]
+		if g.container == nil {
+			g.container = &symbolDef{name: g.prefix, kind: gold.KindTerminal, line: g.line}
+			if strings.EqualFold(g.prefix, "Comment") {
+				g.container.kind = gold.KindNoise
+			}
+			b.addSymbol(g.container)
+		}
+		g.container.container = true
+		if types := g.attrs["type"]; len(types) == 1 {
+			g.container.kind = kindOfType(types[0], g.container.kind)
+		}
+	}
+
+	// noise and content types of terminals
+	for _, s := range b.symbols {
+		if s.kind != gold.KindTerminal && s.kind != gold.KindNoise {
+			continue
+		}
+		if types := b.attribute(s.name, "Type"); len(types) == 1 {
+			s.kind = kindOfType(types[0], s.kind)
+		}
+	}
+
+	if b.autoWhitespace && b.terminals["whitespace"] == nil {
+		var set regexNode = regexSet{text: "Whitespace"}
+		if b.terminals["newline"] != nil {
+			b.sets["\x00whitespace without newlines"] = &setDecl{
+				name: "Whitespace",
+				expr: setExpression{
+					items: []setItem{{text: "Whitespace"}, {text: "CR"}, {text: "LF"}},
+					ops:   []byte{'-', '-'},
+				},
+			}
+			set = regexSet{text: "\x00Whitespace without newlines"}
+		}
+		b.addSymbol(&symbolDef{name: "Whitespace", kind: gold.KindNoise, regex: regexRepeat{node: set, kind: '+'}})
+	}
+}
+
+func (b *grammarBuilder) readRules() []*ruleDecl {
+	for _, r := range b.src.rules {
+		key := strings.ToLower(r.head)
+		if _, ok := b.nonTerminals[key]; !ok {
+			b.nonTerminals[key] = &symbolDef{name: r.head, kind: gold.KindNonTerminal, line: r.line}
+		}
+	}
+
+	for _, r := range b.src.rules {
+		for _, handle := range r.handles {
+			for _, s := range handle {
+				if s.nonTerminal {
+					if nt, ok := b.nonTerminals[strings.ToLower(s.name)]; ok {
+						nt.used = true
+					} else {
+						b.errorf(r.line, "the non-terminal <%s> is not defined", s.name)
+					}
+					continue
+				}
+				b.terminalOf(s.name, r.line).used = true
+			}
+		}
+	}
+	return b.src.rules
+}
+
+// returns the terminal with the given name or the literal terminal for the text
+func (b *grammarBuilder) terminalOf(name string, line int) *symbolDef {
+	if t, ok := b.terminals[strings.ToLower(name)]; ok {
+		return t
+	}
+	key := name
+	if !b.caseSensitive {
+		key = strings.ToLower(name)
+	}
+	if t, ok := b.literals[key]; ok {
+		return t
+	}
+	t := &symbolDef{name: name, kind: gold.KindTerminal, regex: regexLiteral(name), line: line}
+	b.literals[key] = t
+	b.symbols = append(b.symbols, t)
+	return t
+}
+
+type symbolsByName []*symbolDef
+
+func (s symbolsByName) Len() int      { return len(s) }
+func (s symbolsByName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s symbolsByName) Less(i, j int) bool {
+	a, b := strings.ToLower(s[i].name), strings.ToLower(s[j].name)
+	if a != b {
+		return a < b
+	}
+	return s[i].name < s[j].name
+}
+
+func (b *grammarBuilder) build() (*Result, error) {
+	for _, s := range b.src.sets {
+		key := strings.ToLower(s.name)
+		if existing, ok := b.sets[key]; ok {
+			b.errorf(s.line, "the set {%s} is already defined in line %d", s.name, existing.line)
+			continue
+		}
+		b.sets[key] = s
+	}
+	b.readParameters()
+	b.readTerminals()
+	rules := b.readRules()
+
+	if len(rules) == 0 {
+		b.errorf(0, "the grammar has no rules")
+	}
+	if len(b.errs) > 0 {
+		return nil, b.errs
+	}
+
+	start := b.nonTerminals[strings.ToLower(b.startSymbol)]
+	if b.startSymbol == "" {
+		start = b.nonTerminals[strings.ToLower(rules[0].head)]
+	} else if start == nil {
+		b.errorf(b.startLine, "the start symbol <%s> is not defined", b.startSymbol)
+		return nil, b.errs
+	}
+
+	// symbols used in the rules are always terminals
+	for _, s := range b.symbols {
+		if s.used && s.kind == gold.KindNoise {
+			s.kind = gold.KindTerminal
+		}
+		if !s.used && s.kind == gold.KindTerminal && !s.container {
+			b.warnings = append(b.warnings, fmt.Sprintf("the terminal %s is not used in any rule", s.name))
+		}
+	}
+
+	// the symbol table starts with the end and error symbols, followed by the terminals and the non-terminals
+	terminals := append(symbolsByName(nil), b.symbols...)
+	sort.Stable(terminals)
+	nonTerminals := make(symbolsByName, 0, len(b.nonTerminals))
+	for _, nt := range b.nonTerminals {
+		nonTerminals = append(nonTerminals, nt)
+		if !nt.used && nt != start {
+			b.warnings = append(b.warnings, fmt.Sprintf("the non-terminal <%s> is not used in any rule", nt.name))
+		}
+	}
+	sort.Sort(nonTerminals)
+
+	all := []*symbolDef{{name: "EOF", kind: gold.KindEnd}, {name: "Error", kind: gold.KindError}}
+	all = append(all, terminals...)
+	all = append(all, nonTerminals...)
+
+	tables := &gold.Tables{
+		Properties:    b.properties,
+		CaseSensitive: b.caseSensitive,
+		StartSymbol:   0,
+		Symbols:       make([]gold.TableSymbol, len(all)),
+	}
+	for idx, s := range all {
+		s.id = gold.SymbolId(idx)
+		tables.Symbols[idx] = gold.TableSymbol{Name: s.name, Kind: s.kind}
+	}
+	tables.StartSymbol = start.id
+
+	b.buildGroups(tables)
+	if len(b.errs) > 0 {
+		return nil, b.errs
+	}
+
+	for _, r := range rules {
+		head := b.nonTerminals[strings.ToLower(r.head)]
+		for _, handle := range r.handles {
+			rule := gold.TableRule{Head: head.id, Symbols: make([]gold.SymbolId, len(handle))}
+			for i, s := range handle {
+				if s.nonTerminal {
+					rule.Symbols[i] = b.nonTerminals[strings.ToLower(s.name)].id
+				} else {
+					rule.Symbols[i] = b.terminalOf(s.name, r.line).id
+				}
+			}
+			tables.Rules = append(tables.Rules, rule)
+		}
+	}
+
+	if err := b.buildDFA(tables, terminals); err != nil {
+		return nil, err
+	}
+
+	lalr := newLALRBuilder(tables.Symbols, tables.Rules, tables.StartSymbol)
+	lrStates, err := lalr.build()
+	if err != nil {
+		return nil, err
+	}
+	tables.LRStates = lrStates
+	b.warnings = append(b.warnings, lalr.warnings...)
+
+	return &Result{Tables: tables, Warnings: b.warnings}, nil
+}
+
+func (b *grammarBuilder) buildGroups(tables *gold.Tables) {
+	for idx, g := range b.groups {
+		g.id = gold.GroupId(idx)
+	}
+	for _, g := range b.groups {
+		tg := gold.TableGroup{
+			Name:        g.name,
+			Container:   g.container.id,
+			Start:       g.start.id,
+			End:         g.end.id,
+			AdvanceMode: gold.AdvanceCharacter,
+			EndingMode:  gold.EndingClosed,
+		}
+		if g.isLine {
+			tg.EndingMode = gold.EndingOpen
+		}
+		if v := g.attrs["advance"]; len(v) == 1 && strings.EqualFold(v[0], "token") {
+			tg.AdvanceMode = gold.AdvanceToken
+		}
+		if v := g.attrs["ending"]; len(v) == 1 {
+			if strings.EqualFold(v[0], "open") {
+				tg.EndingMode = gold.EndingOpen
+			} else if strings.EqualFold(v[0], "closed") {
+				tg.EndingMode = gold.EndingClosed
+			}
+		}
+		for _, n := range g.attrs["nesting"] {
+			switch strings.ToLower(n) {
+			case "none":
+			case "all":
+				for _, other := range b.groups {
+					tg.Nesting = append(tg.Nesting, other.id)
+				}
+			case "self":
+				tg.Nesting = append(tg.Nesting, g.id)
+			default:
+				found := false
+				for _, other := range b.groups {
+					if strings.EqualFold(other.name, n) {
+						tg.Nesting = append(tg.Nesting, other.id)
+						found = true
+					}
+				}
+				if !found {
+					b.errorf(g.line, "unknown group %s nested in group %s", n, g.name)
+				}
+			}
+		}
+		tables.Groups = append(tables.Groups, tg)
+	}
+}
+
+func (b *grammarBuilder) buildDFA(tables *gold.Tables, symbols []*symbolDef) error {
+	var lexical []*lexicalTerminal
+	var ids []gold.SymbolId
+	for _, s := range symbols {
+		if s.regex == nil {
+			continue
+		}
+		lexical = append(lexical, &lexicalTerminal{name: s.name, regex: s.regex, priority: regexPriority(s.regex)})
+		ids = append(ids, s.id)
+	}
+
+	states, err := buildDFA(lexical, b.resolveSet, b.caseSensitive)
+	if err != nil {
+		return err
+	}
+
+	charSets := make(map[string]uint16)
+	tables.DFAStates = make([]gold.TableDFAState, len(states))
+	for idx, state := range states {
+		ts := &tables.DFAStates[idx]
+		if state.accept >= 0 {
+			ts.Accept = true
+			ts.AcceptSymbol = ids[state.accept]
+		}
+		for _, e := range state.edges {
+			for _, cs := range e.set.toTables() {
+				key := fmt.Sprintf("%d:%v", cs.Plane, cs.Ranges)
+				csIdx, ok := charSets[key]
+				if !ok {
+					csIdx = uint16(len(tables.CharSets))
+					charSets[key] = csIdx
+					tables.CharSets = append(tables.CharSets, cs)
+				}
+				ts.Edges = append(ts.Edges, gold.TableDFAEdge{CharSet: csIdx, Target: uint16(e.target)})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+// returns the source of the calculator grammar of the tests of the gold package
+func calcGrammar(t *testing.T) string {
+	t.Helper()
+	src, err := os.ReadFile("../testdata/calc.grm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
+
+func TestBuildParses(t *testing.T) {
+	calcGrammar := calcGrammar(t)
+	tests := []struct {
+		name    string
+		grammar string
+		valid   []string
+		invalid []string
+		warning string
+	}{
+		{
+			name:    "calculator",
+			grammar: calcGrammar,
+			valid:   []string{"", "x = 1;", "x = 1 + 2 * 3; // c\n/* b */ print (x - 4.5) / -2 ;\n"},
+			invalid: []string{"x = ;", "print 1", "x = (1;"},
+		},
+		{
+			name: "nested comments",
+			grammar: calcGrammar + `
+Comment @= { Nesting = All }
+`,
+			valid: []string{"x = 1; /* a /* b */ c */"},
+		},
+		{
+			name: "case insensitive keywords",
+			grammar: `
+"Case Sensitive" = False
+"Start Symbol" = <S>
+<S> ::= begin end
+`,
+			valid:   []string{"BEGIN End", "begin end"},
+			invalid: []string{"begin", "end begin"},
+		},
+		{
+			name: "shift-reduce conflict",
+			grammar: `
+"Start Symbol" = <S>
+Id = {Letter}+
+<S> ::= if Id then <S> | if Id then <S> else <S> | Id
+`,
+			valid:   []string{"if a then if b then c else d"},
+			invalid: []string{"if a then"},
+			warning: "shift-reduce conflict",
+		},
+		{
+			name: "unused terminal",
+			grammar: `
+"Start Symbol" = <S>
+Unused = 'x'
+<S> ::= a
+`,
+			valid:   []string{"a"},
+			warning: "the terminal Unused is not used",
+		},
+	}
+	for _, tt := range tests {
+		result, err := Build(strings.NewReader(tt.grammar))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.warning != "" && !strings.Contains(strings.Join(result.Warnings, "\n"), tt.warning) {
+			t.Errorf("%s: missing warning %q in %q", tt.name, tt.warning, result.Warnings)
+		}
+		p, err := gold.NewParserFromTables(result.Tables)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, input := range tt.valid {
+			if _, err := p.ParseContext(context.Background(), strings.NewReader(input)); err != nil {
+				t.Errorf("%s: %q: %v", tt.name, input, err)
+			}
+		}
+		for _, input := range tt.invalid {
+			var pe *gold.ParseError
+			if _, err := p.ParseContext(context.Background(), strings.NewReader(input)); !errors.As(err, &pe) {
+				t.Errorf("%s: %q: got %v, want a ParseError", tt.name, input, err)
+			}
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar string
+		message string
+	}{
+		{"no rules", `"Name" = 'x'`, "the grammar has no rules"},
+		{"unknown start symbol", "\"Start Symbol\" = <X>\n<S> ::= a\n", "the start symbol <X> is not defined"},
+		{"undefined non-terminal", "<S> ::= <T>\n", "the non-terminal <T> is not defined"},
+		{"duplicate terminal", "A = 'a'\nA = 'b'\n<S> ::= A\n", "the terminal A is already defined"},
+		{"duplicate set", "{A} = [a]\n{A} = [b]\nX = {A}\n<S> ::= X\n", "the set {A} is already defined"},
+		{"unknown set", "X = {Unknown}+\n<S> ::= X\n", "unknown set {Unknown}"},
+		{"recursive set", "{A} = {B}\n{B} = {A}\nX = {A}\n<S> ::= X\n", "defined recursively"},
+		{"lexical conflict", "A = 'a'\nB = 'a'\n<S> ::= A B\n", "lexical conflict"},
+		{"reduce-reduce conflict", "<S> ::= <A> | <B>\n<A> ::= x\n<B> ::= x\n", "reduce-reduce conflict"},
+		{"syntax error", "<S> = a\n", "expected ::="},
+		{"unterminated literal", "<S> ::= 'a\n", "unterminated"},
+		{"unknown nested group", "Comment Start = '/*'\nComment End = '*/'\nComment @= { Nesting = Strings }\n<S> ::= a\n",
+			"line 1: unknown group Strings nested in group Comment Block"},
+	}
+	for _, tt := range tests {
+		result, err := Build(strings.NewReader(tt.grammar))
+		if err == nil {
+			t.Errorf("%s: built %d symbols without error", tt.name, len(result.Tables.Symbols))
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: got %q, want %q", tt.name, err, tt.message)
+		}
+		var e *Error
+		if !errors.As(err, &e) {
+			var errs Errors
+			if !errors.As(err, &errs) || len(errs) == 0 {
+				t.Errorf("%s: got %T, want *Error or Errors", tt.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode"
+
+	"github.com/boombuler/gold"
+)
+
+// a range of runes, including lo and hi
+type runeRange struct {
+	lo rune
+	hi rune
+}
+
+// a set of runes, stored as sorted and non overlapping ranges
+type runeSet []runeRange
+
+func newRuneSet(ranges ...runeRange) runeSet {
+	result := make(runeSet, 0, len(ranges))
+	for _, r := range ranges {
+		if r.lo <= r.hi {
+			result = append(result, r)
+		}
+	}
+	return result.normalize()
+}
+
+func runeSetOf(runes ...rune) runeSet {
+	ranges := make([]runeRange, len(runes))
+	for i, r := range runes {
+		ranges[i] = runeRange{r, r}
+	}
+	return newRuneSet(ranges...)
+}
+
+func (s runeSet) Len() int           { return len(s) }
+func (s runeSet) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s runeSet) Less(i, j int) bool { return s[i].lo < s[j].lo }
+
+// sorts the ranges and merges overlapping or adjacent ranges
+func (s runeSet) normalize() runeSet {
+	if len(s) == 0 {
+		return s
+	}
+	sort.Sort(s)
+	result := runeSet{s[0]}
+	for _, r := range s[1:] {
+		last := &result[len(result)-1]
+		if r.lo <= last.hi+1 {
+			if r.hi > last.hi {
+				last.hi = r.hi
+			}
+		} else {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func (s runeSet) union(o runeSet) runeSet {
+	result := make(runeSet, 0, len(s)+len(o))
+	result = append(result, s...)
+	result = append(result, o...)
+	return result.normalize()
+}
+
+func (s runeSet) subtract(o runeSet) runeSet {
+	result := make(runeSet, 0, len(s))
+	for _, r := range s {
+		lo := r.lo
+		for _, x := range o {
+			if x.hi < lo || x.lo > r.hi {
+				continue
+			}
+			if x.lo > lo {
+				result = append(result, runeRange{lo, x.lo - 1})
+			}
+			lo = x.hi + 1
+			if lo > r.hi {
+				break
+			}
+		}
+		if lo <= r.hi {
+			result = append(result, runeRange{lo, r.hi})
+		}
+	}
+	return result
+}
+
+func (s runeSet) contains(r rune) bool {
+	idx := sort.Search(len(s), func(i int) bool { return s[i].hi >= r })
+	return idx < len(s) && s[idx].lo <= r
+}
+
+func (s runeSet) isEmpty() bool {
+	return len(s) == 0
+}
+
+// returns a set which also contains the other cases of all letters of the set
+func (s runeSet) foldCase() runeSet {
+	var added []runeRange
+	for _, rr := range s {
+		for r := rr.lo; r <= rr.hi; r++ {
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				added = append(added, runeRange{f, f})
+			}
+		}
+	}
+	return s.union(added)
+}
+
+// returns a string which identifies the set
+func (s runeSet) key() string {
+	buf := new(bytes.Buffer)
+	for _, r := range s {
+		fmt.Fprintf(buf, "%x-%x,", r.lo, r.hi)
+	}
+	return string(buf.Bytes())
+}
+
+// splits the set into the character sets of the grammar tables, one for each unicode plane.
+func (s runeSet) toTables() []gold.TableCharSet {
+	var result []gold.TableCharSet
+	for _, r := range s {
+		for lo := r.lo; lo <= r.hi; {
+			plane := uint16(lo >> 16)
+			hi := r.hi
+			if planeEnd := rune(plane)<<16 | 0xFFFF; hi > planeEnd {
+				hi = planeEnd
+			}
+			if len(result) == 0 || result[len(result)-1].Plane != plane {
+				result = append(result, gold.TableCharSet{Plane: plane})
+			}
+			cs := &result[len(result)-1]
+			cs.Ranges = append(cs.Ranges, gold.TableCharRange{Start: uint16(lo & 0xFFFF), End: uint16(hi & 0xFFFF)})
+			lo = hi + 1
+		}
+	}
+	return result
+}
+
+func rangeTableSet(tables ...*unicode.RangeTable) runeSet {
+	var ranges []runeRange
+	for _, t := range tables {
+		for _, r := range t.R16 {
+			for lo := rune(r.Lo); lo <= rune(r.Hi); lo += rune(r.Stride) {
+				if r.Stride == 1 {
+					ranges = append(ranges, runeRange{rune(r.Lo), rune(r.Hi)})
+					break
+				}
+				ranges = append(ranges, runeRange{lo, lo})
+			}
+		}
+		for _, r := range t.R32 {
+			for lo := rune(r.Lo); lo <= rune(r.Hi); lo += rune(r.Stride) {
+				if r.Stride == 1 {
+					ranges = append(ranges, runeRange{rune(r.Lo), rune(r.Hi)})
+					break
+				}
+				ranges = append(ranges, runeRange{lo, lo})
+			}
+		}
+	}
+	return newRuneSet(ranges...)
+}
+
+// the predefined character sets of the GOLD Meta-Language
+var predefinedSets = func() map[string]runeSet {
+	digit := newRuneSet(runeRange{'0', '9'})
+	letter := newRuneSet(runeRange{'a', 'z'}, runeRange{'A', 'Z'})
+	whitespace := runeSetOf(' ', '\t', '\n', '\v', '\f', '\r', 0xA0)
+	printable := newRuneSet(runeRange{0x20, 0x7E}, runeRange{0xA0, 0xA0})
+	allValid := newRuneSet(runeRange{0x01, 0xD7FF}, runeRange{0xE000, 0xFFFD}, runeRange{0x10000, 0x10FFFF})
+
+	sets := map[string]runeSet{
+		"HT":                 runeSetOf('\t'),
+		"LF":                 runeSetOf('\n'),
+		"VT":                 runeSetOf('\v'),
+		"FF":                 runeSetOf('\f'),
+		"CR":                 runeSetOf('\r'),
+		"Space":              runeSetOf(' '),
+		"NBSP":               runeSetOf(0xA0),
+		"LS":                 runeSetOf(0x2028),
+		"PS":                 runeSetOf(0x2029),
+		"Euro Sign":          runeSetOf(0x20AC),
+		"Number":             digit,
+		"Digit":              digit,
+		"Letter":             letter,
+		"AlphaNumeric":       letter.union(digit),
+		"Printable":          printable,
+		"Letter Extended":    newRuneSet(runeRange{0xC0, 0xD6}, runeRange{0xD8, 0xF6}, runeRange{0xF8, 0xFF}),
+		"Printable Extended": newRuneSet(runeRange{0xA1, 0xFF}),
+		"Whitespace":         whitespace,
+		"Control Codes":      newRuneSet(runeRange{0x00, 0x1F}, runeRange{0x7F, 0x7F}),
+		"All Letters":        rangeTableSet(unicode.Letter),
+		"All Printable":      allValid.subtract(rangeTableSet(unicode.Cc)),
+		"All Whitespace":     rangeTableSet(unicode.White_Space),
+		"All Newline":        runeSetOf('\n', '\r', 0x85, 0x2028, 0x2029),
+		"All Space":          rangeTableSet(unicode.Zs),
+		"All Valid":          allValid,
+		"ANSI Printable":     newRuneSet(runeRange{0x20, 0x7E}, runeRange{0xA0, 0xFF}),
+	}
+	return sets
+}()
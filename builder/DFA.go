@@ -0,0 +1,301 @@
+package builder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type nfaEdge struct {
+	set    runeSet
+	target int
+}
+
+type nfaState struct {
+	epsilon []int
+	edges   []nfaEdge
+	// the index of the terminal which is accepted by the state or -1
+	accept int
+}
+
+// nondeterministic finite automaton built from the regular expressions of the terminals
+type nfa struct {
+	states []*nfaState
+	// resolves the sets used in regular expressions
+	resolveSet func(item setItem) (runeSet, error)
+	// tells if literal texts are matched case sensitive
+	caseSensitive bool
+}
+
+func (n *nfa) add() int {
+	n.states = append(n.states, &nfaState{accept: -1})
+	return len(n.states) - 1
+}
+
+func (n *nfa) addEdge(from, to int, set runeSet) {
+	n.states[from].edges = append(n.states[from].edges, nfaEdge{set: set, target: to})
+}
+
+func (n *nfa) addEpsilon(from, to int) {
+	n.states[from].epsilon = append(n.states[from].epsilon, to)
+}
+
+// builds the states for the regular expression. returns the entry and exit state.
+func (n *nfa) build(node regexNode) (int, int, error) {
+	switch node := node.(type) {
+	case regexLiteral:
+		entry := n.add()
+		cur := entry
+		for _, r := range string(node) {
+			set := runeSetOf(r)
+			if !n.caseSensitive {
+				set = set.foldCase()
+			}
+			next := n.add()
+			n.addEdge(cur, next, set)
+			cur = next
+		}
+		return entry, cur, nil
+	case regexSet:
+		set, err := n.resolveSet(setItem(node))
+		if err != nil {
+			return 0, 0, err
+		}
+		if !n.caseSensitive {
+			set = set.foldCase()
+		}
+		entry, exit := n.add(), n.add()
+		n.addEdge(entry, exit, set)
+		return entry, exit, nil
+	case regexSequence:
+		entry := n.add()
+		cur := entry
+		for _, item := range node {
+			in, out, err := n.build(item)
+			if err != nil {
+				return 0, 0, err
+			}
+			n.addEpsilon(cur, in)
+			cur = out
+		}
+		return entry, cur, nil
+	case regexAlternatives:
+		entry, exit := n.add(), n.add()
+		for _, alt := range node {
+			in, out, err := n.build(alt)
+			if err != nil {
+				return 0, 0, err
+			}
+			n.addEpsilon(entry, in)
+			n.addEpsilon(out, exit)
+		}
+		return entry, exit, nil
+	case regexRepeat:
+		in, out, err := n.build(node.node)
+		if err != nil {
+			return 0, 0, err
+		}
+		entry, exit := n.add(), n.add()
+		n.addEpsilon(entry, in)
+		n.addEpsilon(out, exit)
+		if node.kind != '?' {
+			n.addEpsilon(out, in)
+		}
+		if node.kind != '+' {
+			n.addEpsilon(entry, exit)
+		}
+		return entry, exit, nil
+	}
+	return 0, 0, fmt.Errorf("unknown regular expression %T", node)
+}
+
+// returns the sorted states which are reachable from the given states without input
+func (n *nfa) closure(states []int) []int {
+	seen := make(map[int]bool)
+	work := append([]int(nil), states...)
+	for len(work) > 0 {
+		s := work[len(work)-1]
+		work = work[:len(work)-1]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		work = append(work, n.states[s].epsilon...)
+	}
+	result := make([]int, 0, len(seen))
+	for s := range seen {
+		result = append(result, s)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func stateSetKey(states []int) string {
+	parts := make([]string, len(states))
+	for i, s := range states {
+		parts[i] = strconv.Itoa(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+// returns the priority of a terminal to resolve lexical conflicts. Terminals with a fixed text
+// win over terminals which match a finite number of texts which win over all other terminals.
+func regexPriority(node regexNode) int {
+	isLiteral := func(n regexNode) bool {
+		_, ok := n.(regexLiteral)
+		return ok
+	}
+	if isLiteral(node) {
+		return 2
+	}
+	if seq, ok := node.(regexSequence); ok {
+		literal := true
+		for _, item := range seq {
+			literal = literal && isLiteral(item)
+		}
+		if literal {
+			return 2
+		}
+	}
+	var finite func(n regexNode) bool
+	finite = func(n regexNode) bool {
+		switch n := n.(type) {
+		case regexRepeat:
+			return n.kind == '?' && finite(n.node)
+		case regexSequence:
+			for _, item := range n {
+				if !finite(item) {
+					return false
+				}
+			}
+		case regexAlternatives:
+			for _, item := range n {
+				if !finite(item) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if finite(node) {
+		return 1
+	}
+	return 0
+}
+
+type dfaEdge struct {
+	set    runeSet
+	target int
+}
+
+type dfaState struct {
+	nfaStates []int
+	// the index of the accepted terminal or -1
+	accept int
+	edges  []dfaEdge
+}
+
+// a terminal which is scanned by the DFA
+type lexicalTerminal struct {
+	name     string
+	regex    regexNode
+	priority int
+}
+
+// builds the DFA for the terminals by the subset construction.
+func buildDFA(terminals []*lexicalTerminal, resolveSet func(setItem) (runeSet, error), caseSensitive bool) ([]*dfaState, error) {
+	n := &nfa{resolveSet: resolveSet, caseSensitive: caseSensitive}
+	start := n.add()
+	for idx, t := range terminals {
+		in, out, err := n.build(t.regex)
+		if err != nil {
+			return nil, err
+		}
+		n.addEpsilon(start, in)
+		n.states[out].accept = idx
+	}
+
+	var errs Errors
+	var states []*dfaState
+	known := make(map[string]int)
+
+	addState := func(nfaStates []int) int {
+		key := stateSetKey(nfaStates)
+		if idx, ok := known[key]; ok {
+			return idx
+		}
+		state := &dfaState{nfaStates: nfaStates, accept: -1}
+		var candidates []int
+		for _, s := range nfaStates {
+			if a := n.states[s].accept; a >= 0 {
+				if state.accept < 0 || terminals[a].priority > terminals[state.accept].priority {
+					state.accept = a
+					candidates = []int{a}
+				} else if terminals[a].priority == terminals[state.accept].priority && a != state.accept {
+					candidates = append(candidates, a)
+				}
+			}
+		}
+		if len(candidates) > 1 {
+			names := make([]string, len(candidates))
+			for i, c := range candidates {
+				names[i] = terminals[c].name
+			}
+			errs = append(errs, &Error{Message: "lexical conflict between the terminals " + strings.Join(names, ", ")})
+		}
+		states = append(states, state)
+		known[key] = len(states) - 1
+		return len(states) - 1
+	}
+
+	addState(n.closure([]int{start}))
+	for idx := 0; idx < len(states); idx++ {
+		state := states[idx]
+
+		var edges []nfaEdge
+		for _, s := range state.nfaStates {
+			edges = append(edges, n.states[s].edges...)
+		}
+
+		// split the characters into intervals which lead to the same NFA states
+		var bounds []rune
+		for _, e := range edges {
+			for _, r := range e.set {
+				bounds = append(bounds, r.lo, r.hi+1)
+			}
+		}
+		sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+		targets := make(map[int]runeSet)
+		var order []int
+		for i := 0; i+1 < len(bounds); i++ {
+			lo, hi := bounds[i], bounds[i+1]-1
+			if lo > hi {
+				continue
+			}
+			var next []int
+			for _, e := range edges {
+				if e.set.contains(lo) {
+					next = append(next, e.target)
+				}
+			}
+			if len(next) == 0 {
+				continue
+			}
+			target := addState(n.closure(next))
+			if _, ok := targets[target]; !ok {
+				order = append(order, target)
+			}
+			targets[target] = append(targets[target], runeRange{lo, hi})
+		}
+		for _, target := range order {
+			state.edges = append(state.edges, dfaEdge{set: targets[target].normalize(), target: target})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return states, nil
+}
@@ -0,0 +1,425 @@
+package builder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/gold"
+)
+
+type bitSet []uint64
+
+func newBitSet(size int) bitSet {
+	return make(bitSet, (size+63)/64)
+}
+
+func (b bitSet) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bitSet) has(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+func (b bitSet) clear(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+// adds all elements of o to b. returns true if b was changed.
+func (b bitSet) addAll(o bitSet) bool {
+	changed := false
+	for i := range b {
+		if n := b[i] | o[i]; n != b[i] {
+			b[i] = n
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (b bitSet) copy() bitSet {
+	return append(bitSet(nil), b...)
+}
+
+type production struct {
+	head int
+	body []int
+}
+
+type lrItem struct {
+	prod int
+	dot  int
+}
+
+type lrItemSet []lrItem
+
+func (s lrItemSet) Len() int      { return len(s) }
+func (s lrItemSet) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s lrItemSet) Less(i, j int) bool {
+	if s[i].prod != s[j].prod {
+		return s[i].prod < s[j].prod
+	}
+	return s[i].dot < s[j].dot
+}
+
+func (s lrItemSet) key() string {
+	parts := make([]string, len(s))
+	for i, item := range s {
+		parts[i] = strconv.Itoa(item.prod) + "." + strconv.Itoa(item.dot)
+	}
+	return strings.Join(parts, ",")
+}
+
+type lrBuildState struct {
+	kernel     lrItemSet
+	lookaheads []bitSet
+	transition map[int]int
+}
+
+// builds the LALR(1) tables
+type lalrBuilder struct {
+	symbols []gold.TableSymbol
+	// productions[0] is the augmented start rule, all other productions are the rules of the grammar
+	productions []production
+	byHead      map[int][]int
+
+	nullable []bool
+	first    []bitSet
+
+	states []*lrBuildState
+	known  map[string]int
+
+	// symbol id of the augmented start symbol and of the propagation marker
+	augmented int
+	marker    int
+	endSymbol int
+
+	warnings []string
+}
+
+func newLALRBuilder(symbols []gold.TableSymbol, rules []gold.TableRule, start gold.SymbolId) *lalrBuilder {
+	b := &lalrBuilder{
+		symbols:   symbols,
+		byHead:    make(map[int][]int),
+		known:     make(map[string]int),
+		augmented: len(symbols),
+		marker:    len(symbols) + 1,
+		endSymbol: -1,
+	}
+	for idx, s := range symbols {
+		if s.Kind == gold.KindEnd {
+			b.endSymbol = idx
+		}
+	}
+
+	b.productions = append(b.productions, production{head: b.augmented, body: []int{int(start)}})
+	for _, r := range rules {
+		body := make([]int, len(r.Symbols))
+		for i, s := range r.Symbols {
+			body[i] = int(s)
+		}
+		b.productions = append(b.productions, production{head: int(r.Head), body: body})
+	}
+	for idx, p := range b.productions {
+		b.byHead[p.head] = append(b.byHead[p.head], idx)
+	}
+	return b
+}
+
+func (b *lalrBuilder) isNonTerminal(s int) bool {
+	return s == b.augmented || b.symbols[s].Kind == gold.KindNonTerminal
+}
+
+func (b *lalrBuilder) symbolName(s int) string {
+	if s == b.augmented {
+		return "<S'>"
+	}
+	if b.symbols[s].Kind == gold.KindNonTerminal {
+		return "<" + b.symbols[s].Name + ">"
+	}
+	return b.symbols[s].Name
+}
+
+func (b *lalrBuilder) computeFirst() {
+	count := len(b.symbols) + 2
+	b.nullable = make([]bool, count)
+	b.first = make([]bitSet, count)
+	for s := 0; s < count; s++ {
+		b.first[s] = newBitSet(count)
+		if s < len(b.symbols) && !b.isNonTerminal(s) {
+			b.first[s].set(s)
+		}
+	}
+	b.first[b.marker].set(b.marker)
+
+	for changed := true; changed; {
+		changed = false
+		for _, p := range b.productions {
+			allNullable := true
+			for _, s := range p.body {
+				if b.first[p.head].addAll(b.first[s]) {
+					changed = true
+				}
+				if !b.nullable[s] {
+					allNullable = false
+					break
+				}
+			}
+			if allNullable && !b.nullable[p.head] {
+				b.nullable[p.head] = true
+				changed = true
+			}
+		}
+	}
+}
+
+// returns the first set of the symbols and if all symbols are nullable
+func (b *lalrBuilder) firstOf(symbols []int) (bitSet, bool) {
+	result := newBitSet(len(b.symbols) + 2)
+	for _, s := range symbols {
+		result.addAll(b.first[s])
+		if !b.nullable[s] {
+			return result, false
+		}
+	}
+	return result, true
+}
+
+func (b *lalrBuilder) closure0(kernel lrItemSet) lrItemSet {
+	seen := make(map[lrItem]bool)
+	result := append(lrItemSet(nil), kernel...)
+	for _, item := range kernel {
+		seen[item] = true
+	}
+	for i := 0; i < len(result); i++ {
+		p := b.productions[result[i].prod]
+		if result[i].dot >= len(p.body) || !b.isNonTerminal(p.body[result[i].dot]) {
+			continue
+		}
+		for _, prod := range b.byHead[p.body[result[i].dot]] {
+			item := lrItem{prod: prod, dot: 0}
+			if !seen[item] {
+				seen[item] = true
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// computes the LR(1) closure of the items. items maps every item to its lookahead set.
+func (b *lalrBuilder) closure1(items map[lrItem]bitSet) {
+	work := make([]lrItem, 0, len(items))
+	for item := range items {
+		work = append(work, item)
+	}
+	for len(work) > 0 {
+		item := work[len(work)-1]
+		work = work[:len(work)-1]
+
+		p := b.productions[item.prod]
+		if item.dot >= len(p.body) || !b.isNonTerminal(p.body[item.dot]) {
+			continue
+		}
+		la, nullable := b.firstOf(p.body[item.dot+1:])
+		if nullable {
+			la.addAll(items[item])
+		}
+		for _, prod := range b.byHead[p.body[item.dot]] {
+			next := lrItem{prod: prod, dot: 0}
+			cur, ok := items[next]
+			if !ok {
+				items[next] = la.copy()
+				work = append(work, next)
+			} else if cur.addAll(la) {
+				work = append(work, next)
+			}
+		}
+	}
+}
+
+func (b *lalrBuilder) addState(kernel lrItemSet) int {
+	sort.Sort(kernel)
+	key := kernel.key()
+	if idx, ok := b.known[key]; ok {
+		return idx
+	}
+	state := &lrBuildState{kernel: kernel, transition: make(map[int]int)}
+	state.lookaheads = make([]bitSet, len(kernel))
+	for i := range kernel {
+		state.lookaheads[i] = newBitSet(len(b.symbols) + 2)
+	}
+	b.states = append(b.states, state)
+	b.known[key] = len(b.states) - 1
+	return len(b.states) - 1
+}
+
+func (b *lalrBuilder) buildLR0() {
+	b.addState(lrItemSet{{prod: 0, dot: 0}})
+	for idx := 0; idx < len(b.states); idx++ {
+		state := b.states[idx]
+		kernels := make(map[int]lrItemSet)
+		var symbols []int
+		for _, item := range b.closure0(state.kernel) {
+			p := b.productions[item.prod]
+			if item.dot >= len(p.body) {
+				continue
+			}
+			s := p.body[item.dot]
+			if _, ok := kernels[s]; !ok {
+				symbols = append(symbols, s)
+			}
+			kernels[s] = append(kernels[s], lrItem{prod: item.prod, dot: item.dot + 1})
+		}
+		sort.Ints(symbols)
+		for _, s := range symbols {
+			state.transition[s] = b.addState(kernels[s])
+		}
+	}
+}
+
+func (b *lalrBuilder) kernelIndex(state int, item lrItem) int {
+	for i, k := range b.states[state].kernel {
+		if k == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// computes the lookaheads of the kernel items by spontaneous generation and propagation
+func (b *lalrBuilder) computeLookaheads() {
+	type kernelRef struct {
+		state int
+		item  int
+	}
+	propagation := make(map[kernelRef][]kernelRef)
+
+	for sIdx, state := range b.states {
+		for kIdx, kernel := range state.kernel {
+			marker := newBitSet(len(b.symbols) + 2)
+			marker.set(b.marker)
+			items := map[lrItem]bitSet{kernel: marker}
+			b.closure1(items)
+
+			for item, la := range items {
+				p := b.productions[item.prod]
+				if item.dot >= len(p.body) {
+					continue
+				}
+				target := state.transition[p.body[item.dot]]
+				tIdx := b.kernelIndex(target, lrItem{prod: item.prod, dot: item.dot + 1})
+				spontaneous := la.copy()
+				spontaneous.clear(b.marker)
+				b.states[target].lookaheads[tIdx].addAll(spontaneous)
+				if la.has(b.marker) {
+					from := kernelRef{sIdx, kIdx}
+					propagation[from] = append(propagation[from], kernelRef{target, tIdx})
+				}
+			}
+		}
+	}
+
+	b.states[0].lookaheads[0].set(b.endSymbol)
+	for changed := true; changed; {
+		changed = false
+		for from, targets := range propagation {
+			la := b.states[from.state].lookaheads[from.item]
+			for _, to := range targets {
+				if b.states[to.state].lookaheads[to.item].addAll(la) {
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// builds the LALR states. Shift-reduce conflicts are resolved in favor of shifting,
+// reduce-reduce conflicts are reported as errors.
+func (b *lalrBuilder) build() ([]gold.TableLRState, error) {
+	if b.endSymbol < 0 {
+		return nil, &Error{Message: "grammar has no end symbol"}
+	}
+	b.computeFirst()
+	b.buildLR0()
+	b.computeLookaheads()
+
+	var errs Errors
+	result := make([]gold.TableLRState, len(b.states))
+	for sIdx, state := range b.states {
+		items := make(map[lrItem]bitSet)
+		for kIdx, kernel := range state.kernel {
+			items[kernel] = state.lookaheads[kIdx].copy()
+		}
+		b.closure1(items)
+
+		actions := make(map[int]gold.TableLRAction)
+		for s, target := range state.transition {
+			if b.isNonTerminal(s) {
+				actions[s] = gold.TableLRAction{Symbol: gold.SymbolId(s), Action: gold.ActionGoto, Target: uint16(target)}
+			} else {
+				actions[s] = gold.TableLRAction{Symbol: gold.SymbolId(s), Action: gold.ActionShift, Target: uint16(target)}
+			}
+		}
+
+		completed := make(lrItemSet, 0)
+		for item := range items {
+			if item.dot == len(b.productions[item.prod].body) {
+				completed = append(completed, item)
+			}
+		}
+		sort.Sort(completed)
+
+		for _, item := range completed {
+			la := items[item]
+			for s := 0; s < len(b.symbols); s++ {
+				if !la.has(s) {
+					continue
+				}
+				var act gold.TableLRAction
+				if item.prod == 0 {
+					act = gold.TableLRAction{Symbol: gold.SymbolId(s), Action: gold.ActionAccept}
+				} else {
+					act = gold.TableLRAction{Symbol: gold.SymbolId(s), Action: gold.ActionReduce, Target: uint16(item.prod - 1)}
+				}
+
+				existing, ok := actions[s]
+				switch {
+				case !ok:
+					actions[s] = act
+				case existing.Action == gold.ActionShift:
+					b.warnings = append(b.warnings, fmt.Sprintf("shift-reduce conflict in state %d on %s, resolved by shifting instead of reducing %s",
+						sIdx, b.symbolName(s), b.productionString(item.prod)))
+				default:
+					errs = append(errs, &Error{Message: fmt.Sprintf("reduce-reduce conflict in state %d on %s between %s and %s",
+						sIdx, b.symbolName(s), b.productionString(int(existing.Target)+1), b.productionString(item.prod))})
+				}
+			}
+		}
+
+		ids := make([]int, 0, len(actions))
+		for s := range actions {
+			ids = append(ids, s)
+		}
+		sort.Ints(ids)
+		for _, s := range ids {
+			result[sIdx].Actions = append(result[sIdx].Actions, actions[s])
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+func (b *lalrBuilder) productionString(prod int) string {
+	p := b.productions[prod]
+	parts := []string{b.symbolName(p.head), "::="}
+	for _, s := range p.body {
+		parts = append(parts, b.symbolName(s))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,714 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+type metaTokenKind byte
+
+const (
+	mtEnd         metaTokenKind = iota // end of the source
+	mtNewLine                          // end of a line
+	mtParameter                        // "Name"
+	mtNonTerminal                      // <Name>
+	mtSetName                          // {Name}
+	mtSetLiteral                       // [abc]
+	mtTerminal                         // Name or 'text'
+	mtOperator                         // ::= @= = | + - * ? ( )
+)
+
+// a token of the GOLD Meta-Language
+type metaToken struct {
+	kind metaTokenKind
+	text string
+	line int
+}
+
+func (t metaToken) String() string {
+	switch t.kind {
+	case mtEnd:
+		return "end of file"
+	case mtNewLine:
+		return "end of line"
+	case mtParameter:
+		return fmt.Sprintf("\"%s\"", t.text)
+	case mtNonTerminal:
+		return fmt.Sprintf("<%s>", t.text)
+	case mtSetName:
+		return fmt.Sprintf("{%s}", t.text)
+	case mtSetLiteral:
+		return fmt.Sprintf("[%s]", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+func isTerminalStart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isTerminalChar(r rune) bool {
+	return isTerminalStart(r) || r == '-' || r == '.'
+}
+
+// splits the source of a grammar into tokens
+type metaLexer struct {
+	rd   *bufio.Reader
+	line int
+}
+
+func (l *metaLexer) read() (rune, bool) {
+	r, _, err := l.rd.ReadRune()
+	if err != nil {
+		return 0, false
+	}
+	if r == '\n' {
+		l.line++
+	}
+	return r, true
+}
+
+func (l *metaLexer) peek() (rune, bool) {
+	r, _, err := l.rd.ReadRune()
+	if err != nil {
+		return 0, false
+	}
+	l.rd.UnreadRune()
+	return r, true
+}
+
+func (l *metaLexer) errorf(format string, args ...interface{}) error {
+	return &Error{Line: l.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// reads the text up to the closing rune
+func (l *metaLexer) readUntil(closing rune, what string) (string, error) {
+	buf := new(bytes.Buffer)
+	for {
+		r, ok := l.read()
+		if !ok || r == '\n' {
+			return "", l.errorf("unterminated %s", what)
+		}
+		if r == closing {
+			return string(buf.Bytes()), nil
+		}
+		buf.WriteRune(r)
+	}
+}
+
+// reads a quoted text. Two single quotes stand for one single quote.
+func (l *metaLexer) readQuoted() (string, error) {
+	buf := new(bytes.Buffer)
+	for {
+		r, ok := l.read()
+		if !ok || r == '\n' {
+			return "", l.errorf("unterminated literal")
+		}
+		if r == '\'' {
+			if next, ok := l.peek(); ok && next == '\'' {
+				l.read()
+				buf.WriteRune('\'')
+				continue
+			}
+			return string(buf.Bytes()), nil
+		}
+		buf.WriteRune(r)
+	}
+}
+
+// reads a set literal, parts in single quotes are taken as they are.
+func (l *metaLexer) readSetLiteral() (string, error) {
+	buf := new(bytes.Buffer)
+	for {
+		r, ok := l.read()
+		if !ok || r == '\n' {
+			return "", l.errorf("unterminated set literal")
+		}
+		switch r {
+		case ']':
+			return string(buf.Bytes()), nil
+		case '\'':
+			if next, ok := l.peek(); ok && next == '\'' {
+				l.read()
+				buf.WriteRune('\'')
+				continue
+			}
+			quoted, err := l.readQuoted()
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(quoted)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// reads a text in braces which may contain nested braces
+func (l *metaLexer) readBraces() (string, error) {
+	buf := new(bytes.Buffer)
+	depth := 1
+	for {
+		r, ok := l.read()
+		if !ok {
+			return "", l.errorf("unterminated set name")
+		}
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return string(buf.Bytes()), nil
+			}
+		}
+		buf.WriteRune(r)
+	}
+}
+
+func (l *metaLexer) skipBlockComment() error {
+	depth := 1
+	prev := rune(0)
+	for depth > 0 {
+		r, ok := l.read()
+		if !ok {
+			return l.errorf("unterminated comment")
+		}
+		switch {
+		case prev == '!' && r == '*':
+			depth++
+			r = 0
+		case prev == '*' && r == '!':
+			depth--
+			r = 0
+		}
+		prev = r
+	}
+	return nil
+}
+
+func (l *metaLexer) next() (metaToken, error) {
+	for {
+		r, ok := l.read()
+		if !ok {
+			return metaToken{kind: mtEnd, line: l.line}, nil
+		}
+		line := l.line
+		tok := func(kind metaTokenKind, text string) (metaToken, error) {
+			return metaToken{kind: kind, text: text, line: line}, nil
+		}
+
+		switch {
+		case r == '\n':
+			return metaToken{kind: mtNewLine, line: line - 1}, nil
+		case unicode.IsSpace(r):
+			continue
+		case r == '!':
+			if next, ok := l.peek(); ok && next == '*' {
+				l.read()
+				if err := l.skipBlockComment(); err != nil {
+					return metaToken{}, err
+				}
+				continue
+			}
+			for r, ok = l.read(); ok && r != '\n'; r, ok = l.read() {
+			}
+			if ok {
+				return metaToken{kind: mtNewLine, line: line}, nil
+			}
+			continue
+		case r == '"':
+			text, err := l.readUntil('"', "parameter name")
+			if err != nil {
+				return metaToken{}, err
+			}
+			return tok(mtParameter, text)
+		case r == '<':
+			text, err := l.readUntil('>', "non-terminal")
+			if err != nil {
+				return metaToken{}, err
+			}
+			return tok(mtNonTerminal, text)
+		case r == '{':
+			text, err := l.readBraces()
+			if err != nil {
+				return metaToken{}, err
+			}
+			return tok(mtSetName, text)
+		case r == '[':
+			text, err := l.readSetLiteral()
+			if err != nil {
+				return metaToken{}, err
+			}
+			return tok(mtSetLiteral, text)
+		case r == '\'':
+			text, err := l.readQuoted()
+			if err != nil {
+				return metaToken{}, err
+			}
+			if text == "" {
+				// '' is a literal single quote
+				text = "'"
+			}
+			return tok(mtTerminal, text)
+		case r == ':':
+			if a, _ := l.read(); a == ':' {
+				if b, _ := l.read(); b == '=' {
+					return tok(mtOperator, "::=")
+				}
+			}
+			return metaToken{}, l.errorf("invalid operator, expected ::=")
+		case r == '@':
+			if a, _ := l.read(); a == '=' {
+				return tok(mtOperator, "@=")
+			}
+			return metaToken{}, l.errorf("invalid operator, expected @=")
+		case strings.ContainsRune("=|+-*?()", r):
+			return tok(mtOperator, string(r))
+		case isTerminalStart(r):
+			buf := new(bytes.Buffer)
+			buf.WriteRune(r)
+			for next, ok := l.peek(); ok && isTerminalChar(next); next, ok = l.peek() {
+				l.read()
+				buf.WriteRune(next)
+			}
+			return tok(mtTerminal, string(buf.Bytes()))
+		default:
+			return metaToken{}, l.errorf("unexpected character %q", r)
+		}
+	}
+}
+
+// an item of a set expression
+type setItem struct {
+	// the name of a set or the characters of a set literal
+	text    string
+	literal bool
+	line    int
+}
+
+type setExpression struct {
+	items []setItem
+	// '+' or '-' for all items after the first one
+	ops []byte
+}
+
+// a node of a regular expression
+type regexNode interface{}
+
+type regexAlternatives []regexNode
+
+type regexSequence []regexNode
+
+type regexSet setItem
+
+type regexLiteral string
+
+type regexRepeat struct {
+	node regexNode
+	// '*', '+' or '?'
+	kind byte
+}
+
+type parameterDecl struct {
+	name  string
+	lines []string
+	// the non-terminals in the parameter value
+	nonTerminals []string
+	line         int
+}
+
+type setDecl struct {
+	name string
+	expr setExpression
+	line int
+}
+
+type terminalDecl struct {
+	name  string
+	regex regexNode
+	line  int
+}
+
+type attributeDecl struct {
+	name  string
+	attrs map[string][]string
+	line  int
+}
+
+// a symbol of a rule body
+type ruleSymbol struct {
+	name        string
+	nonTerminal bool
+}
+
+type ruleDecl struct {
+	head    string
+	handles [][]ruleSymbol
+	line    int
+}
+
+// the declarations of a grammar source
+type grammarSource struct {
+	parameters []*parameterDecl
+	sets       []*setDecl
+	terminals  []*terminalDecl
+	attributes []*attributeDecl
+	rules      []*ruleDecl
+}
+
+// parses the source of a grammar
+type metaParser struct {
+	tokens []metaToken
+	pos    int
+}
+
+func parseSource(r io.Reader) (*grammarSource, error) {
+	lex := &metaLexer{rd: bufio.NewReader(r), line: 1}
+	p := new(metaParser)
+	for {
+		t, err := lex.next()
+		if err != nil {
+			return nil, err
+		}
+		p.tokens = append(p.tokens, t)
+		if t.kind == mtEnd {
+			break
+		}
+	}
+	return p.parse()
+}
+
+func (p *metaParser) peek() metaToken {
+	return p.tokens[p.pos]
+}
+
+func (p *metaParser) next() metaToken {
+	t := p.tokens[p.pos]
+	if t.kind != mtEnd {
+		p.pos++
+	}
+	return t
+}
+
+func (p *metaParser) isOperator(op string) bool {
+	t := p.peek()
+	return t.kind == mtOperator && t.text == op
+}
+
+// skips the line breaks if the next token after them is one of the operators.
+// returns true if such an operator follows.
+func (p *metaParser) continuesWith(ops ...string) bool {
+	idx := p.pos
+	for p.tokens[idx].kind == mtNewLine {
+		idx++
+	}
+	t := p.tokens[idx]
+	if t.kind != mtOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos = idx
+			return true
+		}
+	}
+	return false
+}
+
+func (p *metaParser) errorf(t metaToken, format string, args ...interface{}) error {
+	return &Error{Line: t.line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *metaParser) expectOperator(op string) error {
+	p.continuesWith(op)
+	if t := p.next(); t.kind != mtOperator || t.text != op {
+		return p.errorf(t, "unexpected %s, expected %s", t, op)
+	}
+	return nil
+}
+
+// expects the end of a declaration
+func (p *metaParser) expectEnd() error {
+	t := p.next()
+	if t.kind != mtNewLine && t.kind != mtEnd {
+		return p.errorf(t, "unexpected %s, expected end of line", t)
+	}
+	return nil
+}
+
+func (p *metaParser) parse() (*grammarSource, error) {
+	src := new(grammarSource)
+	for {
+		t := p.peek()
+		var err error
+		switch t.kind {
+		case mtEnd:
+			return src, nil
+		case mtNewLine:
+			p.next()
+			continue
+		case mtParameter:
+			err = p.parseParameter(src)
+		case mtSetName:
+			err = p.parseSetDecl(src)
+		case mtNonTerminal:
+			err = p.parseRule(src)
+		case mtTerminal:
+			err = p.parseTerminalDecl(src)
+		default:
+			err = p.errorf(t, "unexpected %s", t)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *metaParser) parseParameter(src *grammarSource) error {
+	name := p.next()
+	if err := p.expectOperator("="); err != nil {
+		return err
+	}
+	decl := &parameterDecl{name: name.text, line: name.line}
+	for {
+		var words []string
+	itemLoop:
+		for {
+			t := p.peek()
+			switch t.kind {
+			case mtTerminal, mtSetLiteral:
+				words = append(words, t.text)
+			case mtSetName:
+				words = append(words, t.String())
+			case mtNonTerminal:
+				words = append(words, t.String())
+				decl.nonTerminals = append(decl.nonTerminals, t.text)
+			default:
+				break itemLoop
+			}
+			p.next()
+		}
+		if len(words) == 0 {
+			return p.errorf(p.peek(), "unexpected %s, expected the value of parameter \"%s\"", p.peek(), name.text)
+		}
+		decl.lines = append(decl.lines, strings.Join(words, " "))
+		if !p.continuesWith("|") {
+			break
+		}
+		p.next()
+	}
+	src.parameters = append(src.parameters, decl)
+	return p.expectEnd()
+}
+
+func (p *metaParser) parseSetItem() (setItem, error) {
+	t := p.next()
+	switch t.kind {
+	case mtSetName:
+		return setItem{text: t.text, line: t.line}, nil
+	case mtSetLiteral:
+		return setItem{text: t.text, literal: true, line: t.line}, nil
+	}
+	return setItem{}, p.errorf(t, "unexpected %s, expected a set", t)
+}
+
+func (p *metaParser) parseSetDecl(src *grammarSource) error {
+	name := p.next()
+	if err := p.expectOperator("="); err != nil {
+		return err
+	}
+	decl := &setDecl{name: name.text, line: name.line}
+	item, err := p.parseSetItem()
+	if err != nil {
+		return err
+	}
+	decl.expr.items = append(decl.expr.items, item)
+	for p.continuesWith("+", "-") {
+		op := p.next().text[0]
+		if item, err = p.parseSetItem(); err != nil {
+			return err
+		}
+		decl.expr.items = append(decl.expr.items, item)
+		decl.expr.ops = append(decl.expr.ops, op)
+	}
+	src.sets = append(src.sets, decl)
+	return p.expectEnd()
+}
+
+func (p *metaParser) parseRule(src *grammarSource) error {
+	head := p.next()
+	if err := p.expectOperator("::="); err != nil {
+		return err
+	}
+	decl := &ruleDecl{head: head.text, line: head.line}
+	for {
+		var handle []ruleSymbol
+	handleLoop:
+		for {
+			t := p.peek()
+			switch t.kind {
+			case mtTerminal:
+				handle = append(handle, ruleSymbol{name: t.text})
+			case mtNonTerminal:
+				handle = append(handle, ruleSymbol{name: t.text, nonTerminal: true})
+			case mtNewLine, mtEnd:
+				break handleLoop
+			case mtOperator:
+				if t.text == "|" {
+					break handleLoop
+				}
+				fallthrough
+			default:
+				return p.errorf(t, "unexpected %s in rule <%s>", t, head.text)
+			}
+			p.next()
+		}
+		decl.handles = append(decl.handles, handle)
+		if !p.continuesWith("|") {
+			break
+		}
+		p.next()
+	}
+	src.rules = append(src.rules, decl)
+	return p.expectEnd()
+}
+
+func (p *metaParser) parseTerminalDecl(src *grammarSource) error {
+	first := p.peek()
+	var words []string
+	for p.peek().kind == mtTerminal {
+		words = append(words, p.next().text)
+	}
+	name := strings.Join(words, " ")
+
+	if p.continuesWith("@=") {
+		p.next()
+		t := p.next()
+		if t.kind != mtSetName {
+			return p.errorf(t, "unexpected %s, expected attributes in braces", t)
+		}
+		attrs, err := parseAttributes(t)
+		if err != nil {
+			return err
+		}
+		src.attributes = append(src.attributes, &attributeDecl{name: name, attrs: attrs, line: first.line})
+		return p.expectEnd()
+	}
+
+	if err := p.expectOperator("="); err != nil {
+		return err
+	}
+	regex, err := p.parseRegex()
+	if err != nil {
+		return err
+	}
+	src.terminals = append(src.terminals, &terminalDecl{name: name, regex: regex, line: first.line})
+	return p.expectEnd()
+}
+
+func (p *metaParser) parseRegex() (regexNode, error) {
+	var alts regexAlternatives
+	for {
+		seq, err := p.parseRegexSequence()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, seq)
+		if !p.continuesWith("|") {
+			break
+		}
+		p.next()
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return alts, nil
+}
+
+func (p *metaParser) parseRegexSequence() (regexNode, error) {
+	var seq regexSequence
+	for {
+		t := p.peek()
+		var node regexNode
+		switch t.kind {
+		case mtSetName:
+			node = regexSet(setItem{text: t.text, line: t.line})
+		case mtSetLiteral:
+			node = regexSet(setItem{text: t.text, literal: true, line: t.line})
+		case mtTerminal:
+			node = regexLiteral(t.text)
+		case mtOperator:
+			if t.text == "(" {
+				p.next()
+				inner, err := p.parseRegex()
+				if err != nil {
+					return nil, err
+				}
+				for p.peek().kind == mtNewLine {
+					p.next()
+				}
+				if !p.isOperator(")") {
+					return nil, p.errorf(p.peek(), "unexpected %s, expected )", p.peek())
+				}
+				node = inner
+			}
+		}
+		if node == nil {
+			if len(seq) == 0 {
+				return nil, p.errorf(t, "unexpected %s, expected a regular expression", t)
+			}
+			return seq, nil
+		}
+		p.next()
+
+		if t := p.peek(); t.kind == mtOperator && (t.text == "*" || t.text == "+" || t.text == "?") {
+			p.next()
+			node = regexRepeat{node: node, kind: t.text[0]}
+		}
+		seq = append(seq, node)
+	}
+}
+
+// parses an attribute list like "Type = Noise, Nesting = {Comment, String}"
+func parseAttributes(t metaToken) (map[string][]string, error) {
+	result := make(map[string][]string)
+	text := t.text
+	for strings.TrimSpace(text) != "" {
+		eq := strings.Index(text, "=")
+		if eq < 0 {
+			return nil, &Error{Line: t.line, Message: fmt.Sprintf("invalid attribute %q", strings.TrimSpace(text))}
+		}
+		name := strings.TrimSpace(text[:eq])
+		text = strings.TrimSpace(text[eq+1:])
+
+		var values []string
+		if strings.HasPrefix(text, "{") {
+			end := strings.Index(text, "}")
+			if end < 0 {
+				return nil, &Error{Line: t.line, Message: fmt.Sprintf("unterminated value of attribute %q", name)}
+			}
+			for _, v := range strings.Split(text[1:end], ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+			text = text[end+1:]
+		} else {
+			end := strings.Index(text, ",")
+			if end < 0 {
+				end = len(text)
+			}
+			values = append(values, strings.TrimSpace(text[:end]))
+			text = text[end:]
+		}
+		text = strings.TrimSpace(text)
+		text = strings.TrimPrefix(text, ",")
+		result[name] = values
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+// Command goldgen generates a Go package which contains the tables of a grammar as static data.
+//
+// Usage:
+//
+//	goldgen [-gen tables|constants|ast] [-pkg name] [-o file] grammar.egt
+//
+// The grammar can be an egt or cgt file of the GOLD Builder or a .grm source file.
+//
+// With -gen tables (the default) the generated package has a function Parser which returns
+// the parser for the grammar without loading any file at runtime.
+// With -gen constants the generated file contains named gold.SymbolId and gold.RuleId constants
+// for all symbols and rules of the grammar and the functions SymbolName and RuleName.
+// With -gen ast the generated file contains a typed syntax tree with a struct for each rule,
+// an interface for each non-terminal, a visitor and functions to build the typed tree.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boombuler/gold"
+	"github.com/boombuler/gold/builder"
+	"github.com/boombuler/gold/codegen"
+)
+
+func main() {
+	pkg := flag.String("pkg", "", "the name of the generated package, defaults to the name of the output directory")
+	out := flag.String("o", "", "the output file, defaults to the standard output")
+	gen := flag.String("gen", "tables", "what to generate: tables, constants or ast")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: goldgen [-gen tables|constants|ast] [-pkg name] [-o file] grammar\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	generate, ok := generators[*gen]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "goldgen: unknown generator %q\n", *gen)
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *pkg, *out, generate); err != nil {
+		fmt.Fprintln(os.Stderr, "goldgen:", err)
+		os.Exit(1)
+	}
+}
+
+// the generators selected by the -gen flag
+var generators = map[string]func(io.Writer, *gold.Tables, codegen.Config) error{
+	"tables":    codegen.Generate,
+	"constants": codegen.GenerateConstants,
+	"ast":       codegen.GenerateAST,
+}
+
+func run(grammarFile, pkg, out string, generate func(io.Writer, *gold.Tables, codegen.Config) error) error {
+	tables, err := loadTables(grammarFile)
+	if err != nil {
+		return err
+	}
+
+	if pkg == "" {
+		pkg = packageName(out)
+	}
+
+	buf := new(bytes.Buffer)
+	cfg := codegen.Config{Package: pkg, Source: filepath.Base(grammarFile)}
+	if err := generate(buf, tables, cfg); err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = io.Copy(os.Stdout, buf)
+		return err
+	}
+	return os.WriteFile(out, buf.Bytes(), 0666)
+}
+
+// reads the tables of a grammar file or builds them from a .grm source
+func loadTables(grammarFile string) (*gold.Tables, error) {
+	f, err := os.Open(grammarFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(grammarFile), ".grm") {
+		result, err := builder.Build(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintln(os.Stderr, "goldgen: warning:", w)
+		}
+		return result.Tables, nil
+	}
+	return gold.ReadTables(f)
+}
+
+// returns the name of the directory of the output file as package name
+func packageName(out string) string {
+	dir := "."
+	if out != "" {
+		dir = filepath.Dir(out)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return -1
+	}, filepath.Base(dir))
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return "grammar"
+	}
+	return name
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackageName(t *testing.T) {
+	tests := []struct {
+		out, want string
+	}{
+		{"calc/tables.go", "calc"},
+		{"My-Grammar/tables.go", "mygrammar"},
+		{"parser_v2/tables.go", "parser_v2"},
+		{"2go/tables.go", "grammar"},
+		{"---/tables.go", "grammar"},
+	}
+	for _, tt := range tests {
+		if got := packageName(filepath.Join(t.TempDir(), tt.out)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	// the generated files of the package in codegen/internal/calc
+	files := map[string]string{"tables": "tables.go", "constants": "constants.go", "ast": "ast.go"}
+	for gen, file := range files {
+		want, err := os.ReadFile("../../codegen/internal/calc/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, input := range []string{"calc.egt", "calc.grm"} {
+			out := filepath.Join(t.TempDir(), "calc", file)
+			if err := os.Mkdir(filepath.Dir(out), 0777); err != nil {
+				t.Fatal(err)
+			}
+			if err := run("../../testdata/"+input, "", out, generators[gen]); err != nil {
+				t.Errorf("%s %s: %v", gen, input, err)
+				continue
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the builder writes the same tables as the grammar file, only the source differs
+			got = bytes.Replace(got, []byte(input), []byte("calc.egt"), 1)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s %s: got\n%s", gen, input, got)
+			}
+		}
+	}
+	if len(generators) != len(files) {
+		t.Errorf("the generators %v are not all tested", generators)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if err := run("../../testdata/missing.egt", "calc", "", generators["tables"]); !os.IsNotExist(err) {
+		t.Errorf("got %v for a missing file", err)
+	}
+	if err := run("main.go", "calc", "", generators["tables"]); err == nil {
+		t.Error("no error for a file which is no grammar")
+	}
+}
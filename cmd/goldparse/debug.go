@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/gold"
+)
+
+const debugHelp = `commands:
+  step, s           run to the next step of the tokenizer or the parser
+  next, n           run to the next step which is not a character of the tokenizer
+  continue, c       run to the next breakpoint or error
+  break sym NAME    break when a symbol is scanned or reduced
+  break rule ID     break in front of the reduction of a rule
+  break state ID    break when an LR state is pushed
+  break dfa ID      break when the tokenizer moves to a DFA state
+  clear             remove all breakpoints
+  stack             print the LR state stack and the token stack
+  rules             print the rules with their ids
+  quit, q           abort parsing
+An empty line repeats the last command.
+`
+
+// runs the interactive debugger on the input. The commands are read from in. Returns false
+// if the input could not be parsed.
+func debug(p gold.Parser, input string, in io.Reader, out io.Writer, opts options) bool {
+	if input == "-" {
+		fmt.Fprintln(os.Stderr, "goldparse: -debug reads the commands from the standard input and needs an input file")
+		return false
+	}
+	name, src, err := readInput(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "goldparse:", err)
+		return false
+	}
+
+	g := p.Grammar()
+	d := gold.NewDebugger(p, bytes.NewReader(src), opts.parseFlags...)
+	defer d.Close()
+
+	fmt.Fprintf(out, "debugging %s, type help for the commands\n", name)
+	scanner := bufio.NewScanner(in)
+	var last []string
+	var current *gold.ParseEvent
+	for {
+		fmt.Fprint(out, "(gold) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return true
+		}
+		args := strings.Fields(scanner.Text())
+		if len(args) == 0 {
+			args = last
+		}
+		if len(args) == 0 {
+			continue
+		}
+		last = args
+
+		var e *gold.ParseEvent
+		ok := true
+		switch args[0] {
+		case "step", "s":
+			e, ok = d.Step()
+		case "next", "n":
+			e, ok = d.Next()
+		case "continue", "c":
+			e, ok = d.Continue()
+		case "break", "b":
+			if err := addBreakpoint(d, g, args[1:]); err != nil {
+				fmt.Fprintln(out, err)
+			}
+			continue
+		case "clear":
+			d.ClearBreakpoints()
+			continue
+		case "stack":
+			printStacks(out, current)
+			continue
+		case "rules":
+			for _, r := range g.Rules() {
+				fmt.Fprintf(out, "%4d %s\n", r.Id, r)
+			}
+			continue
+		case "help", "h", "?":
+			fmt.Fprint(out, debugHelp)
+			continue
+		case "quit", "q":
+			return true
+		default:
+			fmt.Fprintf(out, "unknown command %q, type help for the commands\n", args[0])
+			continue
+		}
+
+		if !ok {
+			tree, err := d.Result()
+			if err != nil {
+				reportError(name, err)
+			} else if tree != nil && opts.writeTree != nil {
+				if err := opts.writeTree(out, tree); err != nil {
+					fmt.Fprintln(os.Stderr, "goldparse:", err)
+				}
+			}
+			return err == nil
+		}
+		current = e
+		fmt.Fprintf(out, "%d:%d: %s\n", e.Terminal.Position.Line, e.Terminal.Position.Column, e)
+	}
+}
+
+func addBreakpoint(d *gold.Debugger, g gold.Grammar, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: break sym|rule|state|dfa VALUE")
+	}
+	if args[0] == "sym" {
+		sym := g.SymbolByName(args[1])
+		if sym == nil {
+			return fmt.Errorf("unknown symbol %q", args[1])
+		}
+		d.BreakOnSymbol(sym.Id)
+		return nil
+	}
+
+	id, err := strconv.Atoi(args[1])
+	if err != nil || id < 0 {
+		return fmt.Errorf("invalid id %q", args[1])
+	}
+	switch args[0] {
+	case "rule":
+		if g.Rule(gold.RuleId(id)) == nil {
+			return fmt.Errorf("unknown rule %d", id)
+		}
+		d.BreakOnRule(gold.RuleId(id))
+	case "state":
+		if id >= g.LRStateCount() {
+			return fmt.Errorf("unknown LR state %d", id)
+		}
+		d.BreakOnState(id)
+	case "dfa":
+		if id >= g.DFAStateCount() {
+			return fmt.Errorf("unknown DFA state %d", id)
+		}
+		d.BreakOnDFAState(id)
+	default:
+		return fmt.Errorf("unknown breakpoint %q", args[0])
+	}
+	return nil
+}
+
+// prints the stacks of the parser at the event from bottom to top
+func printStacks(w io.Writer, e *gold.ParseEvent) {
+	if e == nil {
+		fmt.Fprintln(w, "the parser has not started")
+		return
+	}
+	fmt.Fprintf(w, "states: %v\n", e.StateStack())
+	values := e.ValueStack()
+	names := make([]string, len(values))
+	for i, v := range values {
+		if tok, ok := v.(*gold.Token); ok {
+			names[i] = tok.Name
+			if tok.IsTerminal {
+				names[i] += " " + strconv.Quote(tok.Text)
+			}
+		} else {
+			names[i] = fmt.Sprint(v)
+		}
+	}
+	fmt.Fprintf(w, "tokens: [%s]\n", strings.Join(names, ", "))
+}
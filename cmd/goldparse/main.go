@@ -0,0 +1,281 @@
+// Command goldparse parses input files with a grammar and prints the syntax trees.
+//
+// Usage:
+//
+//	goldparse [flags] grammar.egt [input ...]
+//
+// The grammar can be an egt or cgt file of the GOLD Builder or a .grm source file. Without
+// input files or with - as file name the standard input is parsed.
+//
+// The flags are:
+//
+//	-info     print the information of the grammar
+//	-tokens   print the tokens scanned from the input
+//	-tree     the format of the syntax tree: text, json, xml, sexpr, dot or none
+//	-trim     trim reductions which have only one non-terminal
+//	-recover  recover from syntax errors and report all of them
+//	-trivia   keep the noise and comments in the syntax tree
+//	-stats    print the statistics of each parse to the standard error
+//	-trace    print each step of the parser to the standard error
+//	-debug    step through the tokenizer and the parser of the first input file
+//
+// The debugger reads commands like step, continue and break from the standard input and
+// prints each step with the current lexeme, the DFA or LR states and the chosen action.
+// Type help in the debugger for all commands.
+//
+// Errors are reported as file:line:column: message. The exit status is 1 if an input could
+// not be parsed and 2 for invalid arguments or grammars.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/gold"
+	"github.com/boombuler/gold/builder"
+)
+
+// the writers selected by the -tree flag
+var treeWriters = map[string]func(io.Writer, *gold.Token) error{
+	"text":  writeText,
+	"json":  gold.WriteJSON,
+	"xml":   gold.WriteXML,
+	"sexpr": gold.WriteSExpr,
+	"dot":   gold.WriteTreeDot,
+	"none":  nil,
+}
+
+type options struct {
+	info       bool
+	tokens     bool
+	debug      bool
+	stats      bool
+	writeTree  func(io.Writer, *gold.Token) error
+	parseFlags []gold.ParseOption
+}
+
+func main() {
+	info := flag.Bool("info", false, "print the information of the grammar")
+	tokens := flag.Bool("tokens", false, "print the tokens scanned from the input")
+	tree := flag.String("tree", "text", "the format of the syntax tree: text, json, xml, sexpr, dot or none")
+	trim := flag.Bool("trim", false, "trim reductions which have only one non-terminal")
+	recoverErrors := flag.Bool("recover", false, "recover from syntax errors and report all of them")
+	trivia := flag.Bool("trivia", false, "keep the noise and comments in the syntax tree")
+	stats := flag.Bool("stats", false, "print the statistics of each parse to the standard error")
+	trace := flag.Bool("trace", false, "print each step of the parser to the standard error")
+	debugInput := flag.Bool("debug", false, "step through the tokenizer and the parser of the first input file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: goldparse [flags] grammar [input ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	writeTree, ok := treeWriters[*tree]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "goldparse: unknown tree format %q\n", *tree)
+		os.Exit(2)
+	}
+	opts := options{
+		info:       *info,
+		tokens:     *tokens,
+		debug:      *debugInput,
+		stats:      *stats,
+		writeTree:  writeTree,
+		parseFlags: []gold.ParseOption{gold.TrimReductions(*trim), gold.KeepTrivia(*trivia)},
+	}
+	if *recoverErrors {
+		opts.parseFlags = append(opts.parseFlags, gold.RecoverErrors())
+	}
+	if *trace {
+		opts.parseFlags = append(opts.parseFlags, gold.Trace(gold.TracerFunc(func(e *gold.ParseEvent) {
+			fmt.Fprintf(os.Stderr, "%d:%d: %s\n", e.Terminal.Position.Line, e.Terminal.Position.Column, e)
+		})))
+	}
+
+	p, err := loadParser(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "goldparse:", err)
+		os.Exit(2)
+	}
+	if opts.info {
+		printInfo(os.Stdout, p)
+	}
+
+	inputs := flag.Args()[1:]
+	if len(inputs) == 0 && !opts.info {
+		inputs = []string{"-"}
+	}
+	if opts.debug {
+		if len(inputs) == 0 || !debug(p, inputs[0], os.Stdin, os.Stdout, opts) {
+			os.Exit(1)
+		}
+		return
+	}
+	failed := false
+	for _, input := range inputs {
+		if !run(p, input, opts) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// creates the parser for a grammar file or a .grm source
+func loadParser(grammarFile string) (gold.Parser, error) {
+	f, err := os.Open(grammarFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(grammarFile), ".grm") {
+		result, err := builder.Build(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintln(os.Stderr, "goldparse: warning:", w)
+		}
+		return gold.NewParserFromTables(result.Tables)
+	}
+	return gold.NewParser(f)
+}
+
+func printInfo(w io.Writer, p gold.Parser) {
+	info := p.GetInformation()
+	g := p.Grammar()
+	fmt.Fprintf(w, "Name:    %s\n", info.Name)
+	fmt.Fprintf(w, "Version: %s\n", info.Version)
+	fmt.Fprintf(w, "Author:  %s\n", info.Author)
+	fmt.Fprintf(w, "About:   %s\n", info.About)
+	fmt.Fprintf(w, "Symbols: %d\n", len(g.Symbols()))
+	fmt.Fprintf(w, "Rules:   %d\n", len(g.Rules()))
+	fmt.Fprintf(w, "Groups:  %d\n", len(g.Groups()))
+}
+
+// parses an input file and prints the results. Returns false if the input could not be parsed.
+func run(p gold.Parser, input string, opts options) bool {
+	name, src, err := readInput(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "goldparse:", err)
+		return false
+	}
+
+	ok := true
+	if opts.tokens {
+		ok = printTokens(os.Stdout, p, name, src)
+	}
+	if opts.writeTree == nil && opts.tokens {
+		return ok
+	}
+
+	parseFlags := opts.parseFlags
+	var stats gold.ParseStats
+	if opts.stats {
+		parseFlags = append(parseFlags[:len(parseFlags):len(parseFlags)], gold.CollectStats(&stats))
+	}
+	tree, err := p.ParseContext(context.Background(), bytes.NewReader(src), parseFlags...)
+	if err != nil {
+		reportError(name, err)
+		ok = false
+	}
+	if opts.stats {
+		fmt.Fprintf(os.Stderr, "%s:\n", name)
+		stats.WriteReport(os.Stderr)
+	}
+	if tree != nil && opts.writeTree != nil {
+		if err := opts.writeTree(os.Stdout, tree); err != nil {
+			fmt.Fprintln(os.Stderr, "goldparse:", err)
+			return false
+		}
+	}
+	return ok
+}
+
+func readInput(input string) (string, []byte, error) {
+	if input == "-" {
+		src, err := io.ReadAll(os.Stdin)
+		return "<stdin>", src, err
+	}
+	src, err := os.ReadFile(input)
+	return input, src, err
+}
+
+// prints the terminals of the input one per line. Returns false if the input contains
+// unknown tokens.
+func printTokens(w io.Writer, p gold.Parser, name string, src []byte) bool {
+	ok := true
+	lx := p.NewLexer(bytes.NewReader(src))
+	for {
+		t, err := lx.Next()
+		if err == io.EOF {
+			return ok
+		}
+		// unknown tokens and unterminated groups are printed in front of their error
+		fmt.Fprintf(w, "%s:%d:%d: %s %s\n", name, t.Position.Line, t.Position.Column, t.Name, strconv.Quote(t.Text))
+		if err != nil {
+			reportError(name, err)
+			ok = false
+		}
+	}
+}
+
+// prints the error in the form file:line:column: message
+func reportError(name string, err error) {
+	var errs gold.ParseErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			reportError(name, e)
+		}
+		return
+	}
+	var pe *gold.ParseError
+	if errors.As(err, &pe) && pe.Position.Line > 0 {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", name, pe.Position.Line, pe.Position.Column, pe.Message)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+}
+
+// prints the tree indented by two spaces per level
+func writeText(w io.Writer, tree *gold.Token) error {
+	buf := new(bytes.Buffer)
+	writeTextNode(buf, tree, 0)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeTextNode(buf *bytes.Buffer, tok *gold.Token, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	switch {
+	case tok.IsTerminal:
+		fmt.Fprintf(buf, "%s %s", tok.Name, strconv.Quote(tok.Text))
+	case tok.IsError:
+		buf.WriteString(tok.Name)
+	default:
+		buf.WriteString(tok.Text)
+	}
+	if tok.IsError {
+		buf.WriteString(" (error)")
+	}
+	buf.WriteString("\n")
+	for _, sub := range tok.Tokens {
+		if sub != nil {
+			writeTextNode(buf, sub, depth+1)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runs main instead of the tests if the test binary is started by goldparse
+func TestMain(m *testing.M) {
+	if os.Getenv("GOLDPARSE_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runs goldparse with the arguments and the input and returns the output and the exit status
+func goldparse(t *testing.T, stdin string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GOLDPARSE_TEST_MAIN=1")
+	cmd.Dir = "../../testdata"
+	cmd.Stdin = strings.NewReader(stdin)
+	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = outBuf, errBuf
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		status = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return outBuf.String(), errBuf.String(), status
+}
+
+// writes the input into a temporary file and returns its path
+func inputFile(t *testing.T, input string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.calc")
+	if err := os.WriteFile(path, []byte(input), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGoldparse(t *testing.T) {
+	valid := inputFile(t, "x = 1;\nprint x;")
+	invalid := inputFile(t, "x = ;\nprint x $ ;")
+	tests := []struct {
+		name   string
+		stdin  string
+		args   []string
+		stdout string
+		stderr string
+		status int
+	}{
+		{"text tree", "", []string{"-trim", "calc.egt", valid},
+			"<Stmts> ::= <Stmt> <Stmts>\n" +
+				"  <Stmt> ::= Identifier = <Expr> ;\n    Identifier \"x\"\n    = \"=\"\n    <Factor> ::= Number\n      Number \"1\"\n    ; \";\"\n" +
+				"  <Stmts> ::= <Stmt> <Stmts>\n" +
+				"    <Stmt> ::= print <Expr> ;\n      print \"print\"\n      <Factor> ::= Identifier\n        Identifier \"x\"\n      ; \";\"\n" +
+				"    <Stmts> ::= \n", "", 0},
+		{"standard input", "print 1;", []string{"-tree", "sexpr", "-trim", "calc.cgt"},
+			`("<Stmts>" :symbol 23 :rule 1 :start (1 1 0 0) :end (1 9 8 8)
+  ("<Stmt>" :symbol 22 :rule 4 :start (1 1 0 0) :end (1 9 8 8)
+    ("print" :symbol 17 :terminal :text "print" :start (1 1 0 0) :end (1 6 5 5))
+    ("<Factor>" :symbol 20 :rule 11 :start (1 7 6 6) :end (1 8 7 7)
+      ("Number" :symbol 16 :terminal :text "1" :start (1 7 6 6) :end (1 8 7 7)))
+    (";" :symbol 8 :terminal :text ";" :start (1 8 7 7) :end (1 9 8 8)))
+  ("<Stmts>" :symbol 23 :rule 2 :start (1 9 8 8) :end (1 9 8 8)))
+`, "", 0},
+		{"grammar source", "print 1;", []string{"-tree", "none", "calc.grm", "-"}, "", "", 0},
+		{"tokens", "", []string{"-tokens", "-tree", "none", "calc.egt", valid},
+			valid + ":1:1: Identifier \"x\"\n" + valid + ":1:2: Whitespace \" \"\n" + valid + ":1:3: = \"=\"\n" +
+				valid + ":1:4: Whitespace \" \"\n" + valid + ":1:5: Number \"1\"\n" + valid + ":1:6: ; \";\"\n" +
+				valid + ":1:7: NewLine \"\\n\"\n" + valid + ":2:1: print \"print\"\n" + valid + ":2:6: Whitespace \" \"\n" +
+				valid + ":2:7: Identifier \"x\"\n" + valid + ":2:8: ; \";\"\n", "", 0},
+		{"unknown token", "print $;", []string{"-tokens", "-tree", "none", "calc.egt", "-"},
+			"<stdin>:1:1: print \"print\"\n<stdin>:1:6: Whitespace \" \"\n<stdin>:1:7: Error \"$\"\n<stdin>:1:8: ; \";\"\n",
+			"<stdin>:1:7: Unknown Token \"$\"\n", 1},
+		{"trace", "print 1;", []string{"-trace", "-tree", "none", "calc.egt", "-"}, "",
+			"1:1: token print \"print\" in 0\n1:1: shift print \"print\": 0 -> 2\n1:6: token Whitespace \" \" in 2\n" +
+				"1:7: token Number \"1\" in 2\n1:7: shift Number \"1\": 2 -> 10\n1:8: token ; \";\" in 10\n" +
+				"1:8: reduce <Factor> ::= Number in 10\n1:8: goto <Factor>: 2 -> 12\n" +
+				"1:8: reduce <Term> ::= <Factor> in 12\n1:8: goto <Term>: 2 -> 13\n" +
+				"1:8: reduce <Expr> ::= <Term> in 13\n1:8: goto <Expr>: 2 -> 11\n1:8: shift ; \";\": 11 -> 20\n" +
+				"1:9: token EOF \"\" in 20\n1:9: reduce <Stmt> ::= print <Expr> ; in 20\n1:9: goto <Stmt>: 0 -> 4\n" +
+				"1:9: reduce <Stmts> ::=  in 4\n1:9: goto <Stmts>: 4 -> 14\n" +
+				"1:9: reduce <Stmts> ::= <Stmt> <Stmts> in 14\n1:9: goto <Stmts>: 0 -> 5\n" +
+				"1:9: reduce <Program> ::= <Stmts> in 5\n1:9: goto <Program>: 0 -> 3\n1:9: accept EOF \"\" in 3\n", 0},
+		{"info", "", []string{"-info", "calc.egt"},
+			"Name:    Calc\nVersion: \nAuthor:  \nAbout:   \nSymbols: 25\nRules:   15\nGroups:  2\n", "", 0},
+		{"syntax error", "", []string{"calc.egt", invalid}, "",
+			invalid + `:1:5: syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number` + "\n", 1},
+		{"recovered errors", "", []string{"-recover", "-tree", "none", "calc.egt", invalid}, "",
+			invalid + `:1:5: syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number` + "\n" +
+				invalid + `:2:9: Unknown Token "$"` + "\n", 1},
+		{"several inputs", "", []string{"-tree", "none", "calc.egt", invalid, valid}, "",
+			invalid + `:1:5: syntax Error: unexpected ";", expected one of: "(", "-", Identifier, Number` + "\n", 1},
+		{"missing input", "", []string{"calc.egt", "missing.calc"}, "",
+			"goldparse: open missing.calc: no such file or directory\n", 1},
+		{"unknown tree format", "", []string{"-tree", "yaml", "calc.egt"}, "", "goldparse: unknown tree format \"yaml\"\n", 2},
+		{"missing grammar", "", []string{"missing.egt"}, "", "goldparse: open missing.egt: no such file or directory\n", 2},
+	}
+	for _, tt := range tests {
+		stdout, stderr, status := goldparse(t, tt.stdin, tt.args...)
+		if stdout != tt.stdout || stderr != tt.stderr || status != tt.status {
+			t.Errorf("%s: got the exit status %d, the output\n%s\nand the errors\n%s\nwant %d,\n%s\nand\n%s",
+				tt.name, status, stdout, stderr, tt.status, tt.stdout, tt.stderr)
+		}
+	}
+
+	if _, stderr, status := goldparse(t, ""); status != 2 || !strings.HasPrefix(stderr, "usage: goldparse") {
+		t.Errorf("got the exit status %d and\n%s\nwithout arguments", status, stderr)
+	}
+}
+
+func TestGoldparseStats(t *testing.T) {
+	stdout, stderr, status := goldparse(t, "print 1;", "-stats", "-tree", "none", "calc.egt", "-")
+	// the times and allocations vary between the runs
+	want := []string{"<stdin>:\n", "input:           8 bytes, 8 runes\n", "DFA transitions: 8\n", "max stack depth: 4\n",
+		"tokens:          5\n", "reductions:      7\n", "         1  <Stmt> ::= print <Expr> ;\n"}
+	for _, line := range want {
+		if !strings.Contains(stderr, line) {
+			t.Errorf("the statistics\n%s\ndo not contain %q", stderr, line)
+		}
+	}
+	if stdout != "" || status != 0 {
+		t.Errorf("got the exit status %d and the output\n%s", status, stdout)
+	}
+}
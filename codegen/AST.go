@@ -0,0 +1,284 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+
+	"github.com/boombuler/gold"
+)
+
+// the names used by the generated code, which can not be used for node types
+var reservedTypeNames = []string{
+	"Node", "Span", "Terminal", "ErrorNode", "Visitor", "BaseVisitor", "Convert", "Actions",
+	"Parser", "Tables", "SymbolName", "RuleName", "VerifyGrammar",
+}
+
+// the names of the fields and methods of the node types, which can not be used for fields
+var reservedFieldNames = []string{"Pos", "NodeSpan", "Children", "Accept"}
+
+// the names of the types and fields of the generated syntax tree
+type astNames struct {
+	// the interface of each non-terminal, indexed by symbol
+	nonTerminals map[gold.SymbolId]string
+	// the struct of each rule
+	rules []string
+	// the fields of the struct of each rule
+	fields [][]string
+}
+
+func newASTNames(t *gold.Tables) *astNames {
+	var ids []gold.SymbolId
+	names := append([]string(nil), reservedTypeNames...)
+	for idx, s := range t.Symbols {
+		if s.Kind == gold.KindNonTerminal {
+			ids = append(ids, gold.SymbolId(idx))
+			names = append(names, goName(s.Name))
+		}
+	}
+	names = append(names, ruleNames(t)...)
+	names = uniqueNames(names)[len(reservedTypeNames):]
+
+	result := &astNames{nonTerminals: make(map[gold.SymbolId]string)}
+	for i, id := range ids {
+		result.nonTerminals[id] = names[i]
+	}
+	result.rules = names[len(ids):]
+
+	for _, r := range t.Rules {
+		fields := append([]string(nil), reservedFieldNames...)
+		for _, s := range r.Symbols {
+			fields = append(fields, goName(t.Symbols[s].Name))
+		}
+		result.fields = append(result.fields, uniqueNames(fields)[len(reservedFieldNames):])
+	}
+	return result
+}
+
+// returns the Go type of a field for the symbol
+func (n *astNames) fieldType(t *gold.Tables, s gold.SymbolId) string {
+	if t.Symbols[s].Kind == gold.KindNonTerminal {
+		return n.nonTerminals[s]
+	}
+	return "*Terminal"
+}
+
+// Generates the Go source of a typed syntax tree for the grammar. Each non-terminal becomes an
+// interface which is implemented by a struct for each of its rules. The generated Convert function
+// converts a *gold.Token tree, which was parsed without trimmed reductions, into the typed nodes and
+// the generated Actions function returns semantic actions, which build the typed nodes while
+// parsing with Parser.Evaluate.
+func GenerateAST(w io.Writer, t *gold.Tables, cfg Config) error {
+	if err := t.Validate().Err(); err != nil {
+		return err
+	}
+	if cfg.Package == "" {
+		return fmt.Errorf("codegen: no package name")
+	}
+
+	g := new(generator)
+	g.header(cfg, "fmt", "", "github.com/boombuler/gold")
+	g.ast(t, newASTNames(t))
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("codegen: unable to format the generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func (g *generator) ast(t *gold.Tables, names *astNames) {
+	g.printf(`// a node of the typed syntax tree of %s
+type Node interface {
+	// returns the position of the node in the source
+	NodeSpan() Span
+	// returns the child nodes which are not nil
+	Children() []Node
+	// calls the method of the visitor for the type of the node
+	Accept(v Visitor)
+}
+
+// the position of a node in the source
+type Span struct {
+	Start gold.TextPosition
+	End   gold.TextPosition
+}
+
+// a terminal of the syntax tree
+type Terminal struct {
+	Pos    Span
+	Symbol gold.SymbolId
+	Text   string
+}
+
+func (n *Terminal) NodeSpan() Span   { return n.Pos }
+func (n *Terminal) Children() []Node { return nil }
+func (n *Terminal) Accept(v Visitor) { v.VisitTerminal(n) }
+
+// a non-terminal which was replaced by the error recovery. It contains the nodes which
+// could not be parsed.
+type ErrorNode struct {
+	Pos    Span
+	Symbol gold.SymbolId
+	Nodes  []Node
+}
+
+func (n *ErrorNode) NodeSpan() Span   { return n.Pos }
+func (n *ErrorNode) Children() []Node { return n.Nodes }
+func (n *ErrorNode) Accept(v Visitor) { v.VisitError(n) }
+
+`, grammarName(t))
+
+	for idx := range t.Symbols {
+		id := gold.SymbolId(idx)
+		name, ok := names.nonTerminals[id]
+		if !ok {
+			continue
+		}
+		g.printf("// implemented by the nodes of the rules of %s\n", comment(symbolString(t, id)))
+		g.printf("type %s interface {\nNode\nis%s()\n}\n\n", name, name)
+		g.printf("func (n *ErrorNode) is%s() {}\n\n", name)
+	}
+
+	for idx, r := range t.Rules {
+		name, fields := names.rules[idx], names.fields[idx]
+		head := names.nonTerminals[r.Head]
+
+		g.printf("// the rule %s\n", comment(ruleString(t, idx)))
+		g.printf("type %s struct {\nPos Span\n", name)
+		for i, s := range r.Symbols {
+			g.printf("%s %s\n", fields[i], names.fieldType(t, s))
+		}
+		g.printf("}\n\n")
+
+		g.printf("func (n *%s) is%s() {}\n", name, head)
+		g.printf("func (n *%s) NodeSpan() Span { return n.Pos }\n", name)
+		g.printf("func (n *%s) Accept(v Visitor) { v.Visit%s(n) }\n\n", name, name)
+		g.printf("func (n *%s) Children() []Node {\n", name)
+		if len(r.Symbols) == 0 {
+			g.printf("return nil\n}\n\n")
+			continue
+		}
+		g.printf("result := make([]Node, 0, %d)\n", len(r.Symbols))
+		for i := range r.Symbols {
+			g.printf("if n.%s != nil {\nresult = append(result, n.%s)\n}\n", fields[i], fields[i])
+		}
+		g.printf("return result\n}\n\n")
+	}
+
+	g.printf("// is called for each type of node by Node.Accept\n")
+	g.printf("type Visitor interface {\nVisitTerminal(n *Terminal)\nVisitError(n *ErrorNode)\n")
+	for _, name := range names.rules {
+		g.printf("Visit%s(n *%s)\n", name, name)
+	}
+	g.printf("}\n\n")
+
+	g.printf("// a Visitor which does nothing. It can be embedded to implement only some methods of the Visitor.\n")
+	g.printf("type BaseVisitor struct{}\n\n")
+	g.printf("func (BaseVisitor) VisitTerminal(n *Terminal) {}\n")
+	g.printf("func (BaseVisitor) VisitError(n *ErrorNode) {}\n")
+	for _, name := range names.rules {
+		g.printf("func (BaseVisitor) Visit%s(n *%s) {}\n", name, name)
+	}
+	g.printf("\n")
+
+	g.printf(`// converts a syntax tree, which was parsed without trimmed reductions, into the typed nodes
+func Convert(tok *gold.Token) (Node, error) {
+	if tok == nil {
+		return nil, nil
+	}
+	span := Span{Start: tok.Start, End: tok.End}
+	if tok.IsTerminal {
+		return &Terminal{Pos: span, Symbol: tok.Symbol, Text: tok.Text}, nil
+	}
+
+	children := make([]Node, len(tok.Tokens))
+	for i, c := range tok.Tokens {
+		var err error
+		if children[i], err = Convert(c); err != nil {
+			return nil, err
+		}
+	}
+	if tok.IsError {
+		return &ErrorNode{Pos: span, Symbol: tok.Symbol, Nodes: children}, nil
+	}
+	return build(tok.Rule, span, children)
+}
+
+// returns the semantic actions which build the typed nodes while parsing with Parser.Evaluate.
+// The reductions must not be trimmed. Non-terminals which were replaced by the error recovery are nil.
+func Actions() *gold.Actions {
+	return gold.NewActions().
+		OnAnyTerminal(func(t gold.Terminal) (interface{}, error) {
+			return &Terminal{Pos: Span{Start: t.Position, End: t.End}, Symbol: t.Symbol, Text: t.Text}, nil
+		}).
+		OnAnyRule(func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			children := make([]Node, len(values))
+			var span Span
+			first := true
+			for i, v := range values {
+				n, ok := v.(Node)
+				if !ok || n == nil {
+					continue
+				}
+				children[i] = n
+				// empty rules have no position
+				if s := n.NodeSpan(); s != (Span{}) {
+					if first {
+						span.Start = s.Start
+						first = false
+					}
+					span.End = s.End
+				}
+			}
+			return build(rule, span, children)
+		})
+}
+
+func nodeError(rule gold.RuleId, idx int, n Node) error {
+	return fmt.Errorf("the node %%d of the rule %%d has the unexpected type %%T", idx, rule, n)
+}
+
+func asTerminal(n Node) (*Terminal, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(*Terminal)
+	return t, ok
+}
+
+`)
+
+	for idx := range t.Symbols {
+		name, ok := names.nonTerminals[gold.SymbolId(idx)]
+		if !ok {
+			continue
+		}
+		g.printf("func as%s(n Node) (%s, bool) {\nif n == nil {\nreturn nil, true\n}\n", name, name)
+		g.printf("t, ok := n.(%s)\nreturn t, ok\n}\n\n", name)
+	}
+
+	g.printf("// creates the node of a rule from the nodes of its symbols\n")
+	g.printf("func build(rule gold.RuleId, span Span, children []Node) (Node, error) {\n")
+	g.printf("switch rule {\n")
+	for idx, r := range t.Rules {
+		name, fields := names.rules[idx], names.fields[idx]
+		g.printf("case %d: // %s\n", idx, comment(ruleString(t, idx)))
+		g.printf("if len(children) != %d {\nbreak\n}\n", len(r.Symbols))
+		g.printf("n := &%s{Pos: span}\n", name)
+		if len(r.Symbols) > 0 {
+			g.printf("var ok bool\n")
+		}
+		for i, s := range r.Symbols {
+			as := "asTerminal"
+			if t.Symbols[s].Kind == gold.KindNonTerminal {
+				as = "as" + names.nonTerminals[s]
+			}
+			g.printf("if n.%s, ok = %s(children[%d]); !ok {\nreturn nil, nodeError(rule, %d, children[%d])\n}\n", fields[i], as, i, i, i)
+		}
+		g.printf("return n, nil\n")
+	}
+	g.printf("default:\nreturn nil, fmt.Errorf(\"unknown rule %%d\", rule)\n}\n")
+	g.printf("return nil, fmt.Errorf(\"the rule %%d has %%d nodes\", rule, len(children))\n}\n")
+}
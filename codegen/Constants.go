@@ -0,0 +1,119 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+
+	"github.com/boombuler/gold"
+)
+
+// Generates the Go source of named constants for all symbols and rules of the grammar.
+// The symbols are named Sym<Name> and the rules Rule<Head><Terminals>, for example SymIdentifier
+// and RuleExprPlus. The constants have the types gold.SymbolId and gold.RuleId, so they can be
+// compared with Token.Symbol and Token.Rule directly. The generated functions SymbolName and
+// RuleName return the names of the ids and VerifyGrammar checks that a loaded grammar still
+// matches the constants.
+func GenerateConstants(w io.Writer, t *gold.Tables, cfg Config) error {
+	if err := t.Validate().Err(); err != nil {
+		return err
+	}
+	if cfg.Package == "" {
+		return fmt.Errorf("codegen: no package name")
+	}
+
+	g := new(generator)
+	g.header(cfg, "fmt", "", "github.com/boombuler/gold")
+	g.constants(t)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("codegen: unable to format the generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// returns a description of the grammar for comments
+func grammarName(t *gold.Tables) string {
+	if n := strings.Join(strings.Fields(t.Property("Name")), " "); n != "" {
+		return "the " + n + " grammar"
+	}
+	return "the grammar"
+}
+
+func (g *generator) constants(t *gold.Tables) {
+	name := grammarName(t)
+
+	g.printf("// the symbols of %s\n", name)
+	g.printf("const (\n")
+	for idx, n := range symbolNames(t) {
+		g.printf("Sym%s gold.SymbolId = %d // %s\n", n, idx, comment(symbolString(t, gold.SymbolId(idx))))
+	}
+	g.printf(")\n\n")
+
+	g.printf("var symbolNames = [...]string{\n")
+	for idx := range t.Symbols {
+		g.printf("%q,\n", symbolString(t, gold.SymbolId(idx)))
+	}
+	g.printf("}\n\n")
+
+	g.printf(`// returns the name of the symbol, non-terminals are enclosed in angle brackets
+func SymbolName(id gold.SymbolId) string {
+	if int(id) < len(symbolNames) {
+		return symbolNames[id]
+	}
+	return fmt.Sprintf("SymbolId(%%d)", id)
+}
+
+`)
+
+	g.printf("// the rules of %s\n", name)
+	g.printf("const (\n")
+	for idx, n := range ruleNames(t) {
+		g.printf("Rule%s gold.RuleId = %d // %s\n", n, idx, comment(ruleString(t, idx)))
+	}
+	g.printf(")\n\n")
+
+	g.printf("var ruleNames = [...]string{\n")
+	for idx := range t.Rules {
+		g.printf("%q,\n", ruleString(t, idx))
+	}
+	g.printf("}\n\n")
+
+	g.printf(`// returns the rule in BNF notation
+func RuleName(id gold.RuleId) string {
+	if int(id) < len(ruleNames) {
+		return ruleNames[id]
+	}
+	return fmt.Sprintf("RuleId(%%d)", id)
+}
+
+// checks that the symbols and rules of the grammar match the constants, for example after
+// the grammar file was rebuilt.
+func VerifyGrammar(g gold.Grammar) error {
+	symbols, rules := g.Symbols(), g.Rules()
+	if len(symbols) != len(symbolNames) || len(rules) != len(ruleNames) {
+		return fmt.Errorf("the grammar has %%d symbols and %%d rules, but %%d symbols and %%d rules are expected",
+			len(symbols), len(rules), len(symbolNames), len(ruleNames))
+	}
+	for i, s := range symbols {
+		if s.String() != symbolNames[i] {
+			return fmt.Errorf("the symbol %%d of the grammar is %%s, but %%s is expected", i, s, symbolNames[i])
+		}
+	}
+	for i, r := range rules {
+		if r.String() != ruleNames[i] {
+			return fmt.Errorf("the rule %%d of the grammar is %%s, but %%s is expected", i, r, ruleNames[i])
+		}
+	}
+	return nil
+}
+`)
+}
+
+// returns the text as single line for comments
+func comment(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,224 @@
+// Package codegen generates Go source code which contains the tables of a grammar as static data,
+// so that a parser can be created without loading a grammar file.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+
+	"github.com/boombuler/gold"
+)
+
+// the options of the generated code
+type Config struct {
+	// the name of the generated package
+	Package string
+	// the name of the grammar file the code is generated from, mentioned in the header comment
+	Source string
+}
+
+// Generates the Go source of a package which contains the tables and a function returning the
+// parser for them. Returns an error if the tables are not valid.
+func Generate(w io.Writer, t *gold.Tables, cfg Config) error {
+	if err := t.Validate().Err(); err != nil {
+		return err
+	}
+	if cfg.Package == "" {
+		return fmt.Errorf("codegen: no package name")
+	}
+
+	g := new(generator)
+	g.header(cfg, "sync", "", "github.com/boombuler/gold")
+	g.tables(t)
+	g.parser(t)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("codegen: unable to format the generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type generator struct {
+	buf bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// writes the header of a generated file. An empty import separates the import groups.
+func (g *generator) header(cfg Config, imports ...string) {
+	g.printf("// Code generated by goldgen")
+	if cfg.Source != "" {
+		g.printf(" from %s", cfg.Source)
+	}
+	g.printf(". DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", cfg.Package)
+	g.printf("import (\n")
+	for _, imp := range imports {
+		if imp == "" {
+			g.printf("\n")
+		} else {
+			g.printf("%q\n", imp)
+		}
+	}
+	g.printf(")\n\n")
+}
+
+var kindNames = map[gold.SymbolKind]string{
+	gold.KindNonTerminal: "gold.KindNonTerminal",
+	gold.KindTerminal:    "gold.KindTerminal",
+	gold.KindNoise:       "gold.KindNoise",
+	gold.KindEnd:         "gold.KindEnd",
+	gold.KindGroupStart:  "gold.KindGroupStart",
+	gold.KindGroupEnd:    "gold.KindGroupEnd",
+	gold.KindCommentLine: "gold.KindCommentLine",
+	gold.KindError:       "gold.KindError",
+}
+
+var actionNames = map[gold.ActionKind]string{
+	gold.ActionShift:  "gold.ActionShift",
+	gold.ActionReduce: "gold.ActionReduce",
+	gold.ActionGoto:   "gold.ActionGoto",
+	gold.ActionAccept: "gold.ActionAccept",
+}
+
+func advanceName(m gold.AdvanceMode) string {
+	if m == gold.AdvanceToken {
+		return "gold.AdvanceToken"
+	}
+	return "gold.AdvanceCharacter"
+}
+
+func endingName(m gold.EndingMode) string {
+	if m == gold.EndingOpen {
+		return "gold.EndingOpen"
+	}
+	return "gold.EndingClosed"
+}
+
+func (g *generator) tables(t *gold.Tables) {
+	g.printf("var tables = &gold.Tables{\n")
+
+	g.printf("Properties: []gold.TableProperty{\n")
+	for _, p := range t.Properties {
+		g.printf("{Name: %q, Value: %q},\n", p.Name, p.Value)
+	}
+	g.printf("},\n")
+	g.printf("CaseSensitive: %v,\n", t.CaseSensitive)
+	g.printf("StartSymbol: %d,\n", t.StartSymbol)
+	g.printf("InitialDFAState: %d,\n", t.InitialDFAState)
+	g.printf("InitialLRState: %d,\n", t.InitialLRState)
+
+	g.printf("CharSets: []gold.TableCharSet{\n")
+	for _, cs := range t.CharSets {
+		g.printf("{Plane: %d, Ranges: []gold.TableCharRange{", cs.Plane)
+		for i, r := range cs.Ranges {
+			if i > 0 {
+				g.printf(", ")
+			}
+			g.printf("{Start: 0x%04X, End: 0x%04X}", r.Start, r.End)
+		}
+		g.printf("}},\n")
+	}
+	g.printf("},\n")
+
+	g.printf("Symbols: []gold.TableSymbol{\n")
+	for idx, s := range t.Symbols {
+		g.printf("%d: {Name: %q, Kind: %s},\n", idx, s.Name, kindNames[s.Kind])
+	}
+	g.printf("},\n")
+
+	if len(t.Groups) > 0 {
+		g.printf("Groups: []gold.TableGroup{\n")
+		for idx, grp := range t.Groups {
+			g.printf("%d: {Name: %q, Container: %d, Start: %d, End: %d, AdvanceMode: %s, EndingMode: %s",
+				idx, grp.Name, grp.Container, grp.Start, grp.End, advanceName(grp.AdvanceMode), endingName(grp.EndingMode))
+			if len(grp.Nesting) > 0 {
+				g.printf(", Nesting: []gold.GroupId{")
+				for i, n := range grp.Nesting {
+					if i > 0 {
+						g.printf(", ")
+					}
+					g.printf("%d", n)
+				}
+				g.printf("}")
+			}
+			g.printf("},\n")
+		}
+		g.printf("},\n")
+	}
+
+	g.printf("Rules: []gold.TableRule{\n")
+	for idx, r := range t.Rules {
+		g.printf("// %s\n", comment(ruleString(t, idx)))
+		g.printf("%d: {Head: %d, Symbols: []gold.SymbolId{", idx, r.Head)
+		for i, s := range r.Symbols {
+			if i > 0 {
+				g.printf(", ")
+			}
+			g.printf("%d", s)
+		}
+		g.printf("}},\n")
+	}
+	g.printf("},\n")
+
+	g.printf("DFAStates: []gold.TableDFAState{\n")
+	for idx, s := range t.DFAStates {
+		g.printf("%d: {", idx)
+		if s.Accept {
+			g.printf("Accept: true, AcceptSymbol: %d, ", s.AcceptSymbol)
+		}
+		g.printf("Edges: []gold.TableDFAEdge{")
+		for i, e := range s.Edges {
+			if i > 0 {
+				g.printf(", ")
+			}
+			g.printf("{CharSet: %d, Target: %d}", e.CharSet, e.Target)
+		}
+		g.printf("}},\n")
+	}
+	g.printf("},\n")
+
+	g.printf("LRStates: []gold.TableLRState{\n")
+	for idx, s := range t.LRStates {
+		g.printf("%d: {Actions: []gold.TableLRAction{\n", idx)
+		for _, a := range s.Actions {
+			g.printf("{Symbol: %d, Action: %s, Target: %d}, // %s\n", a.Symbol, actionNames[a.Action], a.Target, comment(symbolString(t, a.Symbol)))
+		}
+		g.printf("}},\n")
+	}
+	g.printf("},\n")
+
+	g.printf("}\n\n")
+}
+
+func (g *generator) parser(t *gold.Tables) {
+	g.printf(`var (
+	parserOnce sync.Once
+	parser     gold.Parser
+)
+
+// returns the parser for %s. The parser is created on the first call and can be
+// used concurrently.
+func Parser() gold.Parser {
+	parserOnce.Do(func() {
+		var err error
+		if parser, err = gold.NewParserFromTables(tables); err != nil {
+			// the tables are validated when the code is generated
+			panic(err)
+		}
+	})
+	return parser
+}
+
+// returns a copy of the grammar tables
+func Tables() *gold.Tables {
+	return tables.Clone()
+}
+`, grammarName(t))
+}
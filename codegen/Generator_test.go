@@ -0,0 +1,75 @@
+package codegen_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/boombuler/gold"
+	"github.com/boombuler/gold/codegen"
+)
+
+func loadTables(t *testing.T) *gold.Tables {
+	t.Helper()
+	f, err := os.Open("../testdata/calc.egt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tables, err := gold.ReadTables(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tables
+}
+
+// compares the generated code with the file of the generated package in internal/calc, which is
+// compiled and tested there
+func checkGolden(t *testing.T, name string, src []byte) {
+	t.Helper()
+	want, err := os.ReadFile("internal/calc/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("the generated code differs from internal/calc/%s, run go generate ./codegen/...:\n%s", name, src)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := codegen.Generate(buf, loadTables(t), codegen.Config{Package: "calc", Source: "calc.egt"}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "tables.go", buf.Bytes())
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if err := codegen.Generate(new(bytes.Buffer), loadTables(t), codegen.Config{}); err == nil {
+		t.Error("generated the code without a package name")
+	}
+
+	tables := loadTables(t)
+	tables.InitialLRState = uint16(len(tables.LRStates))
+	var ve *gold.ValidationError
+	if err := codegen.Generate(new(bytes.Buffer), tables, codegen.Config{Package: "calc"}); !errors.As(err, &ve) {
+		t.Errorf("got %v, want a ValidationError", err)
+	}
+}
+
+func TestGenerateConstants(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := codegen.GenerateConstants(buf, loadTables(t), codegen.Config{Package: "calc", Source: "calc.egt"}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "constants.go", buf.Bytes())
+}
+
+func TestGenerateAST(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := codegen.GenerateAST(buf, loadTables(t), codegen.Config{Package: "calc", Source: "calc.egt"}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "ast.go", buf.Bytes())
+}
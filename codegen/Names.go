@@ -0,0 +1,124 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/boombuler/gold"
+)
+
+// the names of punctuation characters in Go identifiers
+var punctuationNames = map[rune]string{
+	'!': "Exclam", '"': "Quote", '#': "Num", '$': "Dollar", '%': "Percent", '&': "Amp", '\'': "Apost",
+	'(': "LParen", ')': "RParen", '*': "Times", '+': "Plus", ',': "Comma", '-': "Minus", '.': "Dot",
+	'/': "Div", ':': "Colon", ';': "Semi", '<': "Lt", '=': "Eq", '>': "Gt", '?': "Question", '@': "At",
+	'[': "LBracket", '\\': "Backslash", ']': "RBracket", '^': "Caret", '`': "Backtick", '{': "LBrace",
+	'|': "Pipe", '}': "RBrace", '~': "Tilde",
+}
+
+// converts the name of a symbol to a part of an exported Go identifier.
+// Words are capitalized and punctuation characters are spelled out, so "Id Head" becomes
+// "IdHead" and "==" becomes "EqEq".
+func goName(name string) string {
+	buf := new(strings.Builder)
+	startWord := true
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if startWord {
+				r = unicode.ToUpper(r)
+			}
+			buf.WriteRune(r)
+			startWord = false
+		case punctuationNames[r] != "":
+			buf.WriteString(punctuationNames[r])
+			startWord = true
+		case r == '_' || unicode.IsSpace(r):
+			startWord = true
+		default:
+			fmt.Fprintf(buf, "U%04X", r)
+			startWord = true
+		}
+	}
+	return buf.String()
+}
+
+// makes the names unique by appending numbers to all but the first of equal names
+func uniqueNames(names []string) []string {
+	used := make(map[string]bool)
+	for _, n := range names {
+		used[n] = true
+	}
+	seen := make(map[string]bool)
+	result := make([]string, len(names))
+	for i, n := range names {
+		name := n
+		// the numbered name must not collide with any other name
+		for num := 2; seen[name] || (name != n && used[name]); num++ {
+			name = fmt.Sprintf("%s%d", n, num)
+		}
+		seen[name] = true
+		result[i] = name
+	}
+	return result
+}
+
+// returns the names of the symbols for the generated constants without prefix
+func symbolNames(t *gold.Tables) []string {
+	names := make([]string, len(t.Symbols))
+	for idx, s := range t.Symbols {
+		names[idx] = goName(s.Name)
+	}
+	return uniqueNames(names)
+}
+
+// returns the names of the rules for the generated constants without prefix. A rule is named by its
+// head and the terminals of its body, or all symbols of the body if that is ambiguous.
+func ruleNames(t *gold.Tables) []string {
+	names := make([]string, len(t.Rules))
+	full := make([]string, len(t.Rules))
+	count := make(map[string]int)
+	for idx, r := range t.Rules {
+		head := goName(t.Symbols[r.Head].Name)
+		var terminals, all []string
+		for _, s := range r.Symbols {
+			n := goName(t.Symbols[s].Name)
+			all = append(all, n)
+			if t.Symbols[s].Kind != gold.KindNonTerminal {
+				terminals = append(terminals, n)
+			}
+		}
+		if len(all) == 0 {
+			all = []string{"Empty"}
+		}
+		if len(terminals) == 0 {
+			terminals = all
+		}
+		names[idx] = head + strings.Join(terminals, "")
+		full[idx] = head + strings.Join(all, "")
+		count[names[idx]]++
+	}
+	for idx := range names {
+		if count[names[idx]] > 1 {
+			names[idx] = full[idx]
+		}
+	}
+	return uniqueNames(names)
+}
+
+// returns the symbol as it is printed by gold.Symbol
+func symbolString(t *gold.Tables, id gold.SymbolId) string {
+	s := t.Symbols[id]
+	return (&gold.Symbol{Id: id, Name: s.Name, Kind: s.Kind}).String()
+}
+
+// returns the rule as it is printed by gold.Rule
+func ruleString(t *gold.Tables, id int) string {
+	r := t.Rules[id]
+	rule := &gold.Rule{Id: gold.RuleId(id), Head: &gold.Symbol{Name: t.Symbols[r.Head].Name, Kind: t.Symbols[r.Head].Kind}}
+	for _, s := range r.Symbols {
+		rule.Symbols = append(rule.Symbols, &gold.Symbol{Name: t.Symbols[s].Name, Kind: t.Symbols[s].Kind})
+	}
+	return rule.String()
+}
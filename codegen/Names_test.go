@@ -0,0 +1,82 @@
+package codegen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/boombuler/gold"
+)
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Identifier", "Identifier"},
+		{"Id Head", "IdHead"},
+		{"set_name", "SetName"},
+		{"==", "EqEq"},
+		{"<=", "LtEq"},
+		{"print", "Print"},
+		{"2nd", "2nd"},
+		{"€", "U20AC"},
+	}
+	for _, tt := range tests {
+		if got := goName(tt.name); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	got := uniqueNames([]string{"A", "B", "A", "A2", "A"})
+	if want := []string{"A", "B", "A3", "A2", "A4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRuleNames(t *testing.T) {
+	sym := func(name string, kind gold.SymbolKind) gold.TableSymbol {
+		return gold.TableSymbol{Name: name, Kind: kind}
+	}
+	tables := &gold.Tables{
+		Symbols: []gold.TableSymbol{
+			sym("EOF", gold.KindEnd), sym("Error", gold.KindError), sym("+", gold.KindTerminal),
+			sym("Expr", gold.KindNonTerminal), sym("Term", gold.KindNonTerminal), sym("Factor", gold.KindNonTerminal),
+		},
+		Rules: []gold.TableRule{
+			{Head: 3, Symbols: []gold.SymbolId{3, 2, 4}},
+			// has the same terminals as the first rule
+			{Head: 3, Symbols: []gold.SymbolId{3, 2, 5}},
+			{Head: 3, Symbols: []gold.SymbolId{4}},
+			{Head: 4, Symbols: nil},
+		},
+	}
+	got := ruleNames(tables)
+	if want := []string{"ExprExprPlusTerm", "ExprExprPlusFactor", "ExprTerm", "TermEmpty"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestASTNames(t *testing.T) {
+	tables := &gold.Tables{
+		Symbols: []gold.TableSymbol{
+			{Name: "EOF", Kind: gold.KindEnd}, {Name: "Error", Kind: gold.KindError}, {Name: "Pos", Kind: gold.KindTerminal},
+			{Name: "Node", Kind: gold.KindNonTerminal}, {Name: "Visitor", Kind: gold.KindNonTerminal},
+		},
+		Rules: []gold.TableRule{
+			{Head: 3, Symbols: []gold.SymbolId{2, 4, 2}},
+			{Head: 4, Symbols: []gold.SymbolId{2}},
+		},
+	}
+	// the names of the generated code are not used for the types and the fields
+	names := newASTNames(tables)
+	if got := names.nonTerminals; got[3] != "Node2" || got[4] != "Visitor2" {
+		t.Errorf("got the non-terminals %q", got)
+	}
+	if want := []string{"NodePosPos", "VisitorPos"}; !reflect.DeepEqual(names.rules, want) {
+		t.Errorf("got the rules %q, want %q", names.rules, want)
+	}
+	if want := [][]string{{"Pos2", "Visitor", "Pos3"}, {"Pos2"}}; !reflect.DeepEqual(names.fields, want) {
+		t.Errorf("got the fields %q, want %q", names.fields, want)
+	}
+}
@@ -0,0 +1,790 @@
+// Code generated by goldgen from calc.egt. DO NOT EDIT.
+
+package calc
+
+import (
+	"fmt"
+
+	"github.com/boombuler/gold"
+)
+
+// a node of the typed syntax tree of the Calc grammar
+type Node interface {
+	// returns the position of the node in the source
+	NodeSpan() Span
+	// returns the child nodes which are not nil
+	Children() []Node
+	// calls the method of the visitor for the type of the node
+	Accept(v Visitor)
+}
+
+// the position of a node in the source
+type Span struct {
+	Start gold.TextPosition
+	End   gold.TextPosition
+}
+
+// a terminal of the syntax tree
+type Terminal struct {
+	Pos    Span
+	Symbol gold.SymbolId
+	Text   string
+}
+
+func (n *Terminal) NodeSpan() Span   { return n.Pos }
+func (n *Terminal) Children() []Node { return nil }
+func (n *Terminal) Accept(v Visitor) { v.VisitTerminal(n) }
+
+// a non-terminal which was replaced by the error recovery. It contains the nodes which
+// could not be parsed.
+type ErrorNode struct {
+	Pos    Span
+	Symbol gold.SymbolId
+	Nodes  []Node
+}
+
+func (n *ErrorNode) NodeSpan() Span   { return n.Pos }
+func (n *ErrorNode) Children() []Node { return n.Nodes }
+func (n *ErrorNode) Accept(v Visitor) { v.VisitError(n) }
+
+// implemented by the nodes of the rules of <Expr>
+type Expr interface {
+	Node
+	isExpr()
+}
+
+func (n *ErrorNode) isExpr() {}
+
+// implemented by the nodes of the rules of <Factor>
+type Factor interface {
+	Node
+	isFactor()
+}
+
+func (n *ErrorNode) isFactor() {}
+
+// implemented by the nodes of the rules of <Program>
+type Program interface {
+	Node
+	isProgram()
+}
+
+func (n *ErrorNode) isProgram() {}
+
+// implemented by the nodes of the rules of <Stmt>
+type Stmt interface {
+	Node
+	isStmt()
+}
+
+func (n *ErrorNode) isStmt() {}
+
+// implemented by the nodes of the rules of <Stmts>
+type Stmts interface {
+	Node
+	isStmts()
+}
+
+func (n *ErrorNode) isStmts() {}
+
+// implemented by the nodes of the rules of <Term>
+type Term interface {
+	Node
+	isTerm()
+}
+
+func (n *ErrorNode) isTerm() {}
+
+// the rule <Program> ::= <Stmts>
+type ProgramStmts struct {
+	Pos   Span
+	Stmts Stmts
+}
+
+func (n *ProgramStmts) isProgram()       {}
+func (n *ProgramStmts) NodeSpan() Span   { return n.Pos }
+func (n *ProgramStmts) Accept(v Visitor) { v.VisitProgramStmts(n) }
+
+func (n *ProgramStmts) Children() []Node {
+	result := make([]Node, 0, 1)
+	if n.Stmts != nil {
+		result = append(result, n.Stmts)
+	}
+	return result
+}
+
+// the rule <Stmts> ::= <Stmt> <Stmts>
+type StmtsStmtStmts struct {
+	Pos   Span
+	Stmt  Stmt
+	Stmts Stmts
+}
+
+func (n *StmtsStmtStmts) isStmts()         {}
+func (n *StmtsStmtStmts) NodeSpan() Span   { return n.Pos }
+func (n *StmtsStmtStmts) Accept(v Visitor) { v.VisitStmtsStmtStmts(n) }
+
+func (n *StmtsStmtStmts) Children() []Node {
+	result := make([]Node, 0, 2)
+	if n.Stmt != nil {
+		result = append(result, n.Stmt)
+	}
+	if n.Stmts != nil {
+		result = append(result, n.Stmts)
+	}
+	return result
+}
+
+// the rule <Stmts> ::=
+type StmtsEmpty struct {
+	Pos Span
+}
+
+func (n *StmtsEmpty) isStmts()         {}
+func (n *StmtsEmpty) NodeSpan() Span   { return n.Pos }
+func (n *StmtsEmpty) Accept(v Visitor) { v.VisitStmtsEmpty(n) }
+
+func (n *StmtsEmpty) Children() []Node {
+	return nil
+}
+
+// the rule <Stmt> ::= Identifier = <Expr> ;
+type StmtIdentifierEqSemi struct {
+	Pos        Span
+	Identifier *Terminal
+	Eq         *Terminal
+	Expr       Expr
+	Semi       *Terminal
+}
+
+func (n *StmtIdentifierEqSemi) isStmt()          {}
+func (n *StmtIdentifierEqSemi) NodeSpan() Span   { return n.Pos }
+func (n *StmtIdentifierEqSemi) Accept(v Visitor) { v.VisitStmtIdentifierEqSemi(n) }
+
+func (n *StmtIdentifierEqSemi) Children() []Node {
+	result := make([]Node, 0, 4)
+	if n.Identifier != nil {
+		result = append(result, n.Identifier)
+	}
+	if n.Eq != nil {
+		result = append(result, n.Eq)
+	}
+	if n.Expr != nil {
+		result = append(result, n.Expr)
+	}
+	if n.Semi != nil {
+		result = append(result, n.Semi)
+	}
+	return result
+}
+
+// the rule <Stmt> ::= print <Expr> ;
+type StmtPrintSemi struct {
+	Pos   Span
+	Print *Terminal
+	Expr  Expr
+	Semi  *Terminal
+}
+
+func (n *StmtPrintSemi) isStmt()          {}
+func (n *StmtPrintSemi) NodeSpan() Span   { return n.Pos }
+func (n *StmtPrintSemi) Accept(v Visitor) { v.VisitStmtPrintSemi(n) }
+
+func (n *StmtPrintSemi) Children() []Node {
+	result := make([]Node, 0, 3)
+	if n.Print != nil {
+		result = append(result, n.Print)
+	}
+	if n.Expr != nil {
+		result = append(result, n.Expr)
+	}
+	if n.Semi != nil {
+		result = append(result, n.Semi)
+	}
+	return result
+}
+
+// the rule <Expr> ::= <Expr> + <Term>
+type ExprPlus struct {
+	Pos  Span
+	Expr Expr
+	Plus *Terminal
+	Term Term
+}
+
+func (n *ExprPlus) isExpr()          {}
+func (n *ExprPlus) NodeSpan() Span   { return n.Pos }
+func (n *ExprPlus) Accept(v Visitor) { v.VisitExprPlus(n) }
+
+func (n *ExprPlus) Children() []Node {
+	result := make([]Node, 0, 3)
+	if n.Expr != nil {
+		result = append(result, n.Expr)
+	}
+	if n.Plus != nil {
+		result = append(result, n.Plus)
+	}
+	if n.Term != nil {
+		result = append(result, n.Term)
+	}
+	return result
+}
+
+// the rule <Expr> ::= <Expr> - <Term>
+type ExprMinus struct {
+	Pos   Span
+	Expr  Expr
+	Minus *Terminal
+	Term  Term
+}
+
+func (n *ExprMinus) isExpr()          {}
+func (n *ExprMinus) NodeSpan() Span   { return n.Pos }
+func (n *ExprMinus) Accept(v Visitor) { v.VisitExprMinus(n) }
+
+func (n *ExprMinus) Children() []Node {
+	result := make([]Node, 0, 3)
+	if n.Expr != nil {
+		result = append(result, n.Expr)
+	}
+	if n.Minus != nil {
+		result = append(result, n.Minus)
+	}
+	if n.Term != nil {
+		result = append(result, n.Term)
+	}
+	return result
+}
+
+// the rule <Expr> ::= <Term>
+type ExprTerm struct {
+	Pos  Span
+	Term Term
+}
+
+func (n *ExprTerm) isExpr()          {}
+func (n *ExprTerm) NodeSpan() Span   { return n.Pos }
+func (n *ExprTerm) Accept(v Visitor) { v.VisitExprTerm(n) }
+
+func (n *ExprTerm) Children() []Node {
+	result := make([]Node, 0, 1)
+	if n.Term != nil {
+		result = append(result, n.Term)
+	}
+	return result
+}
+
+// the rule <Term> ::= <Term> * <Factor>
+type TermTimes struct {
+	Pos    Span
+	Term   Term
+	Times  *Terminal
+	Factor Factor
+}
+
+func (n *TermTimes) isTerm()          {}
+func (n *TermTimes) NodeSpan() Span   { return n.Pos }
+func (n *TermTimes) Accept(v Visitor) { v.VisitTermTimes(n) }
+
+func (n *TermTimes) Children() []Node {
+	result := make([]Node, 0, 3)
+	if n.Term != nil {
+		result = append(result, n.Term)
+	}
+	if n.Times != nil {
+		result = append(result, n.Times)
+	}
+	if n.Factor != nil {
+		result = append(result, n.Factor)
+	}
+	return result
+}
+
+// the rule <Term> ::= <Term> / <Factor>
+type TermDiv struct {
+	Pos    Span
+	Term   Term
+	Div    *Terminal
+	Factor Factor
+}
+
+func (n *TermDiv) isTerm()          {}
+func (n *TermDiv) NodeSpan() Span   { return n.Pos }
+func (n *TermDiv) Accept(v Visitor) { v.VisitTermDiv(n) }
+
+func (n *TermDiv) Children() []Node {
+	result := make([]Node, 0, 3)
+	if n.Term != nil {
+		result = append(result, n.Term)
+	}
+	if n.Div != nil {
+		result = append(result, n.Div)
+	}
+	if n.Factor != nil {
+		result = append(result, n.Factor)
+	}
+	return result
+}
+
+// the rule <Term> ::= <Factor>
+type TermFactor struct {
+	Pos    Span
+	Factor Factor
+}
+
+func (n *TermFactor) isTerm()          {}
+func (n *TermFactor) NodeSpan() Span   { return n.Pos }
+func (n *TermFactor) Accept(v Visitor) { v.VisitTermFactor(n) }
+
+func (n *TermFactor) Children() []Node {
+	result := make([]Node, 0, 1)
+	if n.Factor != nil {
+		result = append(result, n.Factor)
+	}
+	return result
+}
+
+// the rule <Factor> ::= Number
+type FactorNumber struct {
+	Pos    Span
+	Number *Terminal
+}
+
+func (n *FactorNumber) isFactor()        {}
+func (n *FactorNumber) NodeSpan() Span   { return n.Pos }
+func (n *FactorNumber) Accept(v Visitor) { v.VisitFactorNumber(n) }
+
+func (n *FactorNumber) Children() []Node {
+	result := make([]Node, 0, 1)
+	if n.Number != nil {
+		result = append(result, n.Number)
+	}
+	return result
+}
+
+// the rule <Factor> ::= Identifier
+type FactorIdentifier struct {
+	Pos        Span
+	Identifier *Terminal
+}
+
+func (n *FactorIdentifier) isFactor()        {}
+func (n *FactorIdentifier) NodeSpan() Span   { return n.Pos }
+func (n *FactorIdentifier) Accept(v Visitor) { v.VisitFactorIdentifier(n) }
+
+func (n *FactorIdentifier) Children() []Node {
+	result := make([]Node, 0, 1)
+	if n.Identifier != nil {
+		result = append(result, n.Identifier)
+	}
+	return result
+}
+
+// the rule <Factor> ::= ( <Expr> )
+type FactorLParenRParen struct {
+	Pos    Span
+	LParen *Terminal
+	Expr   Expr
+	RParen *Terminal
+}
+
+func (n *FactorLParenRParen) isFactor()        {}
+func (n *FactorLParenRParen) NodeSpan() Span   { return n.Pos }
+func (n *FactorLParenRParen) Accept(v Visitor) { v.VisitFactorLParenRParen(n) }
+
+func (n *FactorLParenRParen) Children() []Node {
+	result := make([]Node, 0, 3)
+	if n.LParen != nil {
+		result = append(result, n.LParen)
+	}
+	if n.Expr != nil {
+		result = append(result, n.Expr)
+	}
+	if n.RParen != nil {
+		result = append(result, n.RParen)
+	}
+	return result
+}
+
+// the rule <Factor> ::= - <Factor>
+type FactorMinus struct {
+	Pos    Span
+	Minus  *Terminal
+	Factor Factor
+}
+
+func (n *FactorMinus) isFactor()        {}
+func (n *FactorMinus) NodeSpan() Span   { return n.Pos }
+func (n *FactorMinus) Accept(v Visitor) { v.VisitFactorMinus(n) }
+
+func (n *FactorMinus) Children() []Node {
+	result := make([]Node, 0, 2)
+	if n.Minus != nil {
+		result = append(result, n.Minus)
+	}
+	if n.Factor != nil {
+		result = append(result, n.Factor)
+	}
+	return result
+}
+
+// is called for each type of node by Node.Accept
+type Visitor interface {
+	VisitTerminal(n *Terminal)
+	VisitError(n *ErrorNode)
+	VisitProgramStmts(n *ProgramStmts)
+	VisitStmtsStmtStmts(n *StmtsStmtStmts)
+	VisitStmtsEmpty(n *StmtsEmpty)
+	VisitStmtIdentifierEqSemi(n *StmtIdentifierEqSemi)
+	VisitStmtPrintSemi(n *StmtPrintSemi)
+	VisitExprPlus(n *ExprPlus)
+	VisitExprMinus(n *ExprMinus)
+	VisitExprTerm(n *ExprTerm)
+	VisitTermTimes(n *TermTimes)
+	VisitTermDiv(n *TermDiv)
+	VisitTermFactor(n *TermFactor)
+	VisitFactorNumber(n *FactorNumber)
+	VisitFactorIdentifier(n *FactorIdentifier)
+	VisitFactorLParenRParen(n *FactorLParenRParen)
+	VisitFactorMinus(n *FactorMinus)
+}
+
+// a Visitor which does nothing. It can be embedded to implement only some methods of the Visitor.
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitTerminal(n *Terminal)                         {}
+func (BaseVisitor) VisitError(n *ErrorNode)                           {}
+func (BaseVisitor) VisitProgramStmts(n *ProgramStmts)                 {}
+func (BaseVisitor) VisitStmtsStmtStmts(n *StmtsStmtStmts)             {}
+func (BaseVisitor) VisitStmtsEmpty(n *StmtsEmpty)                     {}
+func (BaseVisitor) VisitStmtIdentifierEqSemi(n *StmtIdentifierEqSemi) {}
+func (BaseVisitor) VisitStmtPrintSemi(n *StmtPrintSemi)               {}
+func (BaseVisitor) VisitExprPlus(n *ExprPlus)                         {}
+func (BaseVisitor) VisitExprMinus(n *ExprMinus)                       {}
+func (BaseVisitor) VisitExprTerm(n *ExprTerm)                         {}
+func (BaseVisitor) VisitTermTimes(n *TermTimes)                       {}
+func (BaseVisitor) VisitTermDiv(n *TermDiv)                           {}
+func (BaseVisitor) VisitTermFactor(n *TermFactor)                     {}
+func (BaseVisitor) VisitFactorNumber(n *FactorNumber)                 {}
+func (BaseVisitor) VisitFactorIdentifier(n *FactorIdentifier)         {}
+func (BaseVisitor) VisitFactorLParenRParen(n *FactorLParenRParen)     {}
+func (BaseVisitor) VisitFactorMinus(n *FactorMinus)                   {}
+
+// converts a syntax tree, which was parsed without trimmed reductions, into the typed nodes
+func Convert(tok *gold.Token) (Node, error) {
+	if tok == nil {
+		return nil, nil
+	}
+	span := Span{Start: tok.Start, End: tok.End}
+	if tok.IsTerminal {
+		return &Terminal{Pos: span, Symbol: tok.Symbol, Text: tok.Text}, nil
+	}
+
+	children := make([]Node, len(tok.Tokens))
+	for i, c := range tok.Tokens {
+		var err error
+		if children[i], err = Convert(c); err != nil {
+			return nil, err
+		}
+	}
+	if tok.IsError {
+		return &ErrorNode{Pos: span, Symbol: tok.Symbol, Nodes: children}, nil
+	}
+	return build(tok.Rule, span, children)
+}
+
+// returns the semantic actions which build the typed nodes while parsing with Parser.Evaluate.
+// The reductions must not be trimmed. Non-terminals which were replaced by the error recovery are nil.
+func Actions() *gold.Actions {
+	return gold.NewActions().
+		OnAnyTerminal(func(t gold.Terminal) (interface{}, error) {
+			return &Terminal{Pos: Span{Start: t.Position, End: t.End}, Symbol: t.Symbol, Text: t.Text}, nil
+		}).
+		OnAnyRule(func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+			children := make([]Node, len(values))
+			var span Span
+			first := true
+			for i, v := range values {
+				n, ok := v.(Node)
+				if !ok || n == nil {
+					continue
+				}
+				children[i] = n
+				// empty rules have no position
+				if s := n.NodeSpan(); s != (Span{}) {
+					if first {
+						span.Start = s.Start
+						first = false
+					}
+					span.End = s.End
+				}
+			}
+			return build(rule, span, children)
+		})
+}
+
+func nodeError(rule gold.RuleId, idx int, n Node) error {
+	return fmt.Errorf("the node %d of the rule %d has the unexpected type %T", idx, rule, n)
+}
+
+func asTerminal(n Node) (*Terminal, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(*Terminal)
+	return t, ok
+}
+
+func asExpr(n Node) (Expr, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(Expr)
+	return t, ok
+}
+
+func asFactor(n Node) (Factor, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(Factor)
+	return t, ok
+}
+
+func asProgram(n Node) (Program, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(Program)
+	return t, ok
+}
+
+func asStmt(n Node) (Stmt, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(Stmt)
+	return t, ok
+}
+
+func asStmts(n Node) (Stmts, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(Stmts)
+	return t, ok
+}
+
+func asTerm(n Node) (Term, bool) {
+	if n == nil {
+		return nil, true
+	}
+	t, ok := n.(Term)
+	return t, ok
+}
+
+// creates the node of a rule from the nodes of its symbols
+func build(rule gold.RuleId, span Span, children []Node) (Node, error) {
+	switch rule {
+	case 0: // <Program> ::= <Stmts>
+		if len(children) != 1 {
+			break
+		}
+		n := &ProgramStmts{Pos: span}
+		var ok bool
+		if n.Stmts, ok = asStmts(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		return n, nil
+	case 1: // <Stmts> ::= <Stmt> <Stmts>
+		if len(children) != 2 {
+			break
+		}
+		n := &StmtsStmtStmts{Pos: span}
+		var ok bool
+		if n.Stmt, ok = asStmt(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Stmts, ok = asStmts(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		return n, nil
+	case 2: // <Stmts> ::=
+		if len(children) != 0 {
+			break
+		}
+		n := &StmtsEmpty{Pos: span}
+		return n, nil
+	case 3: // <Stmt> ::= Identifier = <Expr> ;
+		if len(children) != 4 {
+			break
+		}
+		n := &StmtIdentifierEqSemi{Pos: span}
+		var ok bool
+		if n.Identifier, ok = asTerminal(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Eq, ok = asTerminal(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		if n.Expr, ok = asExpr(children[2]); !ok {
+			return nil, nodeError(rule, 2, children[2])
+		}
+		if n.Semi, ok = asTerminal(children[3]); !ok {
+			return nil, nodeError(rule, 3, children[3])
+		}
+		return n, nil
+	case 4: // <Stmt> ::= print <Expr> ;
+		if len(children) != 3 {
+			break
+		}
+		n := &StmtPrintSemi{Pos: span}
+		var ok bool
+		if n.Print, ok = asTerminal(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Expr, ok = asExpr(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		if n.Semi, ok = asTerminal(children[2]); !ok {
+			return nil, nodeError(rule, 2, children[2])
+		}
+		return n, nil
+	case 5: // <Expr> ::= <Expr> + <Term>
+		if len(children) != 3 {
+			break
+		}
+		n := &ExprPlus{Pos: span}
+		var ok bool
+		if n.Expr, ok = asExpr(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Plus, ok = asTerminal(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		if n.Term, ok = asTerm(children[2]); !ok {
+			return nil, nodeError(rule, 2, children[2])
+		}
+		return n, nil
+	case 6: // <Expr> ::= <Expr> - <Term>
+		if len(children) != 3 {
+			break
+		}
+		n := &ExprMinus{Pos: span}
+		var ok bool
+		if n.Expr, ok = asExpr(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Minus, ok = asTerminal(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		if n.Term, ok = asTerm(children[2]); !ok {
+			return nil, nodeError(rule, 2, children[2])
+		}
+		return n, nil
+	case 7: // <Expr> ::= <Term>
+		if len(children) != 1 {
+			break
+		}
+		n := &ExprTerm{Pos: span}
+		var ok bool
+		if n.Term, ok = asTerm(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		return n, nil
+	case 8: // <Term> ::= <Term> * <Factor>
+		if len(children) != 3 {
+			break
+		}
+		n := &TermTimes{Pos: span}
+		var ok bool
+		if n.Term, ok = asTerm(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Times, ok = asTerminal(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		if n.Factor, ok = asFactor(children[2]); !ok {
+			return nil, nodeError(rule, 2, children[2])
+		}
+		return n, nil
+	case 9: // <Term> ::= <Term> / <Factor>
+		if len(children) != 3 {
+			break
+		}
+		n := &TermDiv{Pos: span}
+		var ok bool
+		if n.Term, ok = asTerm(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Div, ok = asTerminal(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		if n.Factor, ok = asFactor(children[2]); !ok {
+			return nil, nodeError(rule, 2, children[2])
+		}
+		return n, nil
+	case 10: // <Term> ::= <Factor>
+		if len(children) != 1 {
+			break
+		}
+		n := &TermFactor{Pos: span}
+		var ok bool
+		if n.Factor, ok = asFactor(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		return n, nil
+	case 11: // <Factor> ::= Number
+		if len(children) != 1 {
+			break
+		}
+		n := &FactorNumber{Pos: span}
+		var ok bool
+		if n.Number, ok = asTerminal(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		return n, nil
+	case 12: // <Factor> ::= Identifier
+		if len(children) != 1 {
+			break
+		}
+		n := &FactorIdentifier{Pos: span}
+		var ok bool
+		if n.Identifier, ok = asTerminal(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		return n, nil
+	case 13: // <Factor> ::= ( <Expr> )
+		if len(children) != 3 {
+			break
+		}
+		n := &FactorLParenRParen{Pos: span}
+		var ok bool
+		if n.LParen, ok = asTerminal(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Expr, ok = asExpr(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		if n.RParen, ok = asTerminal(children[2]); !ok {
+			return nil, nodeError(rule, 2, children[2])
+		}
+		return n, nil
+	case 14: // <Factor> ::= - <Factor>
+		if len(children) != 2 {
+			break
+		}
+		n := &FactorMinus{Pos: span}
+		var ok bool
+		if n.Minus, ok = asTerminal(children[0]); !ok {
+			return nil, nodeError(rule, 0, children[0])
+		}
+		if n.Factor, ok = asFactor(children[1]); !ok {
+			return nil, nodeError(rule, 1, children[1])
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unknown rule %d", rule)
+	}
+	return nil, fmt.Errorf("the rule %d has %d nodes", rule, len(children))
+}
@@ -0,0 +1,147 @@
+package calc_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+	"github.com/boombuler/gold/codegen/internal/calc"
+)
+
+// prints the types of the nodes and the texts of the terminals in one line
+func nodeString(n calc.Node) string {
+	if t, ok := n.(*calc.Terminal); ok {
+		return t.Text
+	}
+	parts := []string{strings.TrimPrefix(fmt.Sprintf("%T", n), "*calc.")}
+	for _, c := range n.Children() {
+		parts = append(parts, nodeString(c))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+const astInput = "x = 1 + 2;\nprint -(x * 3);"
+
+const astTree = "(ProgramStmts (StmtsStmtStmts (StmtIdentifierEqSemi x = (ExprPlus (ExprTerm (TermFactor (FactorNumber 1))) + " +
+	"(TermFactor (FactorNumber 2))) ;) (StmtsStmtStmts (StmtPrintSemi print (ExprTerm (TermFactor (FactorMinus - " +
+	"(FactorLParenRParen ( (ExprTerm (TermTimes (TermFactor (FactorIdentifier x)) * (FactorNumber 3))) ))))) ;) (StmtsEmpty))))"
+
+func TestConvert(t *testing.T) {
+	tree, err := calc.Parser().Parse(strings.NewReader(astInput), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := calc.Convert(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nodeString(n); got != astTree {
+		t.Errorf("got\n%s\nwant\n%s", got, astTree)
+	}
+
+	stmt := n.(*calc.ProgramStmts).Stmts.(*calc.StmtsStmtStmts).Stmt.(*calc.StmtIdentifierEqSemi)
+	if stmt.Identifier.Text != "x" || stmt.Identifier.Symbol != 14 {
+		t.Errorf("got the identifier %+v", stmt.Identifier)
+	}
+	if stmt.Pos.Start.Offset != 0 || stmt.Pos.End.Offset != 10 || stmt.Expr.NodeSpan().Start.Offset != 4 {
+		t.Errorf("got the spans %+v and %+v", stmt.Pos, stmt.Expr.NodeSpan())
+	}
+	if n, err := calc.Convert(nil); n != nil || err != nil {
+		t.Errorf("converted nil to %v, %v", n, err)
+	}
+}
+
+func TestConvertTrimmedTree(t *testing.T) {
+	tree, err := calc.Parser().ParseContext(context.Background(), strings.NewReader(astInput), gold.TrimReductions(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := calc.Convert(tree); err == nil {
+		t.Error("converted a trimmed tree")
+	}
+}
+
+func TestConvertErrorNodes(t *testing.T) {
+	tree, err := calc.Parser().ParseContext(context.Background(), strings.NewReader("x = (1 + 2 print x;"), gold.RecoverErrors())
+	if _, ok := err.(gold.ParseErrors); !ok || tree == nil {
+		t.Fatalf("got %v", err)
+	}
+	n, err := calc.Convert(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := n.(*calc.ProgramStmts).Stmts.(*calc.StmtsStmtStmts).Stmt
+	errNode, ok := stmt.(*calc.ErrorNode)
+	if !ok || errNode.Symbol != 22 {
+		t.Fatalf("got %s, want an error node for <Stmt>", nodeString(stmt))
+	}
+	if got := nodeString(errNode); got != "(ErrorNode x = ( (ExprTerm (TermFactor (FactorNumber 1))) + 2)" {
+		t.Errorf("got the error node %s", got)
+	}
+}
+
+func TestActions(t *testing.T) {
+	v, err := calc.Parser().Evaluate(context.Background(), strings.NewReader(astInput), calc.Actions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := v.(calc.Node)
+	if got := nodeString(n); got != astTree {
+		t.Errorf("got\n%s\nwant\n%s", got, astTree)
+	}
+
+	tree, err := calc.Parser().Parse(strings.NewReader(astInput), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := calc.Convert(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the nodes have the same spans as the converted nodes, only the empty rules have no position
+	var compare func(a, b calc.Node)
+	compare = func(a, b calc.Node) {
+		if _, empty := a.(*calc.StmtsEmpty); !empty && a.NodeSpan() != b.NodeSpan() {
+			t.Errorf("%s has the span %+v, want %+v", nodeString(a), a.NodeSpan(), b.NodeSpan())
+		}
+		for i, c := range a.Children() {
+			compare(c, b.Children()[i])
+		}
+	}
+	compare(n, converted)
+}
+
+// counts the visited nodes by their type
+type countVisitor struct {
+	calc.BaseVisitor
+	terminals, plus, numbers int
+}
+
+func (v *countVisitor) VisitTerminal(n *calc.Terminal)         { v.terminals++ }
+func (v *countVisitor) VisitExprPlus(n *calc.ExprPlus)         { v.plus++ }
+func (v *countVisitor) VisitFactorNumber(n *calc.FactorNumber) { v.numbers++ }
+
+func TestVisitor(t *testing.T) {
+	tree, err := calc.Parser().Parse(strings.NewReader(astInput), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := calc.Convert(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := new(countVisitor)
+	var walk func(n calc.Node)
+	walk = func(n calc.Node) {
+		n.Accept(v)
+		for _, c := range n.Children() {
+			walk(c)
+		}
+	}
+	walk(n)
+	if v.terminals != 14 || v.plus != 1 || v.numbers != 3 {
+		t.Errorf("visited %d terminals, %d additions and %d numbers", v.terminals, v.plus, v.numbers)
+	}
+}
@@ -0,0 +1,143 @@
+package calc_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/gold"
+	"github.com/boombuler/gold/codegen/internal/calc"
+)
+
+func loadTables(t *testing.T) *gold.Tables {
+	t.Helper()
+	f, err := os.Open("../../../testdata/calc.egt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tables, err := gold.ReadTables(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tables
+}
+
+func TestTables(t *testing.T) {
+	// the tables are compared by their files, because the generated code has no empty slices
+	got, want := new(bytes.Buffer), new(bytes.Buffer)
+	if err := calc.Tables().WriteEGT(got); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadTables(t).WriteEGT(want); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Error("the generated tables differ from the grammar file")
+	}
+	// the result is a copy
+	calc.Tables().Symbols[0].Name = "changed"
+	if calc.Tables().Symbols[0].Name == "changed" {
+		t.Error("Tables returned the generated tables")
+	}
+}
+
+// prints the names and the texts of the tree in one line
+func treeString(tok *gold.Token) string {
+	if tok.IsTerminal {
+		return tok.Text
+	}
+	parts := []string{tok.Name}
+	for _, c := range tok.Tokens {
+		parts = append(parts, treeString(c))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func TestParser(t *testing.T) {
+	const input = "x = 1 + 2 * 3; // a comment\nprint (x - 4.5) / 2;"
+	loaded, err := gold.NewParserFromTables(loadTables(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calc.Parser() != calc.Parser() {
+		t.Error("Parser returned another parser on the second call")
+	}
+	got, err := calc.Parser().Parse(strings.NewReader(input), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := loaded.Parse(strings.NewReader(input), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if treeString(got) != treeString(want) {
+		t.Errorf("got the tree\n%s\nwant\n%s", treeString(got), treeString(want))
+	}
+	if s := treeString(got); s != "(<Stmts> (<Stmt> x = (<Expr> (<Factor> 1) + (<Term> (<Factor> 2) * (<Factor> 3))) ;) "+
+		"(<Stmts> (<Stmt> print (<Term> (<Factor> ( (<Expr> (<Factor> x) - (<Factor> 4.5)) )) / (<Factor> 2)) ;) (<Stmts>)))" {
+		t.Errorf("got the tree %s", s)
+	}
+}
+
+func TestConstants(t *testing.T) {
+	g := calc.Parser().Grammar()
+	if err := calc.VerifyGrammar(g); err != nil {
+		t.Fatal(err)
+	}
+	for _, sym := range []gold.SymbolId{calc.SymIdentifier, calc.SymLParen, calc.SymPrint, calc.SymStmts} {
+		if s := g.Symbol(sym); s.String() != calc.SymbolName(sym) {
+			t.Errorf("the constant %s is the symbol %s", calc.SymbolName(sym), s)
+		}
+	}
+	for _, rule := range []gold.RuleId{calc.RuleStmtsEmpty, calc.RuleExprPlus, calc.RuleFactorLParenRParen} {
+		if r := g.Rule(rule); r.String() != calc.RuleName(rule) {
+			t.Errorf("the constant %s is the rule %s", calc.RuleName(rule), r)
+		}
+	}
+	if calc.SymbolName(25) != "SymbolId(25)" || calc.RuleName(15) != "RuleId(15)" {
+		t.Errorf("got the names %s and %s of unknown ids", calc.SymbolName(25), calc.RuleName(15))
+	}
+
+	// the constants are compared with the ids of the tokens without conversion
+	tree, err := calc.Parser().Parse(strings.NewReader("print 1 + 2;"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := tree.Tokens[0].Tokens[0]
+	if stmt.Symbol != calc.SymStmt || stmt.Rule != calc.RuleStmtPrintSemi || stmt.Tokens[1].Rule != calc.RuleExprPlus {
+		t.Errorf("got the statement %s with the rule %s", calc.SymbolName(stmt.Symbol), calc.RuleName(stmt.Rule))
+	}
+	var numbers []string
+	actions := gold.NewActions().OnRule(calc.RuleFactorNumber, func(rule gold.RuleId, values []interface{}) (interface{}, error) {
+		numbers = append(numbers, values[0].(gold.Terminal).Text)
+		return nil, nil
+	})
+	if _, err := calc.Parser().Evaluate(context.Background(), strings.NewReader("print 1 + 2;"), actions); err != nil || len(numbers) != 2 {
+		t.Errorf("got the numbers %v, %v", numbers, err)
+	}
+}
+
+func TestVerifyGrammar(t *testing.T) {
+	tables := calc.Tables()
+	tables.Symbols[calc.SymPrint].Name = "show"
+	p, err := gold.NewParserFromTables(tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.VerifyGrammar(p.Grammar()); err == nil || err.Error() != "the symbol 17 of the grammar is show, but print is expected" {
+		t.Errorf("got %v", err)
+	}
+
+	tables = calc.Tables()
+	tables.Symbols = append(tables.Symbols, gold.TableSymbol{Name: "Unused", Kind: gold.KindNonTerminal})
+	if p, err = gold.NewParserFromTables(tables); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.VerifyGrammar(p.Grammar()); err == nil ||
+		err.Error() != "the grammar has 26 symbols and 15 rules, but 25 symbols and 15 rules are expected" {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Code generated by goldgen from calc.egt. DO NOT EDIT.
+
+package calc
+
+import (
+	"fmt"
+
+	"github.com/boombuler/gold"
+)
+
+// the symbols of the Calc grammar
+const (
+	SymEOF          gold.SymbolId = 0  // EOF
+	SymError        gold.SymbolId = 1  // Error
+	SymLParen       gold.SymbolId = 2  // (
+	SymRParen       gold.SymbolId = 3  // )
+	SymTimes        gold.SymbolId = 4  // *
+	SymPlus         gold.SymbolId = 5  // +
+	SymMinus        gold.SymbolId = 6  // -
+	SymDiv          gold.SymbolId = 7  // /
+	SymSemi         gold.SymbolId = 8  // ;
+	SymEq           gold.SymbolId = 9  // =
+	SymComment      gold.SymbolId = 10 // Comment
+	SymCommentEnd   gold.SymbolId = 11 // Comment End
+	SymCommentLine  gold.SymbolId = 12 // Comment Line
+	SymCommentStart gold.SymbolId = 13 // Comment Start
+	SymIdentifier   gold.SymbolId = 14 // Identifier
+	SymNewLine      gold.SymbolId = 15 // NewLine
+	SymNumber       gold.SymbolId = 16 // Number
+	SymPrint        gold.SymbolId = 17 // print
+	SymWhitespace   gold.SymbolId = 18 // Whitespace
+	SymExpr         gold.SymbolId = 19 // <Expr>
+	SymFactor       gold.SymbolId = 20 // <Factor>
+	SymProgram      gold.SymbolId = 21 // <Program>
+	SymStmt         gold.SymbolId = 22 // <Stmt>
+	SymStmts        gold.SymbolId = 23 // <Stmts>
+	SymTerm         gold.SymbolId = 24 // <Term>
+)
+
+var symbolNames = [...]string{
+	"EOF",
+	"Error",
+	"(",
+	")",
+	"*",
+	"+",
+	"-",
+	"/",
+	";",
+	"=",
+	"Comment",
+	"Comment End",
+	"Comment Line",
+	"Comment Start",
+	"Identifier",
+	"NewLine",
+	"Number",
+	"print",
+	"Whitespace",
+	"<Expr>",
+	"<Factor>",
+	"<Program>",
+	"<Stmt>",
+	"<Stmts>",
+	"<Term>",
+}
+
+// returns the name of the symbol, non-terminals are enclosed in angle brackets
+func SymbolName(id gold.SymbolId) string {
+	if int(id) < len(symbolNames) {
+		return symbolNames[id]
+	}
+	return fmt.Sprintf("SymbolId(%d)", id)
+}
+
+// the rules of the Calc grammar
+const (
+	RuleProgramStmts         gold.RuleId = 0  // <Program> ::= <Stmts>
+	RuleStmtsStmtStmts       gold.RuleId = 1  // <Stmts> ::= <Stmt> <Stmts>
+	RuleStmtsEmpty           gold.RuleId = 2  // <Stmts> ::=
+	RuleStmtIdentifierEqSemi gold.RuleId = 3  // <Stmt> ::= Identifier = <Expr> ;
+	RuleStmtPrintSemi        gold.RuleId = 4  // <Stmt> ::= print <Expr> ;
+	RuleExprPlus             gold.RuleId = 5  // <Expr> ::= <Expr> + <Term>
+	RuleExprMinus            gold.RuleId = 6  // <Expr> ::= <Expr> - <Term>
+	RuleExprTerm             gold.RuleId = 7  // <Expr> ::= <Term>
+	RuleTermTimes            gold.RuleId = 8  // <Term> ::= <Term> * <Factor>
+	RuleTermDiv              gold.RuleId = 9  // <Term> ::= <Term> / <Factor>
+	RuleTermFactor           gold.RuleId = 10 // <Term> ::= <Factor>
+	RuleFactorNumber         gold.RuleId = 11 // <Factor> ::= Number
+	RuleFactorIdentifier     gold.RuleId = 12 // <Factor> ::= Identifier
+	RuleFactorLParenRParen   gold.RuleId = 13 // <Factor> ::= ( <Expr> )
+	RuleFactorMinus          gold.RuleId = 14 // <Factor> ::= - <Factor>
+)
+
+var ruleNames = [...]string{
+	"<Program> ::= <Stmts>",
+	"<Stmts> ::= <Stmt> <Stmts>",
+	"<Stmts> ::= ",
+	"<Stmt> ::= Identifier = <Expr> ;",
+	"<Stmt> ::= print <Expr> ;",
+	"<Expr> ::= <Expr> + <Term>",
+	"<Expr> ::= <Expr> - <Term>",
+	"<Expr> ::= <Term>",
+	"<Term> ::= <Term> * <Factor>",
+	"<Term> ::= <Term> / <Factor>",
+	"<Term> ::= <Factor>",
+	"<Factor> ::= Number",
+	"<Factor> ::= Identifier",
+	"<Factor> ::= ( <Expr> )",
+	"<Factor> ::= - <Factor>",
+}
+
+// returns the rule in BNF notation
+func RuleName(id gold.RuleId) string {
+	if int(id) < len(ruleNames) {
+		return ruleNames[id]
+	}
+	return fmt.Sprintf("RuleId(%d)", id)
+}
+
+// checks that the symbols and rules of the grammar match the constants, for example after
+// the grammar file was rebuilt.
+func VerifyGrammar(g gold.Grammar) error {
+	symbols, rules := g.Symbols(), g.Rules()
+	if len(symbols) != len(symbolNames) || len(rules) != len(ruleNames) {
+		return fmt.Errorf("the grammar has %d symbols and %d rules, but %d symbols and %d rules are expected",
+			len(symbols), len(rules), len(symbolNames), len(ruleNames))
+	}
+	for i, s := range symbols {
+		if s.String() != symbolNames[i] {
+			return fmt.Errorf("the symbol %d of the grammar is %s, but %s is expected", i, s, symbolNames[i])
+		}
+	}
+	for i, r := range rules {
+		if r.String() != ruleNames[i] {
+			return fmt.Errorf("the rule %d of the grammar is %s, but %s is expected", i, r, ruleNames[i])
+		}
+	}
+	return nil
+}
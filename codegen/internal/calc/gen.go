@@ -0,0 +1,7 @@
+// Package calc contains the code generated by goldgen for the calculator grammar in testdata.
+// It is used to test the generated code.
+package calc
+
+//go:generate go run ../../../cmd/goldgen -o tables.go ../../../testdata/calc.egt
+//go:generate go run ../../../cmd/goldgen -gen constants -o constants.go ../../../testdata/calc.egt
+//go:generate go run ../../../cmd/goldgen -gen ast -o ast.go ../../../testdata/calc.egt
@@ -0,0 +1,363 @@
+// Code generated by goldgen from calc.egt. DO NOT EDIT.
+
+package calc
+
+import (
+	"sync"
+
+	"github.com/boombuler/gold"
+)
+
+var tables = &gold.Tables{
+	Properties: []gold.TableProperty{
+		{Name: "Name", Value: "Calc"},
+		{Name: "Version", Value: ""},
+		{Name: "Author", Value: ""},
+		{Name: "About", Value: ""},
+		{Name: "Character Set", Value: "Unicode"},
+		{Name: "Character Mapping", Value: "None"},
+		{Name: "Generated By", Value: "github.com/boombuler/gold/builder"},
+	},
+	CaseSensitive:   false,
+	StartSymbol:     21,
+	InitialDFAState: 0,
+	InitialLRState:  0,
+	CharSets: []gold.TableCharSet{
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0009, End: 0x0009}, {Start: 0x000B, End: 0x000C}, {Start: 0x0020, End: 0x0020}, {Start: 0x00A0, End: 0x00A0}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x000A, End: 0x000A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x000D, End: 0x000D}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0028, End: 0x0028}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0029, End: 0x0029}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x002A, End: 0x002A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x002B, End: 0x002B}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x002D, End: 0x002D}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x002F, End: 0x002F}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0030, End: 0x0039}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x003B, End: 0x003B}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x003D, End: 0x003D}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0041, End: 0x004F}, {Start: 0x0051, End: 0x005A}, {Start: 0x005F, End: 0x005F}, {Start: 0x0061, End: 0x006F}, {Start: 0x0071, End: 0x007A}, {Start: 0x017F, End: 0x017F}, {Start: 0x212A, End: 0x212A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0050, End: 0x0050}, {Start: 0x0070, End: 0x0070}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x002E, End: 0x002E}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0030, End: 0x0039}, {Start: 0x0041, End: 0x005A}, {Start: 0x005F, End: 0x005F}, {Start: 0x0061, End: 0x007A}, {Start: 0x017F, End: 0x017F}, {Start: 0x212A, End: 0x212A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0030, End: 0x0039}, {Start: 0x0041, End: 0x0051}, {Start: 0x0053, End: 0x005A}, {Start: 0x005F, End: 0x005F}, {Start: 0x0061, End: 0x0071}, {Start: 0x0073, End: 0x007A}, {Start: 0x017F, End: 0x017F}, {Start: 0x212A, End: 0x212A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0052, End: 0x0052}, {Start: 0x0072, End: 0x0072}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0030, End: 0x0039}, {Start: 0x0041, End: 0x0048}, {Start: 0x004A, End: 0x005A}, {Start: 0x005F, End: 0x005F}, {Start: 0x0061, End: 0x0068}, {Start: 0x006A, End: 0x007A}, {Start: 0x017F, End: 0x017F}, {Start: 0x212A, End: 0x212A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0049, End: 0x0049}, {Start: 0x0069, End: 0x0069}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0030, End: 0x0039}, {Start: 0x0041, End: 0x004D}, {Start: 0x004F, End: 0x005A}, {Start: 0x005F, End: 0x005F}, {Start: 0x0061, End: 0x006D}, {Start: 0x006F, End: 0x007A}, {Start: 0x017F, End: 0x017F}, {Start: 0x212A, End: 0x212A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x004E, End: 0x004E}, {Start: 0x006E, End: 0x006E}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0030, End: 0x0039}, {Start: 0x0041, End: 0x0053}, {Start: 0x0055, End: 0x005A}, {Start: 0x005F, End: 0x005F}, {Start: 0x0061, End: 0x0073}, {Start: 0x0075, End: 0x007A}, {Start: 0x017F, End: 0x017F}, {Start: 0x212A, End: 0x212A}}},
+		{Plane: 0, Ranges: []gold.TableCharRange{{Start: 0x0054, End: 0x0054}, {Start: 0x0074, End: 0x0074}}},
+	},
+	Symbols: []gold.TableSymbol{
+		0:  {Name: "EOF", Kind: gold.KindEnd},
+		1:  {Name: "Error", Kind: gold.KindError},
+		2:  {Name: "(", Kind: gold.KindTerminal},
+		3:  {Name: ")", Kind: gold.KindTerminal},
+		4:  {Name: "*", Kind: gold.KindTerminal},
+		5:  {Name: "+", Kind: gold.KindTerminal},
+		6:  {Name: "-", Kind: gold.KindTerminal},
+		7:  {Name: "/", Kind: gold.KindTerminal},
+		8:  {Name: ";", Kind: gold.KindTerminal},
+		9:  {Name: "=", Kind: gold.KindTerminal},
+		10: {Name: "Comment", Kind: gold.KindNoise},
+		11: {Name: "Comment End", Kind: gold.KindGroupEnd},
+		12: {Name: "Comment Line", Kind: gold.KindGroupStart},
+		13: {Name: "Comment Start", Kind: gold.KindGroupStart},
+		14: {Name: "Identifier", Kind: gold.KindTerminal},
+		15: {Name: "NewLine", Kind: gold.KindNoise},
+		16: {Name: "Number", Kind: gold.KindTerminal},
+		17: {Name: "print", Kind: gold.KindTerminal},
+		18: {Name: "Whitespace", Kind: gold.KindNoise},
+		19: {Name: "Expr", Kind: gold.KindNonTerminal},
+		20: {Name: "Factor", Kind: gold.KindNonTerminal},
+		21: {Name: "Program", Kind: gold.KindNonTerminal},
+		22: {Name: "Stmt", Kind: gold.KindNonTerminal},
+		23: {Name: "Stmts", Kind: gold.KindNonTerminal},
+		24: {Name: "Term", Kind: gold.KindNonTerminal},
+	},
+	Groups: []gold.TableGroup{
+		0: {Name: "Comment Line", Container: 10, Start: 12, End: 15, AdvanceMode: gold.AdvanceCharacter, EndingMode: gold.EndingOpen},
+		1: {Name: "Comment Block", Container: 10, Start: 13, End: 11, AdvanceMode: gold.AdvanceCharacter, EndingMode: gold.EndingClosed},
+	},
+	Rules: []gold.TableRule{
+		// <Program> ::= <Stmts>
+		0: {Head: 21, Symbols: []gold.SymbolId{23}},
+		// <Stmts> ::= <Stmt> <Stmts>
+		1: {Head: 23, Symbols: []gold.SymbolId{22, 23}},
+		// <Stmts> ::=
+		2: {Head: 23, Symbols: []gold.SymbolId{}},
+		// <Stmt> ::= Identifier = <Expr> ;
+		3: {Head: 22, Symbols: []gold.SymbolId{14, 9, 19, 8}},
+		// <Stmt> ::= print <Expr> ;
+		4: {Head: 22, Symbols: []gold.SymbolId{17, 19, 8}},
+		// <Expr> ::= <Expr> + <Term>
+		5: {Head: 19, Symbols: []gold.SymbolId{19, 5, 24}},
+		// <Expr> ::= <Expr> - <Term>
+		6: {Head: 19, Symbols: []gold.SymbolId{19, 6, 24}},
+		// <Expr> ::= <Term>
+		7: {Head: 19, Symbols: []gold.SymbolId{24}},
+		// <Term> ::= <Term> * <Factor>
+		8: {Head: 24, Symbols: []gold.SymbolId{24, 4, 20}},
+		// <Term> ::= <Term> / <Factor>
+		9: {Head: 24, Symbols: []gold.SymbolId{24, 7, 20}},
+		// <Term> ::= <Factor>
+		10: {Head: 24, Symbols: []gold.SymbolId{20}},
+		// <Factor> ::= Number
+		11: {Head: 20, Symbols: []gold.SymbolId{16}},
+		// <Factor> ::= Identifier
+		12: {Head: 20, Symbols: []gold.SymbolId{14}},
+		// <Factor> ::= ( <Expr> )
+		13: {Head: 20, Symbols: []gold.SymbolId{2, 19, 3}},
+		// <Factor> ::= - <Factor>
+		14: {Head: 20, Symbols: []gold.SymbolId{6, 20}},
+	},
+	DFAStates: []gold.TableDFAState{
+		0:  {Edges: []gold.TableDFAEdge{{CharSet: 0, Target: 1}, {CharSet: 1, Target: 2}, {CharSet: 2, Target: 3}, {CharSet: 3, Target: 4}, {CharSet: 4, Target: 5}, {CharSet: 5, Target: 6}, {CharSet: 6, Target: 7}, {CharSet: 7, Target: 8}, {CharSet: 8, Target: 9}, {CharSet: 9, Target: 10}, {CharSet: 10, Target: 11}, {CharSet: 11, Target: 12}, {CharSet: 12, Target: 13}, {CharSet: 13, Target: 14}}},
+		1:  {Accept: true, AcceptSymbol: 18, Edges: []gold.TableDFAEdge{{CharSet: 0, Target: 1}}},
+		2:  {Accept: true, AcceptSymbol: 15, Edges: []gold.TableDFAEdge{}},
+		3:  {Accept: true, AcceptSymbol: 15, Edges: []gold.TableDFAEdge{{CharSet: 1, Target: 15}}},
+		4:  {Accept: true, AcceptSymbol: 2, Edges: []gold.TableDFAEdge{}},
+		5:  {Accept: true, AcceptSymbol: 3, Edges: []gold.TableDFAEdge{}},
+		6:  {Accept: true, AcceptSymbol: 4, Edges: []gold.TableDFAEdge{{CharSet: 8, Target: 16}}},
+		7:  {Accept: true, AcceptSymbol: 5, Edges: []gold.TableDFAEdge{}},
+		8:  {Accept: true, AcceptSymbol: 6, Edges: []gold.TableDFAEdge{}},
+		9:  {Accept: true, AcceptSymbol: 7, Edges: []gold.TableDFAEdge{{CharSet: 5, Target: 17}, {CharSet: 8, Target: 18}}},
+		10: {Accept: true, AcceptSymbol: 16, Edges: []gold.TableDFAEdge{{CharSet: 14, Target: 19}, {CharSet: 9, Target: 10}}},
+		11: {Accept: true, AcceptSymbol: 8, Edges: []gold.TableDFAEdge{}},
+		12: {Accept: true, AcceptSymbol: 9, Edges: []gold.TableDFAEdge{}},
+		13: {Accept: true, AcceptSymbol: 14, Edges: []gold.TableDFAEdge{{CharSet: 15, Target: 20}}},
+		14: {Accept: true, AcceptSymbol: 14, Edges: []gold.TableDFAEdge{{CharSet: 16, Target: 20}, {CharSet: 17, Target: 21}}},
+		15: {Accept: true, AcceptSymbol: 15, Edges: []gold.TableDFAEdge{}},
+		16: {Accept: true, AcceptSymbol: 11, Edges: []gold.TableDFAEdge{}},
+		17: {Accept: true, AcceptSymbol: 13, Edges: []gold.TableDFAEdge{}},
+		18: {Accept: true, AcceptSymbol: 12, Edges: []gold.TableDFAEdge{}},
+		19: {Edges: []gold.TableDFAEdge{{CharSet: 9, Target: 22}}},
+		20: {Accept: true, AcceptSymbol: 14, Edges: []gold.TableDFAEdge{{CharSet: 15, Target: 20}}},
+		21: {Accept: true, AcceptSymbol: 14, Edges: []gold.TableDFAEdge{{CharSet: 18, Target: 20}, {CharSet: 19, Target: 23}}},
+		22: {Accept: true, AcceptSymbol: 16, Edges: []gold.TableDFAEdge{{CharSet: 9, Target: 22}}},
+		23: {Accept: true, AcceptSymbol: 14, Edges: []gold.TableDFAEdge{{CharSet: 20, Target: 20}, {CharSet: 21, Target: 24}}},
+		24: {Accept: true, AcceptSymbol: 14, Edges: []gold.TableDFAEdge{{CharSet: 22, Target: 20}, {CharSet: 23, Target: 25}}},
+		25: {Accept: true, AcceptSymbol: 17, Edges: []gold.TableDFAEdge{{CharSet: 15, Target: 20}}},
+	},
+	LRStates: []gold.TableLRState{
+		0: {Actions: []gold.TableLRAction{
+			{Symbol: 0, Action: gold.ActionReduce, Target: 2}, // EOF
+			{Symbol: 14, Action: gold.ActionShift, Target: 1}, // Identifier
+			{Symbol: 17, Action: gold.ActionShift, Target: 2}, // print
+			{Symbol: 21, Action: gold.ActionGoto, Target: 3},  // <Program>
+			{Symbol: 22, Action: gold.ActionGoto, Target: 4},  // <Stmt>
+			{Symbol: 23, Action: gold.ActionGoto, Target: 5},  // <Stmts>
+		}},
+		1: {Actions: []gold.TableLRAction{
+			{Symbol: 9, Action: gold.ActionShift, Target: 6}, // =
+		}},
+		2: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 19, Action: gold.ActionGoto, Target: 11},  // <Expr>
+			{Symbol: 20, Action: gold.ActionGoto, Target: 12},  // <Factor>
+			{Symbol: 24, Action: gold.ActionGoto, Target: 13},  // <Term>
+		}},
+		3: {Actions: []gold.TableLRAction{
+			{Symbol: 0, Action: gold.ActionAccept, Target: 0}, // EOF
+		}},
+		4: {Actions: []gold.TableLRAction{
+			{Symbol: 0, Action: gold.ActionReduce, Target: 2}, // EOF
+			{Symbol: 14, Action: gold.ActionShift, Target: 1}, // Identifier
+			{Symbol: 17, Action: gold.ActionShift, Target: 2}, // print
+			{Symbol: 22, Action: gold.ActionGoto, Target: 4},  // <Stmt>
+			{Symbol: 23, Action: gold.ActionGoto, Target: 14}, // <Stmts>
+		}},
+		5: {Actions: []gold.TableLRAction{
+			{Symbol: 0, Action: gold.ActionReduce, Target: 0}, // EOF
+		}},
+		6: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 19, Action: gold.ActionGoto, Target: 15},  // <Expr>
+			{Symbol: 20, Action: gold.ActionGoto, Target: 12},  // <Factor>
+			{Symbol: 24, Action: gold.ActionGoto, Target: 13},  // <Term>
+		}},
+		7: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 19, Action: gold.ActionGoto, Target: 16},  // <Expr>
+			{Symbol: 20, Action: gold.ActionGoto, Target: 12},  // <Factor>
+			{Symbol: 24, Action: gold.ActionGoto, Target: 13},  // <Term>
+		}},
+		8: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 20, Action: gold.ActionGoto, Target: 17},  // <Factor>
+		}},
+		9: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 12}, // )
+			{Symbol: 4, Action: gold.ActionReduce, Target: 12}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 12}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 12}, // -
+			{Symbol: 7, Action: gold.ActionReduce, Target: 12}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 12}, // ;
+		}},
+		10: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 11}, // )
+			{Symbol: 4, Action: gold.ActionReduce, Target: 11}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 11}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 11}, // -
+			{Symbol: 7, Action: gold.ActionReduce, Target: 11}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 11}, // ;
+		}},
+		11: {Actions: []gold.TableLRAction{
+			{Symbol: 5, Action: gold.ActionShift, Target: 18}, // +
+			{Symbol: 6, Action: gold.ActionShift, Target: 19}, // -
+			{Symbol: 8, Action: gold.ActionShift, Target: 20}, // ;
+		}},
+		12: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 10}, // )
+			{Symbol: 4, Action: gold.ActionReduce, Target: 10}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 10}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 10}, // -
+			{Symbol: 7, Action: gold.ActionReduce, Target: 10}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 10}, // ;
+		}},
+		13: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 7}, // )
+			{Symbol: 4, Action: gold.ActionShift, Target: 21}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 7}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 7}, // -
+			{Symbol: 7, Action: gold.ActionShift, Target: 22}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 7}, // ;
+		}},
+		14: {Actions: []gold.TableLRAction{
+			{Symbol: 0, Action: gold.ActionReduce, Target: 1}, // EOF
+		}},
+		15: {Actions: []gold.TableLRAction{
+			{Symbol: 5, Action: gold.ActionShift, Target: 18}, // +
+			{Symbol: 6, Action: gold.ActionShift, Target: 19}, // -
+			{Symbol: 8, Action: gold.ActionShift, Target: 23}, // ;
+		}},
+		16: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionShift, Target: 24}, // )
+			{Symbol: 5, Action: gold.ActionShift, Target: 18}, // +
+			{Symbol: 6, Action: gold.ActionShift, Target: 19}, // -
+		}},
+		17: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 14}, // )
+			{Symbol: 4, Action: gold.ActionReduce, Target: 14}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 14}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 14}, // -
+			{Symbol: 7, Action: gold.ActionReduce, Target: 14}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 14}, // ;
+		}},
+		18: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 20, Action: gold.ActionGoto, Target: 12},  // <Factor>
+			{Symbol: 24, Action: gold.ActionGoto, Target: 25},  // <Term>
+		}},
+		19: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 20, Action: gold.ActionGoto, Target: 12},  // <Factor>
+			{Symbol: 24, Action: gold.ActionGoto, Target: 26},  // <Term>
+		}},
+		20: {Actions: []gold.TableLRAction{
+			{Symbol: 0, Action: gold.ActionReduce, Target: 4},  // EOF
+			{Symbol: 14, Action: gold.ActionReduce, Target: 4}, // Identifier
+			{Symbol: 17, Action: gold.ActionReduce, Target: 4}, // print
+		}},
+		21: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 20, Action: gold.ActionGoto, Target: 27},  // <Factor>
+		}},
+		22: {Actions: []gold.TableLRAction{
+			{Symbol: 2, Action: gold.ActionShift, Target: 7},   // (
+			{Symbol: 6, Action: gold.ActionShift, Target: 8},   // -
+			{Symbol: 14, Action: gold.ActionShift, Target: 9},  // Identifier
+			{Symbol: 16, Action: gold.ActionShift, Target: 10}, // Number
+			{Symbol: 20, Action: gold.ActionGoto, Target: 28},  // <Factor>
+		}},
+		23: {Actions: []gold.TableLRAction{
+			{Symbol: 0, Action: gold.ActionReduce, Target: 3},  // EOF
+			{Symbol: 14, Action: gold.ActionReduce, Target: 3}, // Identifier
+			{Symbol: 17, Action: gold.ActionReduce, Target: 3}, // print
+		}},
+		24: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 13}, // )
+			{Symbol: 4, Action: gold.ActionReduce, Target: 13}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 13}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 13}, // -
+			{Symbol: 7, Action: gold.ActionReduce, Target: 13}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 13}, // ;
+		}},
+		25: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 5}, // )
+			{Symbol: 4, Action: gold.ActionShift, Target: 21}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 5}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 5}, // -
+			{Symbol: 7, Action: gold.ActionShift, Target: 22}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 5}, // ;
+		}},
+		26: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 6}, // )
+			{Symbol: 4, Action: gold.ActionShift, Target: 21}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 6}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 6}, // -
+			{Symbol: 7, Action: gold.ActionShift, Target: 22}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 6}, // ;
+		}},
+		27: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 8}, // )
+			{Symbol: 4, Action: gold.ActionReduce, Target: 8}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 8}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 8}, // -
+			{Symbol: 7, Action: gold.ActionReduce, Target: 8}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 8}, // ;
+		}},
+		28: {Actions: []gold.TableLRAction{
+			{Symbol: 3, Action: gold.ActionReduce, Target: 9}, // )
+			{Symbol: 4, Action: gold.ActionReduce, Target: 9}, // *
+			{Symbol: 5, Action: gold.ActionReduce, Target: 9}, // +
+			{Symbol: 6, Action: gold.ActionReduce, Target: 9}, // -
+			{Symbol: 7, Action: gold.ActionReduce, Target: 9}, // /
+			{Symbol: 8, Action: gold.ActionReduce, Target: 9}, // ;
+		}},
+	},
+}
+
+var (
+	parserOnce sync.Once
+	parser     gold.Parser
+)
+
+// returns the parser for the Calc grammar. The parser is created on the first call and can be
+// used concurrently.
+func Parser() gold.Parser {
+	parserOnce.Do(func() {
+		var err error
+		if parser, err = gold.NewParserFromTables(tables); err != nil {
+			// the tables are validated when the code is generated
+			panic(err)
+		}
+	})
+	return parser
+}
+
+// returns a copy of the grammar tables
+func Tables() *gold.Tables {
+	return tables.Clone()
+}